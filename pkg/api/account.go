@@ -0,0 +1,67 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/CzarSimon/user-service/pkg/auth"
+	"github.com/CzarSimon/user-service/pkg/httputil"
+	"github.com/CzarSimon/user-service/pkg/models"
+	"github.com/CzarSimon/user-service/pkg/service"
+	"github.com/gin-gonic/gin"
+)
+
+const exportFilename = "account-export.json"
+
+type accountController struct {
+	svc service.AccountService
+}
+
+// AttachAccountRoutes attaches the routes for users to delete and export their accounts to a router.
+func AttachAccountRoutes(r gin.IRouter, svc service.AccountService, verifier auth.Verifier) {
+	ctrl := &accountController{svc: svc}
+	g := r.Group("/v1/users/:userId", httputil.Authenticate(verifier))
+
+	g.POST("/deletion", ctrl.deleteAccount)
+	g.GET("/export", ctrl.exportData)
+}
+
+func (ctrl *accountController) deleteAccount(c *gin.Context) {
+	principal, err := httputil.GetToken(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var req models.DeleteAccountRequest
+	err = c.ShouldBindJSON(&req)
+	if err != nil || req.Password == "" {
+		c.Error(errInvalidBody())
+		return
+	}
+	req.UserID = c.Param("userId")
+
+	err = ctrl.svc.DeleteAccount(c.Request.Context(), principal, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	httputil.SendOK(c)
+}
+
+func (ctrl *accountController) exportData(c *gin.Context) {
+	principal, err := httputil.GetToken(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	export, err := ctrl.svc.ExportData(c.Request.Context(), principal, c.Param("userId"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="`+exportFilename+`"`)
+	c.JSON(http.StatusOK, export)
+}
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/CzarSimon/user-service/pkg/auth"
+	"github.com/CzarSimon/user-service/pkg/httputil"
+	"github.com/CzarSimon/user-service/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockAccountService struct {
+	deleteArg models.DeleteAccountRequest
+	exportArg string
+}
+
+func (s *mockAccountService) DeleteAccount(ctx context.Context, principal auth.Token, req models.DeleteAccountRequest) error {
+	s.deleteArg = req
+	return nil
+}
+
+func (s *mockAccountService) ExportData(ctx context.Context, principal auth.Token, userID string) (models.AccountExport, error) {
+	s.exportArg = userID
+	return models.AccountExport{}, nil
+}
+
+func (s *mockAccountService) PurgeDeletedAccounts(ctx context.Context) (int, error) {
+	return 0, nil
+}
+
+func TestAccountRoutes(t *testing.T) {
+	assert := assert.New(t)
+	gin.SetMode(gin.TestMode)
+	svc := &mockAccountService{}
+	r := httputil.NewRouter("user-service", "1.0")
+	AttachAccountRoutes(r, svc, verifier)
+
+	token := issueToken(t, models.UserRole)
+	body := models.DeleteAccountRequest{Password: "secret"}
+	w := performRequest(r, http.MethodPost, "/v1/users/principal-id/deletion", token, body)
+	assert.Equal(http.StatusOK, w.Code)
+	assert.Equal("principal-id", svc.deleteArg.UserID)
+	assert.Equal("secret", svc.deleteArg.Password)
+
+	w = performRequest(r, http.MethodPost, "/v1/users/principal-id/deletion", token, models.DeleteAccountRequest{})
+	assert.Equal(http.StatusBadRequest, w.Code)
+
+	w = performRequest(r, http.MethodPost, "/v1/users/principal-id/deletion", "", body)
+	assert.Equal(http.StatusUnauthorized, w.Code)
+
+	w = performRequest(r, http.MethodGet, "/v1/users/principal-id/export", token, nil)
+	assert.Equal(http.StatusOK, w.Code)
+	assert.Equal("principal-id", svc.exportArg)
+	assert.Equal(`attachment; filename="account-export.json"`, w.Header().Get("Content-Disposition"))
+}
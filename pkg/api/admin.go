@@ -0,0 +1,153 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/CzarSimon/user-service/pkg/auth"
+	"github.com/CzarSimon/user-service/pkg/httputil"
+	"github.com/CzarSimon/user-service/pkg/models"
+	"github.com/CzarSimon/user-service/pkg/service"
+	"github.com/gin-gonic/gin"
+)
+
+type adminController struct {
+	svc service.UserService
+}
+
+// AttachAdminRoutes attaches the admin user management routes to a router.
+// All routes require an authenticated principal with the relevant permission.
+func AttachAdminRoutes(r gin.IRouter, svc service.UserService, verifier auth.Verifier) {
+	ctrl := &adminController{svc: svc}
+	g := r.Group("/v1/admin/users", httputil.Authenticate(verifier))
+
+	g.GET("", httputil.RequirePermission(models.ReadUsersPermission), ctrl.listUsers)
+	g.PUT("/:userId/roles", httputil.RequirePermission(models.AssignRolesPermission), ctrl.changeRole)
+	g.PUT("/:userId/disabled", httputil.RequirePermission(models.WriteUsersPermission), ctrl.disableUser)
+	g.DELETE("/:userId/disabled", httputil.RequirePermission(models.WriteUsersPermission), ctrl.enableUser)
+	g.DELETE("/:userId", httputil.RequirePermission(models.WriteUsersPermission), ctrl.deleteUser)
+}
+
+func (ctrl *adminController) listUsers(c *gin.Context) {
+	principal, err := httputil.GetToken(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	query, err := parseUserQuery(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	page, err := ctrl.svc.ListUsers(c.Request.Context(), principal, query)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+func (ctrl *adminController) changeRole(c *gin.Context) {
+	principal, err := httputil.GetToken(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var req models.ChangeRoleRequest
+	err = c.ShouldBindJSON(&req)
+	if err != nil {
+		c.Error(errInvalidBody())
+		return
+	}
+	req.UserID = c.Param("userId")
+
+	user, err := ctrl.svc.ChangeRole(c.Request.Context(), principal, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+func (ctrl *adminController) disableUser(c *gin.Context) {
+	ctrl.handleUserAction(c, ctrl.svc.DisableUser)
+}
+
+func (ctrl *adminController) enableUser(c *gin.Context) {
+	ctrl.handleUserAction(c, ctrl.svc.EnableUser)
+}
+
+func (ctrl *adminController) deleteUser(c *gin.Context) {
+	ctrl.handleUserAction(c, ctrl.svc.DeleteUser)
+}
+
+// handleUserAction performs an action on the user given in the path and responds with OK on success.
+func (ctrl *adminController) handleUserAction(c *gin.Context, action func(context.Context, auth.Token, string) error) {
+	principal, err := httputil.GetToken(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	err = action(c.Request.Context(), principal, c.Param("userId"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	httputil.SendOK(c)
+}
+
+func parseUserQuery(c *gin.Context) (models.UserQuery, error) {
+	query := models.UserQuery{
+		Role:        c.Query("role"),
+		EmailPrefix: c.Query("emailPrefix"),
+	}
+
+	var err error
+	query.CreatedAfter, err = parseTimeQuery(c, "createdAfter")
+	if err != nil {
+		return models.UserQuery{}, err
+	}
+
+	query.CreatedBefore, err = parseTimeQuery(c, "createdBefore")
+	if err != nil {
+		return models.UserQuery{}, err
+	}
+
+	query.After, err = models.DecodeUserCursor(c.Query("cursor"))
+	if err != nil {
+		return models.UserQuery{}, errInvalidQuery("Invalid cursor", "query.invalidCursor")
+	}
+
+	if limit, ok := c.GetQuery("limit"); ok {
+		query.Limit, err = strconv.Atoi(limit)
+		if err != nil || query.Limit < 1 {
+			return models.UserQuery{}, errInvalidQuery("Invalid limit", "query.invalidLimit")
+		}
+	}
+
+	return query, nil
+}
+
+// parseTimeQuery parses an optional RFC 3339 timestamp from the query.
+func parseTimeQuery(c *gin.Context, key string) (time.Time, error) {
+	value, ok := c.GetQuery(key)
+	if !ok {
+		return time.Time{}, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, errInvalidQuery("Invalid timestamp for param: "+key, "query.invalidTimestamp", key)
+	}
+
+	return t.UTC(), nil
+}
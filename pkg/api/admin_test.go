@@ -0,0 +1,167 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/CzarSimon/user-service/pkg/auth"
+	"github.com/CzarSimon/user-service/pkg/httputil"
+	"github.com/CzarSimon/user-service/pkg/models"
+	"github.com/CzarSimon/user-service/pkg/repository/repotest"
+	"github.com/CzarSimon/user-service/pkg/service"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+var creds = auth.JWTCredentials{
+	Issuer: "user-service-name",
+	Secret: "jwt-secret",
+}
+
+var issuer = auth.NewJWTIssuer(creds)
+
+var verifier = auth.NewJWTVerifier(creds, time.Minute)
+
+func TestAdminRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	user := models.NewUser("mail@mail.com", "Tester", "McTest", models.UserRole, models.Credentials{})
+	userRepo := &repotest.MockUserRepo{
+		FindUser:  user,
+		ListUsers: []models.User{user},
+	}
+	roleRepo := &repotest.MockRoleRepo{
+		FindByNamesRoles: models.DefaultRoles(),
+	}
+	svc := service.NewUserService(auth.NewHasher("secret-pepper"), issuer, userRepo, roleRepo)
+
+	r := httputil.NewRouter("user-service", "1.0")
+	AttachAdminRoutes(r, svc, verifier)
+
+	adminToken := issueToken(t, models.AdminRole, models.Permissions(models.DefaultRoles())...)
+	userToken := issueToken(t, models.UserRole)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		token      string
+		body       interface{}
+		wantStatus int
+	}{
+		{
+			name:       "list-users",
+			method:     http.MethodGet,
+			path:       "/v1/admin/users?role=USER&emailPrefix=mail&createdAfter=2019-01-01T00:00:00Z&limit=10",
+			token:      adminToken,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "list-users-invalid-limit",
+			method:     http.MethodGet,
+			path:       "/v1/admin/users?limit=zero",
+			token:      adminToken,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "list-users-invalid-cursor",
+			method:     http.MethodGet,
+			path:       "/v1/admin/users?cursor=not-a-cursor",
+			token:      adminToken,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "list-users-as-user",
+			method:     http.MethodGet,
+			path:       "/v1/admin/users",
+			token:      userToken,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "list-users-unauthenticated",
+			method:     http.MethodGet,
+			path:       "/v1/admin/users",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "change-role",
+			method:     http.MethodPut,
+			path:       "/v1/admin/users/" + user.ID + "/roles",
+			token:      adminToken,
+			body:       models.ChangeRoleRequest{Roles: []string{models.AdminRole}},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "disable-user",
+			method:     http.MethodPut,
+			path:       "/v1/admin/users/" + user.ID + "/disabled",
+			token:      adminToken,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "enable-user",
+			method:     http.MethodDelete,
+			path:       "/v1/admin/users/" + user.ID + "/disabled",
+			token:      adminToken,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "delete-user",
+			method:     http.MethodDelete,
+			path:       "/v1/admin/users/" + user.ID,
+			token:      adminToken,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "delete-user-as-user",
+			method:     http.MethodDelete,
+			path:       "/v1/admin/users/" + user.ID,
+			token:      userToken,
+			wantStatus: http.StatusForbidden,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := performRequest(r, tt.method, tt.path, tt.token, tt.body)
+			assert.Equal(t, tt.wantStatus, w.Code, w.Body.String())
+		})
+	}
+
+	w := performRequest(r, http.MethodGet, "/v1/admin/users?limit=zero", adminToken, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var res httputil.ErrorResponse
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&res))
+	assert.Equal(t, CodeInvalidQuery, res.Code)
+
+	assert.Equal(t, 10+1, userRepo.ListArg.Limit)
+	assert.Equal(t, models.UserRole, userRepo.ListArg.Role)
+	assert.Equal(t, "mail", userRepo.ListArg.EmailPrefix)
+	assert.Equal(t, []string{models.AdminRole}, userRepo.UpdateRolesArg.Roles)
+	assert.Equal(t, 2, userRepo.SetDisabledInvocations)
+	assert.Equal(t, 1, userRepo.DeleteInvocations)
+}
+
+func issueToken(t *testing.T, role string, permissions ...string) string {
+	token, err := issuer.Issue("principal-id", role, permissions...)
+	assert.NoError(t, err)
+	return token
+}
+
+func performRequest(r http.Handler, method, path, token string, body interface{}) *httptest.ResponseRecorder {
+	var buffer bytes.Buffer
+	if body != nil {
+		json.NewEncoder(&buffer).Encode(body)
+	}
+
+	req := httptest.NewRequest(method, path, &buffer)
+	if token != "" {
+		req.Header.Set(httputil.AuthorizationHeader, httputil.BearerPrefix+token)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
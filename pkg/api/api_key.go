@@ -0,0 +1,82 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/CzarSimon/user-service/pkg/auth"
+	"github.com/CzarSimon/user-service/pkg/httputil"
+	"github.com/CzarSimon/user-service/pkg/models"
+	"github.com/CzarSimon/user-service/pkg/service"
+	"github.com/gin-gonic/gin"
+)
+
+type apiKeyController struct {
+	svc service.APIKeyService
+}
+
+// AttachAPIKeyRoutes attaches the routes for users to create, list and revoke their API keys to a router.
+// Admins with permission to read and write users can list and revoke the keys of any user.
+// Keys are accepted by routes whose verifier has been created with service.NewAPIKeyVerifier.
+func AttachAPIKeyRoutes(r gin.IRouter, svc service.APIKeyService, verifier auth.Verifier) {
+	ctrl := &apiKeyController{svc: svc}
+	g := r.Group("/v1/users/:userId/api-keys", httputil.Authenticate(verifier))
+
+	g.POST("", ctrl.createAPIKey)
+	g.GET("", ctrl.listAPIKeys)
+	g.DELETE("/:keyId", ctrl.revokeAPIKey)
+}
+
+func (ctrl *apiKeyController) createAPIKey(c *gin.Context) {
+	principal, err := httputil.GetToken(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var req models.CreateAPIKeyRequest
+	err = c.ShouldBindJSON(&req)
+	if err != nil {
+		c.Error(errInvalidBody())
+		return
+	}
+
+	created, err := ctrl.svc.Create(c.Request.Context(), principal, c.Param("userId"), req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, created)
+}
+
+func (ctrl *apiKeyController) listAPIKeys(c *gin.Context) {
+	principal, err := httputil.GetToken(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	keys, err := ctrl.svc.List(c.Request.Context(), principal, c.Param("userId"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, keys)
+}
+
+func (ctrl *apiKeyController) revokeAPIKey(c *gin.Context) {
+	principal, err := httputil.GetToken(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	err = ctrl.svc.Revoke(c.Request.Context(), principal, c.Param("userId"), c.Param("keyId"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	httputil.SendOK(c)
+}
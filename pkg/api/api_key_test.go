@@ -0,0 +1,84 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/CzarSimon/user-service/pkg/auth"
+	"github.com/CzarSimon/user-service/pkg/httputil"
+	"github.com/CzarSimon/user-service/pkg/models"
+	"github.com/CzarSimon/user-service/pkg/repository/repotest"
+	"github.com/CzarSimon/user-service/pkg/service"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestAPIKeyRoutes(t *testing.T) {
+	assert := assert.New(t)
+	gin.SetMode(gin.TestMode)
+	user := models.NewUser("mail@mail.com", "Tester", "McTest", models.UserRole, models.Credentials{})
+	user.ID = "principal-id"
+	userRepo := &repotest.MockUserRepo{FindUser: user}
+	roleRepo := &repotest.MockRoleRepo{FindByNamesRoles: []models.Role{{Name: models.UserRole, Permissions: []string{}}}}
+	keyRepo := &repotest.MockAPIKeyRepo{}
+	keyVerifier := service.NewAPIKeyVerifier(verifier, keyRepo, userRepo, roleRepo, zap.NewNop())
+
+	r := httputil.NewRouter("user-service", "1.0")
+	AttachAPIKeyRoutes(r, service.NewAPIKeyService(keyRepo), keyVerifier)
+	AttachUserRoutes(r, service.NewUserService(auth.NewHasher("secret-pepper"), issuer, userRepo, roleRepo), keyVerifier)
+	token := issueToken(t, models.UserRole)
+
+	req := models.CreateAPIKeyRequest{Name: "Deploy script", ExpiresAt: time.Now().Add(time.Hour)}
+	w := performRequest(r, http.MethodPost, "/v1/users/other-id/api-keys", token, req)
+	assert.Equal(http.StatusForbidden, w.Code)
+
+	w = performRequest(r, http.MethodPost, "/v1/users/principal-id/api-keys", token, req)
+	assert.Equal(http.StatusOK, w.Code)
+	assert.NotContains(w.Body.String(), keyRepo.SaveArg.KeyHash)
+	var created models.CreatedAPIKey
+	assert.NoError(json.NewDecoder(w.Body).Decode(&created))
+	assert.NotEmpty(created.Key)
+	assert.Equal("Deploy script", created.APIKey.Name)
+	keyRepo.FindByPrefixKey = keyRepo.SaveArg
+	keyRepo.FindByUserIDKeys = []models.APIKey{keyRepo.SaveArg}
+	keyRepo.FindKey = keyRepo.SaveArg
+
+	w = performAPIKeyRequest(r, http.MethodGet, "/v1/users/principal-id", created.Key)
+	assert.Equal(http.StatusOK, w.Code)
+	var found models.User
+	assert.NoError(json.NewDecoder(w.Body).Decode(&found))
+	assert.Equal(user.ID, found.ID)
+	assert.Equal(created.APIKey.ID, keyRepo.UpdateLastUsedArg)
+
+	w = performAPIKeyRequest(r, http.MethodGet, "/v1/users/principal-id/api-keys", created.Key)
+	assert.Equal(http.StatusOK, w.Code)
+	var keys []models.APIKey
+	assert.NoError(json.NewDecoder(w.Body).Decode(&keys))
+	assert.Len(keys, 1)
+	assert.Equal(created.APIKey.Prefix, keys[0].Prefix)
+	assert.NotContains(w.Body.String(), created.Key)
+
+	w = performAPIKeyRequest(r, http.MethodGet, "/v1/users/principal-id", created.Key+"0")
+	assert.Equal(http.StatusUnauthorized, w.Code)
+
+	w = performRequest(r, http.MethodDelete, "/v1/users/principal-id/api-keys/"+created.APIKey.ID, token, nil)
+	assert.Equal(http.StatusOK, w.Code)
+	assert.Equal(created.APIKey.ID, keyRepo.RevokeArg)
+
+	keyRepo.FindByPrefixKey.RevokedAt = time.Now().UTC()
+	w = performAPIKeyRequest(r, http.MethodGet, "/v1/users/principal-id", created.Key)
+	assert.Equal(http.StatusUnauthorized, w.Code)
+}
+
+func performAPIKeyRequest(r http.Handler, method, path, key string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	req.Header.Set(httputil.AuthorizationHeader, httputil.APIKeyPrefix+key)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
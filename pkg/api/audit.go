@@ -0,0 +1,76 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/CzarSimon/user-service/pkg/auth"
+	"github.com/CzarSimon/user-service/pkg/httputil"
+	"github.com/CzarSimon/user-service/pkg/models"
+	"github.com/CzarSimon/user-service/pkg/service"
+	"github.com/gin-gonic/gin"
+)
+
+type auditController struct {
+	svc service.AuditService
+}
+
+// AttachAuditRoutes attaches the audit log routes to a router.
+// All routes require an authenticated principal with permission to read the audit log.
+func AttachAuditRoutes(r gin.IRouter, svc service.AuditService, verifier auth.Verifier) {
+	ctrl := &auditController{svc: svc}
+	g := r.Group("/v1/admin/audit-events", httputil.Authenticate(verifier))
+
+	g.GET("", httputil.RequirePermission(models.ReadAuditLogPermission), ctrl.queryAuditLog)
+}
+
+func (ctrl *auditController) queryAuditLog(c *gin.Context) {
+	principal, err := httputil.GetToken(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	query, err := parseAuditQuery(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	events, err := ctrl.svc.QueryAuditLog(c.Request.Context(), principal, query)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, events)
+}
+
+func parseAuditQuery(c *gin.Context) (models.AuditQuery, error) {
+	query := models.AuditQuery{
+		Type:      c.Query("type"),
+		ActorID:   c.Query("actorId"),
+		SubjectID: c.Query("subjectId"),
+		Outcome:   c.Query("outcome"),
+	}
+
+	var err error
+	query.After, err = parseTimeQuery(c, "after")
+	if err != nil {
+		return models.AuditQuery{}, err
+	}
+
+	query.Before, err = parseTimeQuery(c, "before")
+	if err != nil {
+		return models.AuditQuery{}, err
+	}
+
+	if limit, ok := c.GetQuery("limit"); ok {
+		query.Limit, err = strconv.Atoi(limit)
+		if err != nil || query.Limit < 1 {
+			return models.AuditQuery{}, errInvalidQuery("Invalid limit", "query.invalidLimit")
+		}
+	}
+
+	return query, nil
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/CzarSimon/user-service/pkg/httputil"
+	"github.com/CzarSimon/user-service/pkg/models"
+	"github.com/CzarSimon/user-service/pkg/service"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockAuditLog struct {
+	events   []models.AuditEvent
+	queryArg models.AuditQuery
+}
+
+func (l *mockAuditLog) Write(ctx context.Context, event models.AuditEvent) error {
+	l.events = append(l.events, event)
+	return nil
+}
+
+func (l *mockAuditLog) Query(ctx context.Context, query models.AuditQuery) ([]models.AuditEvent, error) {
+	l.queryArg = query
+	return l.events, nil
+}
+
+func TestAuditRoutes(t *testing.T) {
+	assert := assert.New(t)
+	gin.SetMode(gin.TestMode)
+	log := &mockAuditLog{
+		events: []models.AuditEvent{models.NewAuditEvent(models.AuditLogin, "user-1", "user-1", "")},
+	}
+	r := httputil.NewRouter("user-service", "1.0")
+	AttachAuditRoutes(r, service.NewAuditService(log), verifier)
+
+	adminToken := issueToken(t, models.AdminRole, models.Permissions(models.DefaultRoles())...)
+	path := "/v1/admin/audit-events?type=LOGIN&actorId=user-1&subjectId=user-1&outcome=SUCCESS&after=2019-01-01T00:00:00Z&limit=10"
+	w := performRequest(r, http.MethodGet, path, adminToken, nil)
+	assert.Equal(http.StatusOK, w.Code)
+	assert.Equal(models.AuditQuery{
+		Type:      models.AuditLogin,
+		ActorID:   "user-1",
+		SubjectID: "user-1",
+		Outcome:   models.AuditSuccess,
+		After:     time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC),
+		Limit:     10,
+	}, log.queryArg)
+
+	var events []models.AuditEvent
+	err := json.NewDecoder(w.Body).Decode(&events)
+	assert.NoError(err)
+	assert.Len(events, 1)
+	assert.Equal(log.events[0].ID, events[0].ID)
+
+	w = performRequest(r, http.MethodGet, "/v1/admin/audit-events?before=yesterday", adminToken, nil)
+	assert.Equal(http.StatusBadRequest, w.Code)
+
+	w = performRequest(r, http.MethodGet, "/v1/admin/audit-events?limit=0", adminToken, nil)
+	assert.Equal(http.StatusBadRequest, w.Code)
+
+	w = performRequest(r, http.MethodGet, "/v1/admin/audit-events", issueToken(t, models.UserRole), nil)
+	assert.Equal(http.StatusForbidden, w.Code)
+
+	w = performRequest(r, http.MethodGet, "/v1/admin/audit-events", "", nil)
+	assert.Equal(http.StatusUnauthorized, w.Code)
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/CzarSimon/user-service/pkg/auth"
+	"github.com/CzarSimon/user-service/pkg/httputil"
+	"github.com/CzarSimon/user-service/pkg/models"
+	"github.com/CzarSimon/user-service/pkg/service"
+	"github.com/gin-gonic/gin"
+)
+
+type authController struct {
+	svc     service.UserService
+	cookies httputil.CookieConfig
+}
+
+// AttachAuthRoutes attaches the signup, login and logout routes to a router.
+// Clients which send the X-Session-Mode: cookie header get their tokens as session
+// cookies instead of in the response body, see httputil.SetSessionCookies.
+// Logging out revokes the session of the token, see service.WithSessions.
+func AttachAuthRoutes(r gin.IRouter, svc service.UserService, cookies httputil.CookieConfig, verifier auth.Verifier) {
+	ctrl := &authController{
+		svc:     svc,
+		cookies: cookies,
+	}
+	g := r.Group("/v1")
+
+	g.POST("/signup", ctrl.signUp)
+	g.POST("/login", ctrl.login)
+	g.POST("/logout", httputil.Authenticate(verifier), ctrl.logout)
+}
+
+func (ctrl *authController) signUp(c *gin.Context) {
+	var req models.SignupRequest
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		c.Error(errInvalidBody())
+		return
+	}
+
+	res, err := ctrl.svc.SignUp(c.Request.Context(), req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	ctrl.sendLoginResponse(c, res)
+}
+
+func (ctrl *authController) login(c *gin.Context) {
+	var req models.LoginRequest
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		c.Error(errInvalidBody())
+		return
+	}
+	req.Client = models.ClientInfo{
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+
+	res, err := ctrl.svc.Login(c.Request.Context(), req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	ctrl.sendLoginResponse(c, res)
+}
+
+func (ctrl *authController) logout(c *gin.Context) {
+	principal, err := httputil.GetToken(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	err = ctrl.svc.Logout(c.Request.Context(), principal)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	httputil.ClearSessionCookies(c, ctrl.cookies)
+	httputil.SendOK(c)
+}
+
+// sendLoginResponse sends a login response, setting the token as a cookie
+// rather than in the body if the client uses cookie sessions.
+func (ctrl *authController) sendLoginResponse(c *gin.Context, res models.LoginResponse) {
+	if httputil.UsesCookieSession(c) {
+		err := httputil.SetSessionCookies(c, ctrl.cookies, res.Token)
+		if err != nil {
+			c.Error(httputil.NewInternalServerError("Failed to create session"))
+			return
+		}
+
+		res.Token = ""
+	}
+
+	c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/CzarSimon/user-service/pkg/auth"
+	"github.com/CzarSimon/user-service/pkg/httputil"
+	"github.com/CzarSimon/user-service/pkg/models"
+	"github.com/CzarSimon/user-service/pkg/repository"
+	"github.com/CzarSimon/user-service/pkg/repository/repotest"
+	"github.com/CzarSimon/user-service/pkg/service"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthRoutes(t *testing.T) {
+	assert := assert.New(t)
+	gin.SetMode(gin.TestMode)
+	hasher := auth.NewHasher("secret-pepper")
+	salt := "salt"
+	hash, err := hasher.Hash("secret-drowssap", salt)
+	assert.NoError(err)
+	user := models.NewUser("mail@mail.com", "Tester", "McTest", models.UserRole, models.Credentials{
+		Salt:         salt,
+		PasswordHash: hash,
+	})
+	userRepo := &repotest.MockUserRepo{
+		FindByEmailUser: user,
+		FindUser:        user,
+	}
+	roleRepo := &repotest.MockRoleRepo{FindByNamesRoles: models.DefaultRoles()}
+	loginRepo := &repotest.MockLoginHistoryRepo{}
+	sessionRepo := &repotest.MockSessionRepo{}
+	svc := service.NewUserService(hasher, issuer, userRepo, roleRepo, service.WithLoginHistory(loginRepo), service.WithSessions(sessionRepo))
+
+	r := httputil.NewRouter("user-service", "1.0")
+	AttachAuthRoutes(r, svc, httputil.CookieConfig{}, verifier)
+	login := models.LoginRequest{Email: user.Email, Password: "secret-drowssap"}
+
+	w := performRequest(r, http.MethodPost, "/v1/login", "", login)
+	assert.Equal(http.StatusOK, w.Code)
+	var res models.LoginResponse
+	assert.NoError(json.NewDecoder(w.Body).Decode(&res))
+	assert.NotEmpty(res.Token)
+	assert.Equal(user.ID, res.User.ID)
+	assert.Empty(w.Header()["Set-Cookie"])
+	assert.Equal("192.0.2.1", loginRepo.SaveArg.IP)
+
+	body, err := json.Marshal(login)
+	assert.NoError(err)
+	req := httptest.NewRequest(http.MethodPost, "/v1/login", bytes.NewReader(body))
+	req.Header.Set(httputil.SessionModeHeader, httputil.CookieSessionMode)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(http.StatusOK, w.Code)
+	res = models.LoginResponse{}
+	assert.NoError(json.NewDecoder(w.Body).Decode(&res))
+	assert.Empty(res.Token)
+	assert.Equal(user.ID, res.User.ID)
+	cookies := make(map[string]*http.Cookie)
+	for _, cookie := range (&http.Response{Header: w.Header()}).Cookies() {
+		cookies[cookie.Name] = cookie
+	}
+	assert.NotEmpty(cookies[httputil.AccessTokenCookie].Value)
+	assert.NotEmpty(cookies[httputil.CSRFTokenCookie].Value)
+
+	w = performRequest(r, http.MethodPost, "/v1/login", "", models.LoginRequest{Email: user.Email, Password: "wrong-password"})
+	assert.Equal(http.StatusUnauthorized, w.Code)
+
+	req = httptest.NewRequest(http.MethodPost, "/v1/logout", nil)
+	req.AddCookie(cookies[httputil.AccessTokenCookie])
+	req.AddCookie(cookies[httputil.CSRFTokenCookie])
+	req.Header.Set(httputil.CSRFTokenHeader, cookies[httputil.CSRFTokenCookie].Value)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(http.StatusOK, w.Code)
+	for _, cookie := range (&http.Response{Header: w.Header()}).Cookies() {
+		assert.Empty(cookie.Value)
+		assert.True(cookie.MaxAge < 0)
+	}
+	cookieTokenID, err := auth.TokenID(cookies[httputil.AccessTokenCookie].Value)
+	assert.NoError(err)
+	assert.Equal([]string{cookieTokenID}, sessionRepo.RevokeArgs)
+
+	req = httptest.NewRequest(http.MethodPost, "/v1/logout", nil)
+	req.AddCookie(cookies[httputil.AccessTokenCookie])
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(http.StatusForbidden, w.Code)
+
+	userRepo.FindByEmailErr = repository.ErrNoSuchUser
+	w = performRequest(r, http.MethodPost, "/v1/signup", "", models.SignupRequest{
+		Email:             "new@mail.com",
+		Password:          "secret-drowssap",
+		RepeatPassword:    "secret-drowssap",
+		Surname:           "Tester",
+		MiddleAndLastName: "McTest",
+	})
+	assert.Equal(http.StatusOK, w.Code)
+	res = models.LoginResponse{}
+	assert.NoError(json.NewDecoder(w.Body).Decode(&res))
+	assert.NotEmpty(res.Token)
+	assert.Equal("new@mail.com", res.User.Email)
+
+	w = performRequest(r, http.MethodPost, "/v1/logout", res.Token, nil)
+	assert.Equal(http.StatusOK, w.Code)
+	bearerTokenID, err := auth.TokenID(res.Token)
+	assert.NoError(err)
+	assert.Equal([]string{cookieTokenID, bearerTokenID}, sessionRepo.RevokeArgs)
+
+	w = performRequest(r, http.MethodPost, "/v1/logout", "", nil)
+	assert.Equal(http.StatusUnauthorized, w.Code)
+
+	w = performRequest(r, http.MethodPost, "/v1/signup", "", "not-a-signup")
+	assert.Equal(http.StatusBadRequest, w.Code)
+}
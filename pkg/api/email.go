@@ -0,0 +1,76 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/CzarSimon/user-service/pkg/auth"
+	"github.com/CzarSimon/user-service/pkg/httputil"
+	"github.com/CzarSimon/user-service/pkg/models"
+	"github.com/CzarSimon/user-service/pkg/service"
+	"github.com/gin-gonic/gin"
+)
+
+type emailController struct {
+	svc service.EmailChangeService
+}
+
+// AttachEmailChangeRoutes attaches the routes for changing email address to a router.
+// Confirming and reverting a change is authenticated by the emailed token alone.
+func AttachEmailChangeRoutes(r gin.IRouter, svc service.EmailChangeService, verifier auth.Verifier) {
+	ctrl := &emailController{svc: svc}
+
+	r.POST("/v1/users/:userId/email", httputil.Authenticate(verifier), ctrl.requestEmailChange)
+	r.POST("/v1/email-changes/confirm", ctrl.confirmEmailChange)
+	r.POST("/v1/email-changes/revert", ctrl.revertEmailChange)
+}
+
+func (ctrl *emailController) requestEmailChange(c *gin.Context) {
+	principal, err := httputil.GetToken(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var req models.ChangeEmailRequest
+	err = c.ShouldBindJSON(&req)
+	if err != nil {
+		c.Error(errInvalidBody())
+		return
+	}
+	req.UserID = c.Param("userId")
+
+	err = ctrl.svc.RequestEmailChange(c.Request.Context(), principal, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	httputil.SendOK(c)
+}
+
+func (ctrl *emailController) confirmEmailChange(c *gin.Context) {
+	ctrl.handleToken(c, ctrl.svc.ConfirmEmailChange)
+}
+
+func (ctrl *emailController) revertEmailChange(c *gin.Context) {
+	ctrl.handleToken(c, ctrl.svc.RevertEmailChange)
+}
+
+// handleToken passes the token in the request body to a handler and responds with the affected user.
+func (ctrl *emailController) handleToken(c *gin.Context, handler func(context.Context, string) (models.User, error)) {
+	var req models.EmailTokenRequest
+	err := c.ShouldBindJSON(&req)
+	if err != nil || req.Token == "" {
+		c.Error(errInvalidBody())
+		return
+	}
+
+	user, err := handler(c.Request.Context(), req.Token)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
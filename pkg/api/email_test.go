@@ -0,0 +1,63 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/CzarSimon/user-service/pkg/auth"
+	"github.com/CzarSimon/user-service/pkg/httputil"
+	"github.com/CzarSimon/user-service/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockEmailChangeService struct {
+	requestArg models.ChangeEmailRequest
+	confirmArg string
+	revertArg  string
+}
+
+func (s *mockEmailChangeService) RequestEmailChange(ctx context.Context, principal auth.Token, req models.ChangeEmailRequest) error {
+	s.requestArg = req
+	return nil
+}
+
+func (s *mockEmailChangeService) ConfirmEmailChange(ctx context.Context, token string) (models.User, error) {
+	s.confirmArg = token
+	return models.User{}, nil
+}
+
+func (s *mockEmailChangeService) RevertEmailChange(ctx context.Context, token string) (models.User, error) {
+	s.revertArg = token
+	return models.User{}, nil
+}
+
+func TestEmailChangeRoutes(t *testing.T) {
+	assert := assert.New(t)
+	gin.SetMode(gin.TestMode)
+	svc := &mockEmailChangeService{}
+	r := httputil.NewRouter("user-service", "1.0")
+	AttachEmailChangeRoutes(r, svc, verifier)
+
+	token := issueToken(t, models.UserRole)
+	body := models.ChangeEmailRequest{NewEmail: "new@mail.com", Password: "secret"}
+	w := performRequest(r, http.MethodPost, "/v1/users/user-id/email", token, body)
+	assert.Equal(http.StatusOK, w.Code)
+	assert.Equal("user-id", svc.requestArg.UserID)
+	assert.Equal("new@mail.com", svc.requestArg.NewEmail)
+
+	w = performRequest(r, http.MethodPost, "/v1/users/user-id/email", "", body)
+	assert.Equal(http.StatusUnauthorized, w.Code)
+
+	w = performRequest(r, http.MethodPost, "/v1/email-changes/confirm", "", models.EmailTokenRequest{Token: "confirm"})
+	assert.Equal(http.StatusOK, w.Code)
+	assert.Equal("confirm", svc.confirmArg)
+
+	w = performRequest(r, http.MethodPost, "/v1/email-changes/revert", "", models.EmailTokenRequest{Token: "revert"})
+	assert.Equal(http.StatusOK, w.Code)
+	assert.Equal("revert", svc.revertArg)
+
+	w = performRequest(r, http.MethodPost, "/v1/email-changes/confirm", "", models.EmailTokenRequest{})
+	assert.Equal(http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,26 @@
+module github.com/CzarSimon/user-service/pkg/api
+
+go 1.12
+
+require (
+	github.com/CzarSimon/user-service/pkg/auth v0.0.0-20190414213512-6f2a7ae6afb1
+	github.com/CzarSimon/user-service/pkg/httputil v0.0.0-20190414213512-6f2a7ae6afb1
+	github.com/CzarSimon/user-service/pkg/models v0.0.0-20190423201041-96646a9231a8
+	github.com/CzarSimon/user-service/pkg/repository v0.0.0-20190423194243-bb8b9a2c67f2
+	github.com/CzarSimon/user-service/pkg/repository/repotest v0.0.0-20190423194243-bb8b9a2c67f2
+	github.com/CzarSimon/user-service/pkg/service v0.0.0-00010101000000-000000000000
+	github.com/gin-gonic/gin v1.3.0
+	github.com/stretchr/testify v1.7.0
+	go.uber.org/zap v1.9.1
+	gopkg.in/square/go-jose.v2 v2.3.1
+)
+
+replace (
+	github.com/CzarSimon/user-service/pkg/auth => ../auth
+	github.com/CzarSimon/user-service/pkg/httputil => ../httputil
+	github.com/CzarSimon/user-service/pkg/id => ../id
+	github.com/CzarSimon/user-service/pkg/models => ../models
+	github.com/CzarSimon/user-service/pkg/repository => ../repository
+	github.com/CzarSimon/user-service/pkg/repository/repotest => ../repository/repotest
+	github.com/CzarSimon/user-service/pkg/service => ../service
+)
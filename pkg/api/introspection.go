@@ -0,0 +1,66 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/CzarSimon/user-service/pkg/auth"
+	"github.com/CzarSimon/user-service/pkg/httputil"
+	"github.com/CzarSimon/user-service/pkg/models"
+	"github.com/CzarSimon/user-service/pkg/service"
+	"github.com/gin-gonic/gin"
+)
+
+type introspectionController struct {
+	verifier auth.Verifier
+}
+
+// AttachIntrospectionRoutes attaches the RFC 7662 token introspection route to a router. Callers must hold the
+// tokens:introspect permission, which is meant for the service accounts of resource servers. Tokens are verified
+// with the given verifier, which should be the one used for the rest of the api so that revoked sessions are
+// reported as inactive. API keys are introspected as well if the verifier accepts them, see auth.APIKeyVerifier.
+func AttachIntrospectionRoutes(r gin.IRouter, verifier auth.Verifier) {
+	ctrl := &introspectionController{verifier: verifier}
+	g := r.Group("/v1/oauth")
+
+	g.POST("/introspect", httputil.Authenticate(verifier), httputil.RequirePermission(models.IntrospectTokensPermission), ctrl.introspect)
+}
+
+func (ctrl *introspectionController) introspect(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+
+	rawToken := c.PostForm("token")
+	if rawToken == "" {
+		sendOAuthError(c, httputil.NewError("Token is required", http.StatusBadRequest).WithCode(service.CodeInvalidRequest))
+		return
+	}
+
+	token, err := ctrl.verify(rawToken)
+	if isInactiveTokenError(err) {
+		c.JSON(http.StatusOK, auth.Introspection{Active: false})
+		return
+	} else if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, auth.NewIntrospection(token))
+}
+
+func (ctrl *introspectionController) verify(rawToken string) (auth.Token, error) {
+	keyVerifier, ok := ctrl.verifier.(auth.APIKeyVerifier)
+	if _, err := auth.ParseAPIKeyPrefix(rawToken); ok && err == nil {
+		return keyVerifier.VerifyAPIKey(rawToken)
+	}
+
+	return ctrl.verifier.Verify(rawToken)
+}
+
+func isInactiveTokenError(err error) bool {
+	switch err {
+	case auth.ErrInvalidToken, auth.ErrExpiredToken, auth.ErrInvalidTokenContent, auth.ErrInvalidAPIKey:
+		return true
+	default:
+		return false
+	}
+}
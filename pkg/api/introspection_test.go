@@ -0,0 +1,126 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/CzarSimon/user-service/pkg/auth"
+	"github.com/CzarSimon/user-service/pkg/httputil"
+	"github.com/CzarSimon/user-service/pkg/models"
+	"github.com/CzarSimon/user-service/pkg/repository"
+	"github.com/CzarSimon/user-service/pkg/repository/repotest"
+	"github.com/CzarSimon/user-service/pkg/service"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIntrospectionRoutes(t *testing.T) {
+	assert := assert.New(t)
+	gin.SetMode(gin.TestMode)
+	r := httputil.NewRouter("user-service", "1.0")
+	AttachIntrospectionRoutes(r, verifier)
+
+	resourceServer := issueToken(t, models.ServiceRole, models.IntrospectTokensPermission)
+	userToken := issueToken(t, models.UserRole, models.ReadUsersPermission)
+
+	w := performIntrospectionRequest(r, resourceServer, userToken)
+	assert.Equal(http.StatusOK, w.Code)
+	assert.Equal("no-store", w.Header().Get("Cache-Control"))
+	var res auth.Introspection
+	assert.NoError(json.NewDecoder(w.Body).Decode(&res))
+	assert.True(res.Active)
+	assert.Equal("principal-id", res.Subject)
+	assert.Equal(models.UserRole, res.Role)
+	assert.Equal([]string{models.ReadUsersPermission}, res.Permissions)
+	assert.NotEmpty(res.ID)
+	assert.NotZero(res.ExpiresAt)
+	assert.NotZero(res.IssuedAt)
+
+	for _, token := range []string{"not-a-token", "usk_0123456789abcdef01234567_" + strings.Repeat("0", 64)} {
+		w = performIntrospectionRequest(r, resourceServer, token)
+		assert.Equal(http.StatusOK, w.Code)
+		assert.JSONEq(`{"active":false}`, w.Body.String())
+	}
+
+	w = performIntrospectionRequest(r, resourceServer, "")
+	assert.Equal(http.StatusBadRequest, w.Code)
+	assert.Contains(w.Body.String(), `"error":"invalid_request"`)
+
+	w = performIntrospectionRequest(r, userToken, userToken)
+	assert.Equal(http.StatusForbidden, w.Code)
+
+	w = performIntrospectionRequest(r, "", userToken)
+	assert.Equal(http.StatusUnauthorized, w.Code)
+}
+
+func TestIntrospectionClient_AgainstRoutes(t *testing.T) {
+	assert := assert.New(t)
+	gin.SetMode(gin.TestMode)
+	hasher := auth.NewHasher("secret-pepper")
+	accountRepo := &repotest.MockServiceAccountRepo{}
+	oauthSvc := service.NewOAuthService(
+		hasher,
+		issuer,
+		&repotest.MockUserRepo{},
+		&repotest.MockRoleRepo{FindByNamesRoles: models.DefaultRoles()},
+		&repotest.MockOAuthClientRepo{FindErr: repository.ErrNoSuchClient},
+		&repotest.MockAuthorizationCodeRepo{},
+		&repotest.MockRefreshTokenRepo{},
+		service.WithServiceAccounts(accountRepo),
+	)
+
+	r := httputil.NewRouter("user-service", "1.0")
+	AttachOAuthRoutes(r, oauthSvc, verifier)
+	AttachIntrospectionRoutes(r, verifier)
+	AttachServiceAccountRoutes(r, service.NewServiceAccountService(hasher, accountRepo), verifier)
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	adminToken := issueToken(t, models.AdminRole, models.Permissions(models.DefaultRoles())...)
+	req := models.CreateServiceAccountRequest{
+		Name:        "Resource server",
+		Permissions: []string{models.IntrospectTokensPermission},
+	}
+	w := performRequest(r, http.MethodPost, "/v1/admin/service-accounts", adminToken, req)
+	assert.Equal(http.StatusOK, w.Code)
+	var creds models.ServiceAccountCredentials
+	assert.NoError(json.NewDecoder(w.Body).Decode(&creds))
+	accountRepo.FindAccount = accountRepo.SaveArg
+
+	client := auth.NewIntrospectionClient(auth.IntrospectionConfig{
+		IntrospectionURL: server.URL + "/v1/oauth/introspect",
+		TokenURL:         server.URL + "/v1/oauth/token",
+		ClientID:         creds.Account.ID,
+		ClientSecret:     creds.ClientSecret,
+	})
+
+	token, err := client.Verify(issueToken(t, models.UserRole, models.ReadUsersPermission))
+	assert.NoError(err)
+	assert.Equal("principal-id", token.Subject)
+	assert.Equal(models.UserRole, token.Role)
+	assert.Equal([]string{models.ReadUsersPermission}, token.Permissions)
+
+	_, err = client.Verify("not-a-token")
+	assert.Equal(auth.ErrInvalidToken, err)
+}
+
+func performIntrospectionRequest(r http.Handler, callerToken, token string) *httptest.ResponseRecorder {
+	form := url.Values{}
+	if token != "" {
+		form.Set("token", token)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/oauth/introspect", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if callerToken != "" {
+		req.Header.Set(httputil.AuthorizationHeader, httputil.BearerPrefix+callerToken)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
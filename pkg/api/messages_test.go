@@ -0,0 +1,76 @@
+package api
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/CzarSimon/user-service/pkg/httputil"
+	"github.com/CzarSimon/user-service/pkg/models"
+	"github.com/CzarSimon/user-service/pkg/repository/repotest"
+	"github.com/CzarSimon/user-service/pkg/service"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessageFiles(t *testing.T) {
+	assert := assert.New(t)
+	en := readMessages(t, "en")
+	files, err := filepath.Glob("messages/*.json")
+	assert.NoError(err)
+
+	for _, file := range files {
+		lang := filepath.Base(file)
+		messages := readMessages(t, lang[:len(lang)-len(".json")])
+		for key := range en {
+			assert.Contains(messages, key, "missing key in %s", file)
+		}
+		for key := range messages {
+			assert.Contains(en, key, "unknown key in %s", file)
+		}
+	}
+}
+
+func TestLocalizedErrors(t *testing.T) {
+	assert := assert.New(t)
+	gin.SetMode(gin.TestMode)
+	catalog, err := httputil.LoadCatalog("messages", "en")
+	assert.NoError(err)
+
+	userRepo := &repotest.MockUserRepo{}
+	roleRepo := &repotest.MockRoleRepo{FindByNamesRoles: models.DefaultRoles()}
+	svc := service.NewUserService(nil, issuer, userRepo, roleRepo)
+
+	r := httputil.NewRouter("user-service", "1.0")
+	r.Use(httputil.Localize(catalog))
+	AttachAdminRoutes(r, svc, verifier)
+	token := issueToken(t, models.AdminRole, models.Permissions(models.DefaultRoles())...)
+
+	req := httptest.NewRequest(http.MethodPut, "/v1/admin/users/user-id/roles", nil)
+	req.Header.Set(httputil.AuthorizationHeader, httputil.BearerPrefix+token)
+	req.Header.Set(httputil.AcceptLanguageHeader, "sv-SE,sv;q=0.9,en;q=0.8")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var res httputil.ErrorResponse
+	assert.NoError(json.NewDecoder(w.Body).Decode(&res))
+	assert.Equal(http.StatusBadRequest, w.Code)
+	assert.Equal("Kunde inte tolka förfrågans innehåll", res.Message)
+
+	w = performRequest(r, http.MethodPut, "/v1/admin/users/user-id/roles", token, models.ChangeRoleRequest{Roles: []string{"OWNER"}})
+	assert.NoError(json.NewDecoder(w.Body).Decode(&res))
+	assert.Equal(http.StatusBadRequest, w.Code)
+	assert.Equal("Unknown role: OWNER", res.Message)
+}
+
+func readMessages(t *testing.T, lang string) map[string]string {
+	content, err := ioutil.ReadFile(filepath.Join("messages", lang+".json"))
+	assert.NoError(t, err)
+
+	var messages map[string]string
+	assert.NoError(t, json.Unmarshal(content, &messages))
+	return messages
+}
@@ -0,0 +1,176 @@
+package api
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/CzarSimon/user-service/pkg/auth"
+	"github.com/CzarSimon/user-service/pkg/httputil"
+	"github.com/CzarSimon/user-service/pkg/models"
+	"github.com/CzarSimon/user-service/pkg/service"
+	"github.com/gin-gonic/gin"
+)
+
+type oauthController struct {
+	svc service.OAuthService
+}
+
+// AttachOAuthRoutes attaches the routes of the OAuth2 authorization server to a router.
+//
+// The authorize routes are called by the login and consent page on behalf of a logged in user:
+// GET returns what the user is asked to consent to and POST records the answer, returning the
+// uri of the client to redirect the user to. The token route is called by clients directly.
+func AttachOAuthRoutes(r gin.IRouter, svc service.OAuthService, verifier auth.Verifier) {
+	ctrl := &oauthController{svc: svc}
+	g := r.Group("/v1/oauth")
+
+	g.GET("/authorize", httputil.Authenticate(verifier), ctrl.authorize)
+	g.POST("/authorize", httputil.Authenticate(verifier), ctrl.consent)
+	g.POST("/token", ctrl.token)
+
+	admin := r.Group("/v1/admin/oauth/clients", httputil.Authenticate(verifier))
+	admin.POST("", httputil.RequirePermission(models.WriteClientsPermission), ctrl.registerClient)
+}
+
+func (ctrl *oauthController) authorize(c *gin.Context) {
+	principal, err := httputil.GetToken(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var req models.AuthorizeRequest
+	err = c.ShouldBindQuery(&req)
+	if err != nil {
+		c.Error(httputil.ErrBadRequest())
+		return
+	}
+
+	prompt, err := ctrl.svc.Authorize(c.Request.Context(), principal, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, prompt)
+}
+
+func (ctrl *oauthController) consent(c *gin.Context) {
+	principal, err := httputil.GetToken(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var req models.AuthorizeRequest
+	err = c.ShouldBind(&req)
+	if err != nil {
+		c.Error(errInvalidBody())
+		return
+	}
+
+	uri, err := ctrl.svc.Consent(c.Request.Context(), principal, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"redirectUri": uri})
+}
+
+func (ctrl *oauthController) token(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+
+	var req models.TokenRequest
+	err := c.ShouldBind(&req)
+	if err != nil {
+		sendOAuthError(c, httputil.NewError("Failed to parse request body", http.StatusBadRequest).WithCode(service.CodeInvalidRequest))
+		return
+	}
+
+	err = setBasicClientCredentials(c, &req)
+	if err != nil {
+		sendOAuthError(c, err)
+		return
+	}
+
+	res, err := ctrl.svc.Token(c.Request.Context(), req)
+	if err != nil {
+		sendOAuthError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (ctrl *oauthController) registerClient(c *gin.Context) {
+	principal, err := httputil.GetToken(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var req models.RegisterClientRequest
+	err = c.ShouldBindJSON(&req)
+	if err != nil {
+		c.Error(errInvalidBody())
+		return
+	}
+
+	reg, err := ctrl.svc.RegisterClient(c.Request.Context(), principal, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, reg)
+}
+
+// setBasicClientCredentials reads client credentials sent with basic auth, which are
+// form encoded as given in RFC 6749 section 2.3.1.
+func setBasicClientCredentials(c *gin.Context, req *models.TokenRequest) error {
+	clientID, secret, ok := c.Request.BasicAuth()
+	if !ok {
+		return nil
+	}
+
+	var err error
+	req.ClientID, err = url.QueryUnescape(clientID)
+	if err != nil {
+		return httputil.NewError("Invalid client credentials", http.StatusBadRequest).WithCode(service.CodeInvalidRequest)
+	}
+
+	req.ClientSecret, err = url.QueryUnescape(secret)
+	if err != nil {
+		return httputil.NewError("Invalid client credentials", http.StatusBadRequest).WithCode(service.CodeInvalidRequest)
+	}
+
+	return nil
+}
+
+// oauthError error response of the token endpoint as given in RFC 6749.
+type oauthError struct {
+	Error       string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+}
+
+// sendOAuthError sends a client error in the form given in RFC 6749 with the error code in lower case.
+// Server errors are passed on to the standard error handling.
+func sendOAuthError(c *gin.Context, err error) {
+	httpErr, ok := err.(*httputil.Error)
+	if !ok || httpErr.StatusCode >= http.StatusInternalServerError {
+		c.Error(err)
+		return
+	}
+
+	if httpErr.StatusCode == http.StatusUnauthorized {
+		c.Header("WWW-Authenticate", `Basic realm="oauth"`)
+	}
+
+	c.JSON(httpErr.StatusCode, oauthError{
+		Error:       strings.ToLower(httpErr.Code),
+		Description: httpErr.Message,
+	})
+}
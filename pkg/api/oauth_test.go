@@ -0,0 +1,139 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/CzarSimon/user-service/pkg/auth"
+	"github.com/CzarSimon/user-service/pkg/httputil"
+	"github.com/CzarSimon/user-service/pkg/models"
+	"github.com/CzarSimon/user-service/pkg/repository"
+	"github.com/CzarSimon/user-service/pkg/repository/repotest"
+	"github.com/CzarSimon/user-service/pkg/service"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOAuthRoutes(t *testing.T) {
+	assert := assert.New(t)
+	gin.SetMode(gin.TestMode)
+	user := models.NewUser("mail@mail.com", "Tester", "McTest", models.UserRole, models.Credentials{UserID: "principal-id"})
+	clientRepo := &repotest.MockOAuthClientRepo{}
+	codeRepo := &repotest.MockAuthorizationCodeRepo{}
+	tokenRepo := &repotest.MockRefreshTokenRepo{}
+	svc := service.NewOAuthService(
+		auth.NewHasher("secret-pepper"),
+		issuer,
+		&repotest.MockUserRepo{FindUser: user},
+		&repotest.MockRoleRepo{FindByNamesRoles: models.DefaultRoles()},
+		clientRepo,
+		codeRepo,
+		tokenRepo,
+	)
+
+	r := httputil.NewRouter("user-service", "1.0")
+	AttachOAuthRoutes(r, svc, verifier)
+
+	registration := models.RegisterClientRequest{
+		Name:         "Web app",
+		Type:         models.ConfidentialClient,
+		RedirectURIs: []string{"https://app.example.com/callback"},
+		Scopes:       []string{"openid"},
+	}
+	w := performRequest(r, http.MethodPost, "/v1/admin/oauth/clients", issueToken(t, models.UserRole), registration)
+	assert.Equal(http.StatusForbidden, w.Code)
+
+	w = performRequest(r, http.MethodPost, "/v1/admin/oauth/clients", issueToken(t, models.AdminRole, models.WriteClientsPermission), registration)
+	assert.Equal(http.StatusOK, w.Code)
+	assert.NotContains(w.Body.String(), clientRepo.SaveArg.SecretHash)
+	var reg models.ClientRegistration
+	assert.NoError(json.NewDecoder(w.Body).Decode(&reg))
+	assert.NotEmpty(reg.ClientSecret)
+	clientRepo.FindClient = clientRepo.SaveArg
+
+	codeVerifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	query := url.Values{
+		"response_type":         {models.CodeResponseType},
+		"client_id":             {reg.Client.ID},
+		"redirect_uri":          {"https://app.example.com/callback"},
+		"scope":                 {"openid"},
+		"state":                 {"xyz"},
+		"code_challenge":        {auth.PKCEChallenge(codeVerifier)},
+		"code_challenge_method": {auth.PKCEMethodS256},
+	}
+	userToken := issueToken(t, models.UserRole)
+
+	w = performRequest(r, http.MethodGet, "/v1/oauth/authorize?"+query.Encode(), "", nil)
+	assert.Equal(http.StatusUnauthorized, w.Code)
+
+	w = performRequest(r, http.MethodGet, "/v1/oauth/authorize?"+query.Encode(), userToken, nil)
+	assert.Equal(http.StatusOK, w.Code)
+	var prompt models.ConsentPrompt
+	assert.NoError(json.NewDecoder(w.Body).Decode(&prompt))
+	assert.Equal("Web app", prompt.ClientName)
+	assert.Equal([]string{"openid"}, prompt.Scopes)
+
+	query.Set("approved", "true")
+	req := httptest.NewRequest(http.MethodPost, "/v1/oauth/authorize", strings.NewReader(query.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set(httputil.AuthorizationHeader, httputil.BearerPrefix+userToken)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(http.StatusOK, w.Code)
+	var consent struct {
+		RedirectURI string `json:"redirectUri"`
+	}
+	assert.NoError(json.NewDecoder(w.Body).Decode(&consent))
+	redirect, err := url.Parse(consent.RedirectURI)
+	assert.NoError(err)
+	assert.Equal("xyz", redirect.Query().Get("state"))
+	code := redirect.Query().Get("code")
+	codeRepo.ConsumeCode = codeRepo.SaveArg
+
+	form := url.Values{
+		"grant_type":    {models.AuthorizationCodeGrant},
+		"code":          {code},
+		"redirect_uri":  {"https://app.example.com/callback"},
+		"code_verifier": {codeVerifier},
+	}
+	w = performTokenRequest(r, form, reg.Client.ID, reg.ClientSecret)
+	assert.Equal(http.StatusOK, w.Code)
+	assert.Equal("no-store", w.Header().Get("Cache-Control"))
+	var res models.TokenResponse
+	assert.NoError(json.NewDecoder(w.Body).Decode(&res))
+	assert.Equal("Bearer", res.TokenType)
+	assert.Equal("openid", res.Scope)
+	assert.NotEmpty(res.RefreshToken)
+	token, err := verifier.Verify(res.AccessToken)
+	assert.NoError(err)
+	assert.Equal(reg.Client.ID, token.ClientID)
+
+	codeRepo.ConsumeErr = repository.ErrNoSuchAuthorizationCode
+	w = performTokenRequest(r, form, reg.Client.ID, reg.ClientSecret)
+	assert.Equal(http.StatusBadRequest, w.Code)
+	assert.JSONEq(`{"error":"invalid_grant","error_description":"Invalid or expired grant"}`, w.Body.String())
+
+	w = performTokenRequest(r, form, reg.Client.ID, "wrong-secret")
+	assert.Equal(http.StatusUnauthorized, w.Code)
+	assert.NotEmpty(w.Header().Get("WWW-Authenticate"))
+	assert.Contains(w.Body.String(), `"error":"invalid_client"`)
+
+	form.Set("grant_type", "password")
+	w = performTokenRequest(r, form, reg.Client.ID, reg.ClientSecret)
+	assert.Equal(http.StatusBadRequest, w.Code)
+	assert.Contains(w.Body.String(), `"error":"unsupported_grant_type"`)
+}
+
+func performTokenRequest(r http.Handler, form url.Values, clientID, secret string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/v1/oauth/token", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(clientID), url.QueryEscape(secret))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
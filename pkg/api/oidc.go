@@ -0,0 +1,127 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/CzarSimon/user-service/pkg/auth"
+	"github.com/CzarSimon/user-service/pkg/httputil"
+	"github.com/CzarSimon/user-service/pkg/models"
+	"github.com/CzarSimon/user-service/pkg/service"
+	"github.com/gin-gonic/gin"
+)
+
+// OIDCConfig configuration of the OpenID Connect routes.
+type OIDCConfig struct {
+	// Issuer url of the provider, which must match the issuer of its ID tokens.
+	Issuer string
+	// AuthorizationEndpoint url of the login and consent page which calls the authorize routes.
+	AuthorizationEndpoint string
+	IDTokenIssuer         auth.IDTokenIssuer
+	Cookies               httputil.CookieConfig
+}
+
+type oidcController struct {
+	metadata models.ProviderMetadata
+	idIssuer auth.IDTokenIssuer
+	cookies  httputil.CookieConfig
+	svc      service.OAuthService
+}
+
+// AttachOIDCRoutes attaches the OpenID Connect discovery, key set, userinfo and end session routes to a router.
+// The OAuth routes the discovery document refers to are attached with AttachOAuthRoutes.
+func AttachOIDCRoutes(r gin.IRouter, cfg OIDCConfig, svc service.OAuthService, verifier auth.Verifier) {
+	ctrl := &oidcController{
+		metadata: providerMetadata(cfg),
+		idIssuer: cfg.IDTokenIssuer,
+		cookies:  cfg.Cookies,
+		svc:      svc,
+	}
+
+	r.GET("/.well-known/openid-configuration", ctrl.discovery)
+
+	g := r.Group("/v1/oauth")
+	g.GET("/jwks", ctrl.keySet)
+	g.GET("/userinfo", httputil.Authenticate(verifier), ctrl.userInfo)
+	g.POST("/userinfo", httputil.Authenticate(verifier), ctrl.userInfo)
+	g.GET("/logout", ctrl.endSession)
+	g.POST("/logout", ctrl.endSession)
+}
+
+func (ctrl *oidcController) discovery(c *gin.Context) {
+	c.JSON(http.StatusOK, ctrl.metadata)
+}
+
+func (ctrl *oidcController) keySet(c *gin.Context) {
+	c.JSON(http.StatusOK, ctrl.idIssuer.KeySet())
+}
+
+func (ctrl *oidcController) userInfo(c *gin.Context) {
+	principal, err := httputil.GetToken(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if !principal.HasScope(models.OpenIDScope) {
+		c.Header("WWW-Authenticate", `Bearer error="insufficient_scope", scope="openid"`)
+		c.Error(errInsufficientScope())
+		return
+	}
+
+	info, err := ctrl.svc.UserInfo(c.Request.Context(), principal)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, info)
+}
+
+func (ctrl *oidcController) endSession(c *gin.Context) {
+	var req models.EndSessionRequest
+	err := c.ShouldBind(&req)
+	if err != nil {
+		c.Error(httputil.ErrBadRequest())
+		return
+	}
+
+	uri, err := ctrl.svc.EndSession(c.Request.Context(), req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	httputil.ClearSessionCookies(c, ctrl.cookies)
+	if uri == "" {
+		httputil.SendOK(c)
+		return
+	}
+
+	c.Redirect(http.StatusFound, uri)
+}
+
+func providerMetadata(cfg OIDCConfig) models.ProviderMetadata {
+	return models.ProviderMetadata{
+		Issuer:                            cfg.Issuer,
+		AuthorizationEndpoint:             cfg.AuthorizationEndpoint,
+		TokenEndpoint:                     cfg.Issuer + "/v1/oauth/token",
+		UserinfoEndpoint:                  cfg.Issuer + "/v1/oauth/userinfo",
+		JWKSURI:                           cfg.Issuer + "/v1/oauth/jwks",
+		EndSessionEndpoint:                cfg.Issuer + "/v1/oauth/logout",
+		IntrospectionEndpoint:             cfg.Issuer + "/v1/oauth/introspect",
+		ScopesSupported:                   []string{models.OpenIDScope, models.ProfileScope, models.EmailScope},
+		ResponseTypesSupported:            []string{models.CodeResponseType},
+		GrantTypesSupported:               []string{models.AuthorizationCodeGrant, models.RefreshTokenGrant},
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  []string{auth.IDTokenAlgorithm},
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+		CodeChallengeMethodsSupported:     []string{auth.PKCEMethodS256},
+		ClaimsSupported:                   []string{"iss", "sub", "aud", "exp", "iat", "auth_time", "nonce", "sid", "email", "name"},
+	}
+}
+
+func errInsufficientScope() error {
+	return httputil.NewError("Insufficient scope", http.StatusForbidden).
+		WithCode(CodeInsufficientScope).
+		WithMessageKey("oauth.insufficientScope")
+}
@@ -0,0 +1,196 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CzarSimon/user-service/pkg/auth"
+	"github.com/CzarSimon/user-service/pkg/httputil"
+	"github.com/CzarSimon/user-service/pkg/models"
+	"github.com/CzarSimon/user-service/pkg/repository/repotest"
+	"github.com/CzarSimon/user-service/pkg/service"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	jose "gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+func TestOIDCRoutes(t *testing.T) {
+	assert := assert.New(t)
+	gin.SetMode(gin.TestMode)
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(err)
+	idIssuer, err := auth.NewRSAIDTokenIssuer("https://id.example.com", key)
+	assert.NoError(err)
+
+	user := models.NewUser("mail@mail.com", "Tester", "McTest", models.UserRole, models.Credentials{UserID: "principal-id"})
+	userRepo := &repotest.MockUserRepo{FindUser: user}
+	roleRepo := &repotest.MockRoleRepo{FindByNamesRoles: models.DefaultRoles()}
+	client := models.OAuthClient{
+		ID:                     "client-id",
+		Name:                   "Web app",
+		Type:                   models.PublicClient,
+		RedirectURIs:           []string{"https://app.example.com/callback"},
+		PostLogoutRedirectURIs: []string{"https://app.example.com/logged-out"},
+		Scopes:                 []string{models.OpenIDScope, models.ProfileScope, models.EmailScope},
+	}
+	codeRepo := &repotest.MockAuthorizationCodeRepo{}
+	sessionRepo := &repotest.MockSessionRepo{}
+	svc := service.NewOAuthService(
+		auth.NewHasher("secret-pepper"),
+		issuer,
+		userRepo,
+		roleRepo,
+		&repotest.MockOAuthClientRepo{FindClient: client},
+		codeRepo,
+		&repotest.MockRefreshTokenRepo{},
+		service.WithOAuthSessions(sessionRepo),
+		service.WithOpenID(idIssuer),
+	)
+
+	r := httputil.NewRouter("user-service", "1.0")
+	AttachOAuthRoutes(r, svc, verifier)
+	AttachOIDCRoutes(r, OIDCConfig{
+		Issuer:                "https://id.example.com",
+		AuthorizationEndpoint: "https://login.example.com/authorize",
+		IDTokenIssuer:         idIssuer,
+	}, svc, verifier)
+
+	// Discovery
+	w := performRequest(r, http.MethodGet, "/.well-known/openid-configuration", "", nil)
+	assert.Equal(http.StatusOK, w.Code)
+	var metadata map[string]interface{}
+	assert.NoError(json.NewDecoder(w.Body).Decode(&metadata))
+	for _, field := range []string{"issuer", "authorization_endpoint", "token_endpoint", "userinfo_endpoint", "jwks_uri", "end_session_endpoint"} {
+		assert.NotEmpty(metadata[field], field)
+	}
+	assert.Equal("https://id.example.com", metadata["issuer"])
+	assert.Equal("https://id.example.com/v1/oauth/jwks", metadata["jwks_uri"])
+	assert.Contains(metadata["response_types_supported"], "code")
+	assert.Contains(metadata["subject_types_supported"], "public")
+	assert.Contains(metadata["id_token_signing_alg_values_supported"], "RS256")
+	assert.Contains(metadata["scopes_supported"], "openid")
+
+	// Authorization code flow
+	codeVerifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	userToken, err := issuer.Issue("principal-id", models.UserRole)
+	assert.NoError(err)
+	principal, err := verifier.Verify(userToken)
+	assert.NoError(err)
+
+	form := url.Values{
+		"response_type":         {models.CodeResponseType},
+		"client_id":             {client.ID},
+		"redirect_uri":          {"https://app.example.com/callback"},
+		"scope":                 {"openid email profile"},
+		"state":                 {"xyz"},
+		"code_challenge":        {auth.PKCEChallenge(codeVerifier)},
+		"code_challenge_method": {auth.PKCEMethodS256},
+		"nonce":                 {"n-0S6_WzA2Mj"},
+		"approved":              {"true"},
+	}
+	req := httptest.NewRequest(http.MethodPost, "/v1/oauth/authorize", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set(httputil.AuthorizationHeader, httputil.BearerPrefix+userToken)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(http.StatusOK, w.Code)
+	var consent struct {
+		RedirectURI string `json:"redirectUri"`
+	}
+	assert.NoError(json.NewDecoder(w.Body).Decode(&consent))
+	redirect, err := url.Parse(consent.RedirectURI)
+	assert.NoError(err)
+	codeRepo.ConsumeCode = codeRepo.SaveArg
+
+	w = performTokenRequest(r, url.Values{
+		"grant_type":    {models.AuthorizationCodeGrant},
+		"code":          {redirect.Query().Get("code")},
+		"redirect_uri":  {"https://app.example.com/callback"},
+		"code_verifier": {codeVerifier},
+		"client_id":     {client.ID},
+	}, client.ID, "")
+	assert.Equal(http.StatusOK, w.Code)
+	var res models.TokenResponse
+	assert.NoError(json.NewDecoder(w.Body).Decode(&res))
+	assert.NotEmpty(res.IDToken)
+
+	// ID token verifies against the published key set
+	w = performRequest(r, http.MethodGet, "/v1/oauth/jwks", "", nil)
+	assert.Equal(http.StatusOK, w.Code)
+	var keySet jose.JSONWebKeySet
+	assert.NoError(json.NewDecoder(w.Body).Decode(&keySet))
+	assert.Len(keySet.Keys, 1)
+	assert.NotContains(w.Body.String(), `"d":`)
+
+	idToken, err := jwt.ParseSigned(res.IDToken)
+	assert.NoError(err)
+	assert.Equal("RS256", idToken.Headers[0].Algorithm)
+	keys := keySet.Key(idToken.Headers[0].KeyID)
+	assert.Len(keys, 1)
+
+	var claims jwt.Claims
+	var oidcClaims struct {
+		Nonce    string `json:"nonce"`
+		AuthTime int64  `json:"auth_time"`
+		SID      string `json:"sid"`
+		Email    string `json:"email"`
+		Name     string `json:"name"`
+	}
+	assert.NoError(idToken.Claims(keys[0].Key, &claims, &oidcClaims))
+	assert.NoError(claims.Validate(jwt.Expected{
+		Issuer:   metadata["issuer"].(string),
+		Audience: jwt.Audience{client.ID},
+		Time:     time.Now(),
+	}))
+	assert.Equal(user.ID, claims.Subject)
+	assert.Equal("n-0S6_WzA2Mj", oidcClaims.Nonce)
+	assert.Equal(principal.CreatedAt.Unix(), oidcClaims.AuthTime)
+	assert.Equal(principal.ID, oidcClaims.SID)
+	assert.Equal("mail@mail.com", oidcClaims.Email)
+	assert.Equal("Tester McTest", oidcClaims.Name)
+
+	// Userinfo
+	w = performRequest(r, http.MethodGet, "/v1/oauth/userinfo", res.AccessToken, nil)
+	assert.Equal(http.StatusOK, w.Code)
+	var info models.UserInfo
+	assert.NoError(json.NewDecoder(w.Body).Decode(&info))
+	assert.Equal(claims.Subject, info.Subject)
+	assert.Equal("mail@mail.com", info.Email)
+	assert.Equal("Tester McTest", info.Name)
+
+	w = performRequest(r, http.MethodPost, "/v1/oauth/userinfo", res.AccessToken, nil)
+	assert.Equal(http.StatusOK, w.Code)
+
+	w = performRequest(r, http.MethodGet, "/v1/oauth/userinfo", "", nil)
+	assert.Equal(http.StatusUnauthorized, w.Code)
+
+	w = performRequest(r, http.MethodGet, "/v1/oauth/userinfo", userToken, nil)
+	assert.Equal(http.StatusForbidden, w.Code)
+	assert.Contains(w.Header().Get("WWW-Authenticate"), `error="insufficient_scope"`)
+
+	// End session
+	query := url.Values{
+		"id_token_hint":            {res.IDToken},
+		"post_logout_redirect_uri": {"https://app.example.com/logged-out"},
+		"state":                    {"abc"},
+	}
+	w = performRequest(r, http.MethodGet, "/v1/oauth/logout?"+query.Encode(), "", nil)
+	assert.Equal(http.StatusFound, w.Code)
+	assert.Equal("https://app.example.com/logged-out?state=abc", w.Header().Get("Location"))
+	assert.Equal([]string{principal.ID}, sessionRepo.RevokeArgs)
+
+	query.Set("post_logout_redirect_uri", "https://evil.example.com")
+	w = performRequest(r, http.MethodGet, "/v1/oauth/logout?"+query.Encode(), "", nil)
+	assert.Equal(http.StatusBadRequest, w.Code)
+
+	w = performRequest(r, http.MethodGet, "/v1/oauth/logout", "", nil)
+	assert.Equal(http.StatusOK, w.Code)
+}
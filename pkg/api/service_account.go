@@ -0,0 +1,81 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/CzarSimon/user-service/pkg/auth"
+	"github.com/CzarSimon/user-service/pkg/httputil"
+	"github.com/CzarSimon/user-service/pkg/models"
+	"github.com/CzarSimon/user-service/pkg/service"
+	"github.com/gin-gonic/gin"
+)
+
+type serviceAccountController struct {
+	svc service.ServiceAccountService
+}
+
+// AttachServiceAccountRoutes attaches the routes for admins to manage service accounts to a router.
+// Service accounts get tokens from the token route attached by AttachOAuthRoutes, using the client credentials grant.
+func AttachServiceAccountRoutes(r gin.IRouter, svc service.ServiceAccountService, verifier auth.Verifier) {
+	ctrl := &serviceAccountController{svc: svc}
+	g := r.Group("/v1/admin/service-accounts", httputil.Authenticate(verifier), httputil.RequirePermission(models.WriteServiceAccountsPermission))
+
+	g.POST("", ctrl.createServiceAccount)
+	g.GET("", ctrl.listServiceAccounts)
+	g.DELETE("/:clientId", ctrl.deleteServiceAccount)
+}
+
+func (ctrl *serviceAccountController) createServiceAccount(c *gin.Context) {
+	principal, err := httputil.GetToken(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var req models.CreateServiceAccountRequest
+	err = c.ShouldBindJSON(&req)
+	if err != nil {
+		c.Error(errInvalidBody())
+		return
+	}
+
+	creds, err := ctrl.svc.Create(c.Request.Context(), principal, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, creds)
+}
+
+func (ctrl *serviceAccountController) listServiceAccounts(c *gin.Context) {
+	principal, err := httputil.GetToken(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	accounts, err := ctrl.svc.List(c.Request.Context(), principal)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, accounts)
+}
+
+func (ctrl *serviceAccountController) deleteServiceAccount(c *gin.Context) {
+	principal, err := httputil.GetToken(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	err = ctrl.svc.Delete(c.Request.Context(), principal, c.Param("clientId"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	httputil.SendOK(c)
+}
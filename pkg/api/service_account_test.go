@@ -0,0 +1,95 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/CzarSimon/user-service/pkg/auth"
+	"github.com/CzarSimon/user-service/pkg/httputil"
+	"github.com/CzarSimon/user-service/pkg/models"
+	"github.com/CzarSimon/user-service/pkg/repository"
+	"github.com/CzarSimon/user-service/pkg/repository/repotest"
+	"github.com/CzarSimon/user-service/pkg/service"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServiceAccountRoutes(t *testing.T) {
+	assert := assert.New(t)
+	gin.SetMode(gin.TestMode)
+	hasher := auth.NewHasher("secret-pepper")
+	accountRepo := &repotest.MockServiceAccountRepo{}
+	oauthSvc := service.NewOAuthService(
+		hasher,
+		issuer,
+		&repotest.MockUserRepo{},
+		&repotest.MockRoleRepo{FindByNamesRoles: models.DefaultRoles()},
+		&repotest.MockOAuthClientRepo{FindErr: repository.ErrNoSuchClient},
+		&repotest.MockAuthorizationCodeRepo{},
+		&repotest.MockRefreshTokenRepo{},
+		service.WithServiceAccounts(accountRepo),
+	)
+
+	r := httputil.NewRouter("user-service", "1.0")
+	AttachOAuthRoutes(r, oauthSvc, verifier)
+	AttachServiceAccountRoutes(r, service.NewServiceAccountService(hasher, accountRepo), verifier)
+
+	req := models.CreateServiceAccountRequest{
+		Name:        "Nightly export",
+		Permissions: []string{models.ReadUsersPermission, models.ReadAuditLogPermission},
+	}
+	w := performRequest(r, http.MethodPost, "/v1/admin/service-accounts", issueToken(t, models.UserRole), req)
+	assert.Equal(http.StatusForbidden, w.Code)
+
+	adminToken := issueToken(t, models.AdminRole, models.Permissions(models.DefaultRoles())...)
+	w = performRequest(r, http.MethodPost, "/v1/admin/service-accounts", adminToken, req)
+	assert.Equal(http.StatusOK, w.Code)
+	assert.NotContains(w.Body.String(), accountRepo.SaveArg.SecretHash)
+	var creds models.ServiceAccountCredentials
+	assert.NoError(json.NewDecoder(w.Body).Decode(&creds))
+	assert.NotEmpty(creds.ClientSecret)
+	assert.Equal("principal-id", creds.Account.CreatedBy)
+	accountRepo.FindAccount = accountRepo.SaveArg
+	accountRepo.FindAllAccounts = []models.ServiceAccount{accountRepo.SaveArg}
+
+	form := url.Values{
+		"grant_type": {models.ClientCredentialsGrant},
+		"scope":      {models.ReadUsersPermission},
+	}
+	w = performTokenRequest(r, form, creds.Account.ID, creds.ClientSecret)
+	assert.Equal(http.StatusOK, w.Code)
+	var res models.TokenResponse
+	assert.NoError(json.NewDecoder(w.Body).Decode(&res))
+	assert.Empty(res.RefreshToken)
+	token, err := verifier.Verify(res.AccessToken)
+	assert.NoError(err)
+	assert.True(token.IsServiceAccount())
+	assert.Equal(creds.Account.ID, token.Subject)
+	assert.Equal([]string{models.ReadUsersPermission}, token.Permissions)
+
+	w = performTokenRequest(r, form, creds.Account.ID, "wrong-secret")
+	assert.Equal(http.StatusUnauthorized, w.Code)
+	assert.Contains(w.Body.String(), `"error":"invalid_client"`)
+
+	form.Set("scope", models.WriteUsersPermission)
+	w = performTokenRequest(r, form, creds.Account.ID, creds.ClientSecret)
+	assert.Equal(http.StatusBadRequest, w.Code)
+	assert.Contains(w.Body.String(), `"error":"invalid_scope"`)
+
+	w = performRequest(r, http.MethodGet, "/v1/admin/service-accounts", adminToken, nil)
+	assert.Equal(http.StatusOK, w.Code)
+	var accounts []models.ServiceAccount
+	assert.NoError(json.NewDecoder(w.Body).Decode(&accounts))
+	assert.Len(accounts, 1)
+	assert.Equal(creds.Account.ID, accounts[0].ID)
+
+	w = performRequest(r, http.MethodDelete, "/v1/admin/service-accounts/"+creds.Account.ID, adminToken, nil)
+	assert.Equal(http.StatusOK, w.Code)
+	assert.Equal(creds.Account.ID, accountRepo.DeleteArg)
+
+	accountRepo.DeleteErr = repository.ErrNoSuchServiceAccount
+	w = performRequest(r, http.MethodDelete, "/v1/admin/service-accounts/"+creds.Account.ID, adminToken, nil)
+	assert.Equal(http.StatusNotFound, w.Code)
+}
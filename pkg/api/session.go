@@ -0,0 +1,73 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/CzarSimon/user-service/pkg/auth"
+	"github.com/CzarSimon/user-service/pkg/httputil"
+	"github.com/CzarSimon/user-service/pkg/service"
+	"github.com/gin-gonic/gin"
+)
+
+type sessionController struct {
+	svc service.SessionService
+}
+
+// AttachSessionRoutes attaches the routes for users to list and revoke their sessions to a router.
+// Admins with permission to read and write users can manage the sessions of any user.
+func AttachSessionRoutes(r gin.IRouter, svc service.SessionService, verifier auth.Verifier) {
+	ctrl := &sessionController{svc: svc}
+	g := r.Group("/v1/users/:userId/sessions", httputil.Authenticate(verifier))
+
+	g.GET("", ctrl.listSessions)
+	g.DELETE("", ctrl.revokeOtherSessions)
+	g.DELETE("/:sessionId", ctrl.revokeSession)
+}
+
+func (ctrl *sessionController) listSessions(c *gin.Context) {
+	principal, err := httputil.GetToken(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	sessions, err := ctrl.svc.ListSessions(c.Request.Context(), principal, c.Param("userId"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+func (ctrl *sessionController) revokeSession(c *gin.Context) {
+	principal, err := httputil.GetToken(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	err = ctrl.svc.RevokeSession(c.Request.Context(), principal, c.Param("userId"), c.Param("sessionId"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	httputil.SendOK(c)
+}
+
+func (ctrl *sessionController) revokeOtherSessions(c *gin.Context) {
+	principal, err := httputil.GetToken(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	revoked, err := ctrl.svc.RevokeOtherSessions(c.Request.Context(), principal, c.Param("userId"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"revoked": revoked})
+}
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/CzarSimon/user-service/pkg/httputil"
+	"github.com/CzarSimon/user-service/pkg/models"
+	"github.com/CzarSimon/user-service/pkg/repository/repotest"
+	"github.com/CzarSimon/user-service/pkg/service"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessionRoutes(t *testing.T) {
+	assert := assert.New(t)
+	gin.SetMode(gin.TestMode)
+	session := models.NewSession("session-id", "principal-id", models.ClientInfo{IP: "10.0.0.1"})
+	sessionRepo := &repotest.MockSessionRepo{
+		FindSession:          session,
+		FindByUserIDSessions: []models.Session{session},
+	}
+	r := httputil.NewRouter("user-service", "1.0")
+	AttachSessionRoutes(r, service.NewSessionService(sessionRepo), verifier)
+	token := issueToken(t, models.UserRole)
+
+	w := performRequest(r, http.MethodGet, "/v1/users/principal-id/sessions", token, nil)
+	assert.Equal(http.StatusOK, w.Code)
+	var sessions []models.Session
+	assert.NoError(json.NewDecoder(w.Body).Decode(&sessions))
+	assert.Len(sessions, 1)
+	assert.Equal("10.0.0.1", sessions[0].IP)
+
+	w = performRequest(r, http.MethodDelete, "/v1/users/principal-id/sessions/session-id", token, nil)
+	assert.Equal(http.StatusOK, w.Code)
+	assert.Equal([]string{"session-id"}, sessionRepo.RevokeArgs)
+
+	w = performRequest(r, http.MethodDelete, "/v1/users/principal-id/sessions", token, nil)
+	assert.Equal(http.StatusOK, w.Code)
+	assert.JSONEq(`{"revoked": 1}`, w.Body.String())
+
+	w = performRequest(r, http.MethodGet, "/v1/users/other-user/sessions", token, nil)
+	assert.Equal(http.StatusForbidden, w.Code)
+
+	adminToken := issueToken(t, models.AdminRole, models.Permissions(models.DefaultRoles())...)
+	w = performRequest(r, http.MethodDelete, "/v1/users/other-user/sessions", adminToken, nil)
+	assert.Equal(http.StatusOK, w.Code)
+
+	w = performRequest(r, http.MethodGet, "/v1/users/principal-id/sessions", "", nil)
+	assert.Equal(http.StatusUnauthorized, w.Code)
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/CzarSimon/user-service/pkg/auth"
+	"github.com/CzarSimon/user-service/pkg/httputil"
+	"github.com/CzarSimon/user-service/pkg/models"
+	"github.com/CzarSimon/user-service/pkg/service"
+	"github.com/gin-gonic/gin"
+)
+
+type userController struct {
+	svc service.UserService
+}
+
+// AttachUserRoutes attaches the routes for authenticated users to manage their account to a router.
+func AttachUserRoutes(r gin.IRouter, svc service.UserService, verifier auth.Verifier) {
+	ctrl := &userController{svc: svc}
+	g := r.Group("/v1/users", httputil.Authenticate(verifier))
+
+	g.GET("/:userId", ctrl.getUser)
+	g.PATCH("/:userId", ctrl.updateProfile)
+}
+
+func (ctrl *userController) getUser(c *gin.Context) {
+	principal, err := httputil.GetToken(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	user, err := ctrl.svc.Find(c.Request.Context(), principal, c.Param("userId"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+func (ctrl *userController) updateProfile(c *gin.Context) {
+	principal, err := httputil.GetToken(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var req models.UpdateProfileRequest
+	err = c.ShouldBindJSON(&req)
+	if err != nil {
+		c.Error(errInvalidBody())
+		return
+	}
+	req.UserID = c.Param("userId")
+
+	user, err := ctrl.svc.UpdateProfile(c.Request.Context(), principal, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// Error codes returned by the api on top of those returned by the service.
+const (
+	CodeInvalidBody       = "INVALID_BODY"
+	CodeInvalidQuery      = "INVALID_QUERY"
+	CodeInsufficientScope = "INSUFFICIENT_SCOPE"
+)
+
+func errInvalidBody() error {
+	return httputil.NewError("Failed to parse request body", http.StatusBadRequest).
+		WithCode(CodeInvalidBody).
+		WithMessageKey("request.invalidBody")
+}
+
+func errInvalidQuery(message, key string, args ...interface{}) error {
+	return httputil.NewError(message, http.StatusBadRequest).
+		WithCode(CodeInvalidQuery).
+		WithMessageKey(key, args...)
+}
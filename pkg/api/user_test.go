@@ -0,0 +1,56 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/CzarSimon/user-service/pkg/auth"
+	"github.com/CzarSimon/user-service/pkg/httputil"
+	"github.com/CzarSimon/user-service/pkg/models"
+	"github.com/CzarSimon/user-service/pkg/repository/repotest"
+	"github.com/CzarSimon/user-service/pkg/service"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateProfile(t *testing.T) {
+	assert := assert.New(t)
+	gin.SetMode(gin.TestMode)
+	user := models.NewUser("mail@mail.com", "Tester", "McTest", models.UserRole, models.Credentials{})
+	userRepo := &repotest.MockUserRepo{
+		FindUser: user,
+	}
+	roleRepo := &repotest.MockRoleRepo{}
+	svc := service.NewUserService(auth.NewHasher("secret-pepper"), issuer, userRepo, roleRepo)
+
+	r := httputil.NewRouter("user-service", "1.0")
+	AttachUserRoutes(r, svc, verifier)
+
+	token, err := issuer.Issue(user.ID, models.UserRole)
+	assert.NoError(err)
+
+	body := map[string]interface{}{
+		"middleAndLastName": "McUpdated",
+		"version":           1,
+	}
+	w := performRequest(r, http.MethodPatch, "/v1/users/"+user.ID, token, body)
+	assert.Equal(http.StatusOK, w.Code, w.Body.String())
+
+	var updated models.User
+	err = json.NewDecoder(w.Body).Decode(&updated)
+	assert.NoError(err)
+	assert.Equal("Tester", updated.Surname)
+	assert.Equal("McUpdated", updated.MiddleAndLastName)
+	assert.Equal(2, updated.Version)
+
+	body["version"] = 5
+	w = performRequest(r, http.MethodPatch, "/v1/users/"+user.ID, token, body)
+	assert.Equal(http.StatusConflict, w.Code)
+
+	w = performRequest(r, http.MethodPatch, "/v1/users/other-user-id", token, body)
+	assert.Equal(http.StatusForbidden, w.Code)
+
+	w = performRequest(r, http.MethodGet, "/v1/users/"+user.ID, token, nil)
+	assert.Equal(http.StatusOK, w.Code)
+}
@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+)
+
+// apiKeyScheme start of every API key, which makes leaked keys easy to recognize.
+const apiKeyScheme = "usk_"
+
+// API key part lengths in random bytes, which are hex encoded.
+const (
+	apiKeyPrefixLength = 12
+	apiKeySecretLength = 32
+)
+
+// Common API key errors.
+var (
+	ErrInvalidAPIKey = errors.New("invalid api key")
+)
+
+// APIKeyVerifier interface for verifying API keys, resolving them to the principal of the user they were issued to.
+// Verifiers may implement it on top of Verifier, for httputil.Authenticate to accept API keys as well as tokens.
+type APIKeyVerifier interface {
+	VerifyAPIKey(key string) (Token, error)
+}
+
+// GenerateAPIKey generates a new API key along with its prefix. The prefix identifies the key
+// and can be stored and shown in clear text, while only a hash of the full key should be stored.
+func GenerateAPIKey() (string, string, error) {
+	prefix, err := GenSalt(apiKeyPrefixLength)
+	if err != nil {
+		return "", "", err
+	}
+
+	secret, err := GenSalt(apiKeySecretLength)
+	if err != nil {
+		return "", "", err
+	}
+
+	return apiKeyScheme + prefix + "_" + secret, prefix, nil
+}
+
+// ParseAPIKeyPrefix returns the prefix of an API key generated by GenerateAPIKey.
+func ParseAPIKeyPrefix(key string) (string, error) {
+	if !strings.HasPrefix(key, apiKeyScheme) {
+		return "", ErrInvalidAPIKey
+	}
+
+	parts := strings.Split(strings.TrimPrefix(key, apiKeyScheme), "_")
+	if len(parts) != 2 || len(parts[0]) != 2*apiKeyPrefixLength || len(parts[1]) != 2*apiKeySecretLength {
+		return "", ErrInvalidAPIKey
+	}
+
+	return parts[0], nil
+}
@@ -0,0 +1,44 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateAPIKey(t *testing.T) {
+	assert := assert.New(t)
+
+	key, prefix, err := GenerateAPIKey()
+	assert.NoError(err)
+	assert.True(strings.HasPrefix(key, "usk_"+prefix+"_"))
+	assert.Len(prefix, 24)
+
+	parsed, err := ParseAPIKeyPrefix(key)
+	assert.NoError(err)
+	assert.Equal(prefix, parsed)
+
+	other, otherPrefix, err := GenerateAPIKey()
+	assert.NoError(err)
+	assert.NotEqual(key, other)
+	assert.NotEqual(prefix, otherPrefix)
+}
+
+func TestParseAPIKeyPrefix(t *testing.T) {
+	key, _, err := GenerateAPIKey()
+	assert.NoError(t, err)
+
+	for _, invalid := range []string{
+		"",
+		"usk_",
+		strings.TrimPrefix(key, "usk_"),
+		"abc_" + strings.TrimPrefix(key, "usk_"),
+		key + "_extra",
+		key[:len(key)-1],
+		"usk_short_" + strings.Repeat("a", 64),
+	} {
+		_, err := ParseAPIKeyPrefix(invalid)
+		assert.Equal(t, ErrInvalidAPIKey, err, invalid)
+	}
+}
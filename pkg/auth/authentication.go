@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"strings"
 	"time"
 
 	"github.com/CzarSimon/user-service/pkg/id"
@@ -18,11 +19,34 @@ var (
 	ErrInvalidTokenContent = errors.New("invalid token content")
 	ErrInvalidToken        = errors.New("token is invalid")
 	ErrExpiredToken        = errors.New("token has expired")
+	ErrMissingKey          = errors.New("key is not loaded")
 )
 
 // Issuer interface for issuing auth tokens.
 type Issuer interface {
-	Issue(sub, role string) (string, error)
+	Issue(sub, role string, permissions ...string) (string, error)
+}
+
+// Principal types, telling tokens issued to users apart from those issued to service accounts.
+const (
+	UserPrincipal    = "user"
+	ServicePrincipal = "service"
+)
+
+// Grant delegation of access to an OAuth client, which is included in the tokens issued to it.
+// PrincipalType is ServicePrincipal if the client acts on its own behalf, and defaults to UserPrincipal.
+type Grant struct {
+	ClientID      string
+	Scopes        []string
+	PrincipalType string
+}
+
+// GrantIssuer interface for issuing tokens to OAuth clients on behalf of a subject.
+type GrantIssuer interface {
+	Issuer
+	IssueGrant(sub, role string, grant Grant, permissions ...string) (string, error)
+	// TokenAge returns how long issued tokens are valid.
+	TokenAge() time.Duration
 }
 
 // Verifier interface for verifying tokens.
@@ -30,22 +54,74 @@ type Verifier interface {
 	Verify(token string) (Token, error)
 }
 
-// Token body of a JWT token.
+// KeyChecker interface for checking that the key material used to issue or verify tokens is loaded.
+type KeyChecker interface {
+	CheckKey() error
+}
+
+// Token body of a JWT token. ClientID and Scopes are only set
+// if the token was issued to an OAuth client. PrincipalType tells
+// whether the subject is a user or a service account. APIKey is
+// set if the token was resolved from an API key rather than issued.
 type Token struct {
-	ID        string
-	Subject   string
-	Role      string
-	CreatedAt time.Time
+	ID            string
+	Issuer        string
+	Subject       string
+	Role          string
+	Permissions   []string
+	ClientID      string
+	Scopes        []string
+	PrincipalType string
+	APIKey        bool
+	CreatedAt     time.Time
+	ExpiresAt     time.Time
 }
 
 // newToken creates a new token with a unique ID.
-func newToken(sub, role string) Token {
+func newToken(sub, role string, permissions []string) Token {
 	return Token{
-		ID:        id.New(),
-		Subject:   sub,
-		Role:      role,
-		CreatedAt: time.Now().UTC(),
+		ID:          id.New(),
+		Subject:     sub,
+		Role:        role,
+		Permissions: permissions,
+		CreatedAt:   time.Now().UTC(),
+	}
+}
+
+// HasPermission checks if the token grants a given permission.
+func (t Token) HasPermission(permission string) bool {
+	for _, p := range t.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// IsServiceAccount checks if the token was issued to a service account rather than a user.
+func (t Token) IsServiceAccount() bool {
+	return t.PrincipalType == ServicePrincipal
+}
+
+// IsDelegated checks if the token was issued to an OAuth client acting on behalf of a user.
+func (t Token) IsDelegated() bool {
+	return t.ClientID != "" && !t.IsServiceAccount()
+}
+
+// IsDirectLogin checks if the token was issued to a user at login, rather than to an
+// OAuth client or a service account, or resolved from an API key.
+func (t Token) IsDirectLogin() bool {
+	return t.ClientID == "" && !t.IsServiceAccount() && !t.APIKey
+}
+
+// HasScope checks if the token has been granted a given OAuth scope.
+func (t Token) HasScope(scope string) bool {
+	for _, s := range t.Scopes {
+		if s == scope {
+			return true
+		}
 	}
+	return false
 }
 
 // JWTCredentials credentials to issue and verify JWT tokens.
@@ -62,14 +138,19 @@ func ReadJWTCredentials(r io.Reader) (JWTCredentials, error) {
 }
 
 type customJWTClaims struct {
-	Role string `json:"role"`
+	Role          string   `json:"role"`
+	Permissions   []string `json:"permissions,omitempty"`
+	ClientID      string   `json:"client_id,omitempty"`
+	Scope         string   `json:"scope,omitempty"`
+	PrincipalType string   `json:"principal_type,omitempty"`
 }
 
 // JWTIssuer issuer implementation that issues JWT tokens.
 type JWTIssuer struct {
-	name     string
-	signer   jose.Signer
-	tokenAge time.Duration
+	name      string
+	signer    jose.Signer
+	keyLoaded bool
+	tokenAge  time.Duration
 }
 
 // NewJWTIssuer creates a new JWTIssuer.
@@ -80,20 +161,36 @@ func NewJWTIssuer(creds JWTCredentials) *JWTIssuer {
 	}
 
 	return &JWTIssuer{
-		name:     creds.Issuer,
-		signer:   signer,
-		tokenAge: 24 * time.Hour,
+		name:      creds.Issuer,
+		signer:    signer,
+		keyLoaded: creds.Secret != "",
+		tokenAge:  24 * time.Hour,
+	}
+}
+
+// CheckKey returns ErrMissingKey if the issuer has no signing key.
+func (i *JWTIssuer) CheckKey() error {
+	if !i.keyLoaded {
+		return ErrMissingKey
 	}
+	return nil
 }
 
-// Issue issues a JWT token.
-func (i *JWTIssuer) Issue(sub, role string) (string, error) {
+// Issue issues a JWT token with an optional list of permissions.
+func (i *JWTIssuer) Issue(sub, role string, permissions ...string) (string, error) {
+	return i.IssueGrant(sub, role, Grant{}, permissions...)
+}
+
+// IssueGrant issues a JWT token to an OAuth client, with the client id
+// and granted scopes in the client_id and scope claims. Tokens issued
+// to service accounts have the principal_type claim set to service.
+func (i *JWTIssuer) IssueGrant(sub, role string, grant Grant, permissions ...string) (string, error) {
 	err := i.verifyTokenContent(sub, role)
 	if err != nil {
 		return "", err
 	}
 
-	token := newToken(sub, role)
+	token := newToken(sub, role, permissions)
 	claims := jwt.Claims{
 		Subject:   token.Subject,
 		ID:        token.ID,
@@ -102,10 +199,40 @@ func (i *JWTIssuer) Issue(sub, role string) (string, error) {
 		IssuedAt:  jwt.NewNumericDate(token.CreatedAt),
 		Expiry:    jwt.NewNumericDate(token.CreatedAt.Add(i.tokenAge)),
 	}
-	customClaims := customJWTClaims{Role: role}
+	customClaims := customJWTClaims{
+		Role:        role,
+		Permissions: permissions,
+		ClientID:    grant.ClientID,
+		Scope:       strings.Join(grant.Scopes, " "),
+	}
+	if grant.PrincipalType != "" && grant.PrincipalType != UserPrincipal {
+		customClaims.PrincipalType = grant.PrincipalType
+	}
 	return jwt.Signed(i.signer).Claims(claims).Claims(customClaims).CompactSerialize()
 }
 
+// TokenAge returns how long issued tokens are valid.
+func (i *JWTIssuer) TokenAge() time.Duration {
+	return i.tokenAge
+}
+
+// TokenID reads the id of a JWT token without verifying it. It must only be used
+// on tokens the caller has issued itself, e.g. to keep track of issued tokens.
+func TokenID(rawToken string) (string, error) {
+	token, err := jwt.ParseSigned(rawToken)
+	if err != nil {
+		return "", ErrInvalidToken
+	}
+
+	var claims jwt.Claims
+	err = token.UnsafeClaimsWithoutVerification(&claims)
+	if err != nil || claims.ID == "" {
+		return "", ErrInvalidToken
+	}
+
+	return claims.ID, nil
+}
+
 func (i *JWTIssuer) verifyTokenContent(sub, role string) error {
 	if sub == "" {
 		return ErrInvalidTokenContent
@@ -133,6 +260,14 @@ func NewJWTVerifier(creds JWTCredentials, leeway time.Duration) *JWTVerifier {
 	}
 }
 
+// CheckKey returns ErrMissingKey if the verifier has no key to verify signatures with.
+func (v *JWTVerifier) CheckKey() error {
+	if len(v.secret) == 0 {
+		return ErrMissingKey
+	}
+	return nil
+}
+
 // Verify verifies a JWT token string.
 func (v *JWTVerifier) Verify(rawToken string) (Token, error) {
 	token, err := jwt.ParseSigned(rawToken)
@@ -200,10 +335,30 @@ func (v *JWTVerifier) checkTokenExpiry(claims jwt.Claims) error {
 }
 
 func getTokenFromClaims(claims jwt.Claims, customClaims customJWTClaims) Token {
+	// The principal type claim is only set for service accounts.
+	principalType := customClaims.PrincipalType
+	if principalType == "" {
+		principalType = UserPrincipal
+	}
+
 	return Token{
-		ID:        claims.ID,
-		Subject:   claims.Subject,
-		Role:      customClaims.Role,
-		CreatedAt: claims.IssuedAt.Time().UTC(),
+		ID:            claims.ID,
+		Issuer:        claims.Issuer,
+		Subject:       claims.Subject,
+		Role:          customClaims.Role,
+		Permissions:   customClaims.Permissions,
+		ClientID:      customClaims.ClientID,
+		Scopes:        parseScope(customClaims.Scope),
+		PrincipalType: principalType,
+		CreatedAt:     claims.IssuedAt.Time().UTC(),
+		ExpiresAt:     claims.Expiry.Time().UTC(),
+	}
+}
+
+// parseScope splits a space delimited scope claim into its scopes.
+func parseScope(scope string) []string {
+	if scope == "" {
+		return nil
 	}
+	return strings.Fields(scope)
 }
@@ -47,8 +47,9 @@ func TestJWTIssueAndVerify(t *testing.T) {
 	wrongSecretVerfifier := NewJWTVerifier(wrongSecretCreds, time.Minute)
 
 	type args struct {
-		sub  string
-		role string
+		sub         string
+		role        string
+		permissions []string
 	}
 	tests := []struct {
 		name                string
@@ -74,8 +75,9 @@ func TestJWTIssueAndVerify(t *testing.T) {
 			issuer:   issuer,
 			verifier: verifier,
 			args: args{
-				sub:  "user-id-2",
-				role: models.AdminRole,
+				sub:         "user-id-2",
+				role:        models.AdminRole,
+				permissions: []string{models.ReadUsersPermission, models.WriteUsersPermission},
 			},
 			wantErr:             nil,
 			wantVerificationErr: nil,
@@ -133,7 +135,7 @@ func TestJWTIssueAndVerify(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			loopStartTime := time.Now().UTC().Add(-2 * time.Second)
-			rawToken, err := tt.issuer.Issue(tt.args.sub, tt.args.role)
+			rawToken, err := tt.issuer.Issue(tt.args.sub, tt.args.role, tt.args.permissions...)
 			if err != tt.wantErr {
 				t.Errorf("JWTIssuer.Issue() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -160,6 +162,11 @@ func TestJWTIssueAndVerify(t *testing.T) {
 
 			assert.Equal(t, tt.args.sub, token.Subject)
 			assert.Equal(t, tt.args.role, token.Role)
+			assert.Equal(t, tt.args.permissions, token.Permissions)
+			for _, permission := range tt.args.permissions {
+				assert.True(t, token.HasPermission(permission))
+			}
+			assert.False(t, token.HasPermission(models.AssignRolesPermission))
 			if loopStartTime.After(token.CreatedAt) {
 				t.Errorf("JWTVerifier.Verify() token.CreatedAt = %v, Should be after: %v", token.CreatedAt, loopStartTime)
 				return
@@ -167,3 +174,84 @@ func TestJWTIssueAndVerify(t *testing.T) {
 		})
 	}
 }
+
+func TestTokenID(t *testing.T) {
+	assert := assert.New(t)
+	creds := JWTCredentials{
+		Issuer: "issuer-name",
+		Secret: "super-secret-token",
+	}
+
+	rawToken, err := NewJWTIssuer(creds).Issue("user-id", "USER")
+	assert.NoError(err)
+	token, err := NewJWTVerifier(creds, time.Minute).Verify(rawToken)
+	assert.NoError(err)
+
+	tokenID, err := TokenID(rawToken)
+	assert.NoError(err)
+	assert.Equal(token.ID, tokenID)
+
+	_, err = TokenID("not-a-token")
+	assert.Equal(ErrInvalidToken, err)
+}
+
+func TestJWTIssueGrant(t *testing.T) {
+	assert := assert.New(t)
+	creds := JWTCredentials{
+		Issuer: "issuer-name",
+		Secret: "super-secret-token",
+	}
+	issuer := NewJWTIssuer(creds)
+	verifier := NewJWTVerifier(creds, time.Minute)
+
+	grant := Grant{
+		ClientID: "client-id",
+		Scopes:   []string{"openid", models.ReadUsersPermission},
+	}
+	rawToken, err := issuer.IssueGrant("user-id", models.UserRole, grant, models.ReadUsersPermission)
+	assert.NoError(err)
+
+	token, err := verifier.Verify(rawToken)
+	assert.NoError(err)
+	assert.Equal("user-id", token.Subject)
+	assert.Equal("client-id", token.ClientID)
+	assert.Equal(grant.Scopes, token.Scopes)
+	assert.True(token.HasScope("openid"))
+	assert.False(token.HasScope(models.WriteUsersPermission))
+	assert.True(token.IsDelegated())
+	assert.False(token.IsDirectLogin())
+	assert.Equal([]string{models.ReadUsersPermission}, token.Permissions)
+	assert.Equal(UserPrincipal, token.PrincipalType)
+	assert.Equal("issuer-name", token.Issuer)
+	assert.Equal(token.CreatedAt.Add(24*time.Hour), token.ExpiresAt)
+
+	rawToken, err = issuer.Issue("user-id", models.UserRole)
+	assert.NoError(err)
+	token, err = verifier.Verify(rawToken)
+	assert.NoError(err)
+	assert.Empty(token.ClientID)
+	assert.Nil(token.Scopes)
+	assert.Equal(UserPrincipal, token.PrincipalType)
+	assert.False(token.IsServiceAccount())
+	assert.False(token.IsDelegated())
+	assert.True(token.IsDirectLogin())
+	token.APIKey = true
+	assert.False(token.IsDirectLogin())
+
+	grant = Grant{
+		ClientID:      "service-account-id",
+		Scopes:        []string{models.ReadUsersPermission},
+		PrincipalType: ServicePrincipal,
+	}
+	rawToken, err = issuer.IssueGrant("service-account-id", models.ServiceRole, grant, models.ReadUsersPermission)
+	assert.NoError(err)
+	token, err = verifier.Verify(rawToken)
+	assert.NoError(err)
+	assert.Equal(ServicePrincipal, token.PrincipalType)
+	assert.True(token.IsServiceAccount())
+	assert.False(token.IsDelegated())
+	assert.False(token.IsDirectLogin())
+	assert.Equal("service-account-id", token.ClientID)
+
+	assert.Equal(24*time.Hour, issuer.TokenAge())
+}
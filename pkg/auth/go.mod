@@ -9,3 +9,8 @@ require (
 	golang.org/x/crypto v0.0.0-20190411191339-88737f569e3a
 	gopkg.in/square/go-jose.v2 v2.3.1
 )
+
+replace (
+	github.com/CzarSimon/user-service/pkg/id => ../id
+	github.com/CzarSimon/user-service/pkg/models => ../models
+)
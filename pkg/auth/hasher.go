@@ -35,6 +35,13 @@ func Hmac(plaintext, key []byte) (string, error) {
 	return mac, nil
 }
 
+// HashToken computes a SHA-256 hash of a randomly generated token.
+// Suitable for storing high entropy tokens that need to be looked up by their value.
+func HashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
 // GenSalt generates random a salt.
 func GenSalt(length int) (string, error) {
 	nonce := make([]byte, length)
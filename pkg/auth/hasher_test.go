@@ -42,6 +42,26 @@ func TestHmac(t *testing.T) {
 	}
 }
 
+func TestHashToken(t *testing.T) {
+	token, err := GenSalt(32)
+	if err != nil {
+		t.Fatalf("GenSalt() unexpected error = %v", err)
+	}
+
+	hash := HashToken(token)
+	if len(hash) != 64 {
+		t.Errorf("len(HashToken()) = %d, want %d", len(hash), 64)
+	}
+
+	if hash != HashToken(token) {
+		t.Errorf("HashToken() not deterministic")
+	}
+
+	if hash == HashToken(token+"0") {
+		t.Errorf("HashToken() same hash for different tokens")
+	}
+}
+
 func TestGenSalt(t *testing.T) {
 	lastSalt := ""
 	testCases := 100
@@ -0,0 +1,303 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Defaults of the IntrospectionClient.
+const (
+	defaultIntrospectionCacheTTL = time.Minute
+	defaultIntrospectionTimeout  = 10 * time.Second
+	maxIntrospectionCacheSize    = 10000
+	accessTokenExpiryMargin      = time.Minute
+)
+
+// Introspection response of an RFC 7662 token introspection endpoint. Inactive tokens are only described
+// by Active. The role, permissions and principal_type members extend the standard ones to carry the rest
+// of a Token, so that resource servers can authorize requests in the same way as with a local Verifier.
+type Introspection struct {
+	Active        bool     `json:"active"`
+	Subject       string   `json:"sub,omitempty"`
+	Role          string   `json:"role,omitempty"`
+	Permissions   []string `json:"permissions,omitempty"`
+	ClientID      string   `json:"client_id,omitempty"`
+	Scope         string   `json:"scope,omitempty"`
+	PrincipalType string   `json:"principal_type,omitempty"`
+	ExpiresAt     int64    `json:"exp,omitempty"`
+	IssuedAt      int64    `json:"iat,omitempty"`
+	ID            string   `json:"jti,omitempty"`
+	Issuer        string   `json:"iss,omitempty"`
+}
+
+// NewIntrospection creates the introspection response of an active token.
+func NewIntrospection(token Token) Introspection {
+	return Introspection{
+		Active:        true,
+		Subject:       token.Subject,
+		Role:          token.Role,
+		Permissions:   token.Permissions,
+		ClientID:      token.ClientID,
+		Scope:         strings.Join(token.Scopes, " "),
+		PrincipalType: token.PrincipalType,
+		ExpiresAt:     unixTime(token.ExpiresAt),
+		IssuedAt:      unixTime(token.CreatedAt),
+		ID:            token.ID,
+		Issuer:        token.Issuer,
+	}
+}
+
+// Token returns the token described by an introspection response.
+// Returns ErrInvalidToken if the token is not active and ErrExpiredToken if it has expired since.
+func (i Introspection) Token() (Token, error) {
+	if !i.Active || i.Subject == "" {
+		return Token{}, ErrInvalidToken
+	}
+
+	token := Token{
+		ID:            i.ID,
+		Issuer:        i.Issuer,
+		Subject:       i.Subject,
+		Role:          i.Role,
+		Permissions:   i.Permissions,
+		ClientID:      i.ClientID,
+		Scopes:        parseScope(i.Scope),
+		PrincipalType: i.PrincipalType,
+		CreatedAt:     fromUnixTime(i.IssuedAt),
+		ExpiresAt:     fromUnixTime(i.ExpiresAt),
+	}
+	if token.PrincipalType == "" {
+		token.PrincipalType = UserPrincipal
+	}
+
+	if !token.ExpiresAt.IsZero() && !time.Now().Before(token.ExpiresAt) {
+		return Token{}, ErrExpiredToken
+	}
+
+	return token, nil
+}
+
+// IntrospectionConfig configuration of an IntrospectionClient.
+type IntrospectionConfig struct {
+	IntrospectionURL string
+	// TokenURL, ClientID and ClientSecret are the token endpoint and credentials of the service account
+	// the client authenticates as, which must be allowed to introspect tokens.
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	// CacheTTL how long introspection results are cached, which is also how long a revoked token
+	// may still be accepted. Defaults to one minute.
+	CacheTTL time.Duration
+	// HTTPClient defaults to a client with a 10 second timeout.
+	HTTPClient *http.Client
+}
+
+// IntrospectionClient Verifier implementation for resource servers that verifies tokens by calling
+// a token introspection endpoint. Results are cached for at most the cache TTL and never beyond
+// the expiry of the token. Authenticates with an access token issued with the client credentials grant.
+type IntrospectionClient struct {
+	cfg        IntrospectionConfig
+	httpClient *http.Client
+
+	mu    sync.Mutex
+	cache map[string]cachedIntrospection
+
+	tokenMu           sync.Mutex
+	accessToken       string
+	accessTokenExpiry time.Time
+}
+
+type cachedIntrospection struct {
+	token     Token
+	err       error
+	expiresAt time.Time
+}
+
+// NewIntrospectionClient creates a new IntrospectionClient.
+func NewIntrospectionClient(cfg IntrospectionConfig) *IntrospectionClient {
+	if cfg.CacheTTL == 0 {
+		cfg.CacheTTL = defaultIntrospectionCacheTTL
+	}
+
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: defaultIntrospectionTimeout}
+	}
+
+	return &IntrospectionClient{
+		cfg:        cfg,
+		httpClient: httpClient,
+		cache:      make(map[string]cachedIntrospection),
+	}
+}
+
+// Verify verifies a token by introspecting it. Errors calling the endpoint are returned as is and are not cached.
+func (c *IntrospectionClient) Verify(rawToken string) (Token, error) {
+	key := HashToken(rawToken)
+	if result, ok := c.cached(key); ok {
+		return result.token, result.err
+	}
+
+	res, err := c.introspect(rawToken)
+	if err != nil {
+		return Token{}, err
+	}
+
+	token, err := res.Token()
+	c.store(key, token, err)
+	return token, err
+}
+
+func (c *IntrospectionClient) cached(key string) (cachedIntrospection, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	result, ok := c.cache[key]
+	if !ok || !time.Now().Before(result.expiresAt) {
+		return cachedIntrospection{}, false
+	}
+	return result, true
+}
+
+func (c *IntrospectionClient) store(key string, token Token, err error) {
+	now := time.Now()
+	expiresAt := now.Add(c.cfg.CacheTTL)
+	if err == nil && !token.ExpiresAt.IsZero() && token.ExpiresAt.Before(expiresAt) {
+		expiresAt = token.ExpiresAt
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.cache) >= maxIntrospectionCacheSize {
+		for k, result := range c.cache {
+			if !now.Before(result.expiresAt) {
+				delete(c.cache, k)
+			}
+		}
+		if len(c.cache) >= maxIntrospectionCacheSize {
+			c.cache = make(map[string]cachedIntrospection)
+		}
+	}
+
+	c.cache[key] = cachedIntrospection{token: token, err: err, expiresAt: expiresAt}
+}
+
+// introspect calls the introspection endpoint, getting a new access token and retrying once if it has been rejected.
+func (c *IntrospectionClient) introspect(rawToken string) (Introspection, error) {
+	var res Introspection
+	for attempt := 0; attempt < 2; attempt++ {
+		accessToken, err := c.getAccessToken()
+		if err != nil {
+			return Introspection{}, err
+		}
+
+		req, err := http.NewRequest(http.MethodPost, c.cfg.IntrospectionURL, strings.NewReader(url.Values{"token": {rawToken}}.Encode()))
+		if err != nil {
+			return Introspection{}, err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+
+		status, err := c.do(req, &res)
+		if err != nil {
+			return Introspection{}, err
+		}
+
+		if status == http.StatusUnauthorized {
+			c.clearAccessToken(accessToken)
+			continue
+		}
+
+		if status != http.StatusOK {
+			return Introspection{}, fmt.Errorf("token introspection failed with status %d", status)
+		}
+
+		return res, nil
+	}
+
+	return Introspection{}, fmt.Errorf("token introspection failed with status %d", http.StatusUnauthorized)
+}
+
+// getAccessToken returns the access token of the client, requesting a new one if it is about to expire.
+func (c *IntrospectionClient) getAccessToken() (string, error) {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+
+	if c.accessToken != "" && time.Now().Before(c.accessTokenExpiry) {
+		return c.accessToken, nil
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.cfg.TokenURL, strings.NewReader(url.Values{"grant_type": {"client_credentials"}}.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(c.cfg.ClientID), url.QueryEscape(c.cfg.ClientSecret))
+
+	var res struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	status, err := c.do(req, &res)
+	if err != nil {
+		return "", err
+	}
+
+	if status != http.StatusOK || res.AccessToken == "" {
+		return "", fmt.Errorf("failed to get introspection access token, status %d", status)
+	}
+
+	c.accessToken = res.AccessToken
+	c.accessTokenExpiry = time.Now().Add(time.Duration(res.ExpiresIn)*time.Second - accessTokenExpiryMargin)
+	return c.accessToken, nil
+}
+
+func (c *IntrospectionClient) clearAccessToken(rejected string) {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+
+	if c.accessToken == rejected {
+		c.accessToken = ""
+	}
+}
+
+// do sends a request and decodes the body of successful responses into v.
+func (c *IntrospectionClient) do(req *http.Request, v interface{}) (int, error) {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return resp.StatusCode, nil
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(v)
+	if err != nil {
+		return resp.StatusCode, err
+	}
+	return resp.StatusCode, nil
+}
+
+func unixTime(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+func fromUnixTime(seconds int64) time.Time {
+	if seconds == 0 {
+		return time.Time{}
+	}
+	return time.Unix(seconds, 0).UTC()
+}
@@ -0,0 +1,174 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIntrospection(t *testing.T) {
+	assert := assert.New(t)
+	createdAt := time.Now().UTC().Truncate(time.Second)
+	token := Token{
+		ID:            "token-id",
+		Issuer:        "issuer-name",
+		Subject:       "user-id",
+		Role:          "USER",
+		Permissions:   []string{"users:read"},
+		ClientID:      "client-id",
+		Scopes:        []string{"openid", "users:read"},
+		PrincipalType: UserPrincipal,
+		CreatedAt:     createdAt,
+		ExpiresAt:     createdAt.Add(time.Hour),
+	}
+
+	res := NewIntrospection(token)
+	assert.True(res.Active)
+	assert.Equal("openid users:read", res.Scope)
+	assert.Equal(createdAt.Unix(), res.IssuedAt)
+	assert.Equal(createdAt.Add(time.Hour).Unix(), res.ExpiresAt)
+
+	parsed, err := res.Token()
+	assert.NoError(err)
+	assert.Equal(token, parsed)
+
+	_, err = Introspection{Active: false}.Token()
+	assert.Equal(ErrInvalidToken, err)
+
+	res.ExpiresAt = time.Now().Add(-time.Second).Unix()
+	_, err = res.Token()
+	assert.Equal(ErrExpiredToken, err)
+}
+
+func TestIntrospectionClient(t *testing.T) {
+	assert := assert.New(t)
+	active := NewIntrospection(Token{
+		ID:        "token-id",
+		Subject:   "user-id",
+		Role:      "USER",
+		CreatedAt: time.Now().UTC(),
+		ExpiresAt: time.Now().UTC().Add(time.Hour),
+	})
+
+	tokenCalls, introspectCalls := 0, 0
+	rejectAccessToken := false
+	failIntrospection := false
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		tokenCalls++
+		clientID, secret, ok := r.BasicAuth()
+		if !ok || clientID != "service-id" || secret != "service-secret" || r.PostFormValue("grant_type") != "client_credentials" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"access_token": "access-token", "expires_in": 3600})
+	})
+	mux.HandleFunc("/introspect", func(w http.ResponseWriter, r *http.Request) {
+		introspectCalls++
+		if r.Header.Get("Authorization") != "Bearer access-token" || rejectAccessToken {
+			rejectAccessToken = false
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if failIntrospection {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		switch r.PostFormValue("token") {
+		case "active-token":
+			json.NewEncoder(w).Encode(active)
+		default:
+			json.NewEncoder(w).Encode(Introspection{Active: false})
+		}
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := NewIntrospectionClient(IntrospectionConfig{
+		IntrospectionURL: server.URL + "/introspect",
+		TokenURL:         server.URL + "/token",
+		ClientID:         "service-id",
+		ClientSecret:     "service-secret",
+	})
+	var _ Verifier = client
+
+	token, err := client.Verify("active-token")
+	assert.NoError(err)
+	assert.Equal("user-id", token.Subject)
+	assert.Equal(UserPrincipal, token.PrincipalType)
+	assert.Equal(1, tokenCalls)
+	assert.Equal(1, introspectCalls)
+
+	token, err = client.Verify("active-token")
+	assert.NoError(err)
+	assert.Equal("user-id", token.Subject)
+	assert.Equal(1, introspectCalls)
+
+	_, err = client.Verify("revoked-token")
+	assert.Equal(ErrInvalidToken, err)
+	_, err = client.Verify("revoked-token")
+	assert.Equal(ErrInvalidToken, err)
+	assert.Equal(2, introspectCalls)
+	assert.Equal(1, tokenCalls)
+
+	rejectAccessToken = true
+	_, err = client.Verify("other-token")
+	assert.Equal(ErrInvalidToken, err)
+	assert.Equal(4, introspectCalls)
+	assert.Equal(2, tokenCalls)
+
+	failIntrospection = true
+	_, err = client.Verify("failing-token")
+	assert.Error(err)
+	assert.NotEqual(ErrInvalidToken, err)
+	failIntrospection = false
+	_, err = client.Verify("failing-token")
+	assert.Equal(ErrInvalidToken, err)
+	assert.Equal(6, introspectCalls)
+
+	client = NewIntrospectionClient(IntrospectionConfig{
+		IntrospectionURL: server.URL + "/introspect",
+		TokenURL:         server.URL + "/token",
+		ClientID:         "service-id",
+		ClientSecret:     "wrong-secret",
+	})
+	_, err = client.Verify("active-token")
+	assert.Error(err)
+}
+
+func TestIntrospectionClient_CacheExpiry(t *testing.T) {
+	assert := assert.New(t)
+	calls := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"access_token": "access-token", "expires_in": 3600})
+	})
+	mux.HandleFunc("/introspect", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		json.NewEncoder(w).Encode(Introspection{Active: false})
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := NewIntrospectionClient(IntrospectionConfig{
+		IntrospectionURL: server.URL + "/introspect",
+		TokenURL:         server.URL + "/token",
+		CacheTTL:         50 * time.Millisecond,
+	})
+
+	_, err := client.Verify("some-token")
+	assert.Equal(ErrInvalidToken, err)
+	_, err = client.Verify("some-token")
+	assert.Equal(ErrInvalidToken, err)
+	assert.Equal(1, calls)
+
+	time.Sleep(60 * time.Millisecond)
+	_, err = client.Verify("some-token")
+	assert.Equal(ErrInvalidToken, err)
+	assert.Equal(2, calls)
+}
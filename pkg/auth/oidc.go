@@ -0,0 +1,194 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"io"
+	"io/ioutil"
+	"time"
+
+	jose "gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+// IDTokenAlgorithm signing algorithm of ID tokens, which OpenID Connect requires providers to support.
+const IDTokenAlgorithm = "RS256"
+
+// Common ID token errors.
+var (
+	ErrInvalidPrivateKey = errors.New("invalid private key")
+)
+
+// IDToken claims of an OpenID Connect ID token. Audience is the id of the client the token
+// was issued to and SessionID the id of the session the user authorized the client from.
+// Email and Name are only included if the client has been granted the email and profile scopes.
+type IDToken struct {
+	Issuer    string
+	Subject   string
+	Audience  string
+	Nonce     string
+	AuthTime  time.Time
+	SessionID string
+	Email     string
+	Name      string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+// IDTokenIssuer interface for issuing OpenID Connect ID tokens.
+type IDTokenIssuer interface {
+	IssueIDToken(token IDToken) (string, error)
+	// ParseIDTokenHint verifies the signature and issuer of an ID token issued by the issuer.
+	// Expired tokens are accepted, as they are valid hints of which user and session a client refers to.
+	ParseIDTokenHint(rawToken string) (IDToken, error)
+	// KeySet returns the public keys that clients verify ID tokens with.
+	KeySet() jose.JSONWebKeySet
+}
+
+type idTokenClaims struct {
+	Nonce     string           `json:"nonce,omitempty"`
+	AuthTime  *jwt.NumericDate `json:"auth_time,omitempty"`
+	SessionID string           `json:"sid,omitempty"`
+	Email     string           `json:"email,omitempty"`
+	Name      string           `json:"name,omitempty"`
+}
+
+// RSAIDTokenIssuer IDTokenIssuer implementation signing ID tokens with an RSA key.
+type RSAIDTokenIssuer struct {
+	name      string
+	signer    jose.Signer
+	publicKey jose.JSONWebKey
+	tokenAge  time.Duration
+}
+
+// NewRSAIDTokenIssuer creates a new RSAIDTokenIssuer. The issuer name must be the url of the provider,
+// as given in its discovery document. The key id is derived from the public key.
+func NewRSAIDTokenIssuer(issuer string, key *rsa.PrivateKey) (*RSAIDTokenIssuer, error) {
+	publicKey := jose.JSONWebKey{
+		Key:       &key.PublicKey,
+		Algorithm: IDTokenAlgorithm,
+		Use:       "sig",
+	}
+	thumbprint, err := publicKey.Thumbprint(crypto.SHA256)
+	if err != nil {
+		return nil, ErrInvalidPrivateKey
+	}
+	publicKey.KeyID = base64.RawURLEncoding.EncodeToString(thumbprint)
+
+	signingKey := jose.SigningKey{
+		Algorithm: jose.RS256,
+		Key:       jose.JSONWebKey{Key: key, KeyID: publicKey.KeyID},
+	}
+	signer, err := jose.NewSigner(signingKey, (&jose.SignerOptions{}).WithType("JWT"))
+	if err != nil {
+		return nil, ErrInvalidPrivateKey
+	}
+
+	return &RSAIDTokenIssuer{
+		name:      issuer,
+		signer:    signer,
+		publicKey: publicKey,
+		tokenAge:  time.Hour,
+	}, nil
+}
+
+// ReadRSAPrivateKey reads a PEM encoded RSA private key in PKCS #1 or PKCS #8 form from an io.Reader.
+func ReadRSAPrivateKey(r io.Reader) (*rsa.PrivateKey, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, ErrInvalidPrivateKey
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, ErrInvalidPrivateKey
+	}
+
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, ErrInvalidPrivateKey
+	}
+	return key, nil
+}
+
+// IssueIDToken issues a signed ID token. The issuer, issue and expiry times of the given token are ignored.
+func (i *RSAIDTokenIssuer) IssueIDToken(token IDToken) (string, error) {
+	if token.Subject == "" || token.Audience == "" {
+		return "", ErrInvalidTokenContent
+	}
+
+	issuedAt := time.Now().UTC()
+	claims := jwt.Claims{
+		Issuer:   i.name,
+		Subject:  token.Subject,
+		Audience: jwt.Audience{token.Audience},
+		IssuedAt: jwt.NewNumericDate(issuedAt),
+		Expiry:   jwt.NewNumericDate(issuedAt.Add(i.tokenAge)),
+	}
+	customClaims := idTokenClaims{
+		Nonce:     token.Nonce,
+		SessionID: token.SessionID,
+		Email:     token.Email,
+		Name:      token.Name,
+	}
+	if !token.AuthTime.IsZero() {
+		customClaims.AuthTime = jwt.NewNumericDate(token.AuthTime)
+	}
+
+	return jwt.Signed(i.signer).Claims(claims).Claims(customClaims).CompactSerialize()
+}
+
+// ParseIDTokenHint verifies the signature and issuer of an ID token, ignoring its expiry.
+func (i *RSAIDTokenIssuer) ParseIDTokenHint(rawToken string) (IDToken, error) {
+	token, err := jwt.ParseSigned(rawToken)
+	if err != nil {
+		return IDToken{}, ErrInvalidToken
+	}
+
+	var claims jwt.Claims
+	var customClaims idTokenClaims
+	err = token.Claims(i.publicKey.Key, &claims, &customClaims)
+	if err != nil {
+		return IDToken{}, ErrInvalidToken
+	}
+
+	if claims.Issuer != i.name || claims.Subject == "" || len(claims.Audience) != 1 {
+		return IDToken{}, ErrInvalidTokenContent
+	}
+
+	idToken := IDToken{
+		Issuer:    claims.Issuer,
+		Subject:   claims.Subject,
+		Audience:  claims.Audience[0],
+		Nonce:     customClaims.Nonce,
+		SessionID: customClaims.SessionID,
+		Email:     customClaims.Email,
+		Name:      customClaims.Name,
+		IssuedAt:  claims.IssuedAt.Time().UTC(),
+		ExpiresAt: claims.Expiry.Time().UTC(),
+	}
+	if customClaims.AuthTime != nil {
+		idToken.AuthTime = customClaims.AuthTime.Time().UTC()
+	}
+	return idToken, nil
+}
+
+// KeySet returns the public key of the issuer as a JSON Web Key Set.
+func (i *RSAIDTokenIssuer) KeySet() jose.JSONWebKeySet {
+	return jose.JSONWebKeySet{
+		Keys: []jose.JSONWebKey{i.publicKey},
+	}
+}
@@ -0,0 +1,119 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+func TestRSAIDTokenIssuer(t *testing.T) {
+	assert := assert.New(t)
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(err)
+	issuer, err := NewRSAIDTokenIssuer("https://id.example.com", key)
+	assert.NoError(err)
+
+	authTime := time.Now().UTC().Add(-time.Hour).Truncate(time.Second)
+	rawToken, err := issuer.IssueIDToken(IDToken{
+		Subject:   "user-id",
+		Audience:  "client-id",
+		Nonce:     "nonce-value",
+		AuthTime:  authTime,
+		SessionID: "session-id",
+		Email:     "mail@mail.com",
+		Name:      "Tester McTest",
+	})
+	assert.NoError(err)
+
+	// Clients verify ID tokens with the published key set.
+	keySet, err := json.Marshal(issuer.KeySet())
+	assert.NoError(err)
+	var jwks struct {
+		Keys []map[string]interface{} `json:"keys"`
+	}
+	assert.NoError(json.Unmarshal(keySet, &jwks))
+	assert.Len(jwks.Keys, 1)
+	assert.Equal("RSA", jwks.Keys[0]["kty"])
+	assert.Equal("RS256", jwks.Keys[0]["alg"])
+	assert.Equal("sig", jwks.Keys[0]["use"])
+	assert.NotEmpty(jwks.Keys[0]["kid"])
+	assert.Nil(jwks.Keys[0]["d"])
+
+	token, err := jwt.ParseSigned(rawToken)
+	assert.NoError(err)
+	assert.Equal(jwks.Keys[0]["kid"], token.Headers[0].KeyID)
+	var claims map[string]interface{}
+	assert.NoError(token.Claims(&key.PublicKey, &claims))
+	assert.Equal("https://id.example.com", claims["iss"])
+	assert.Equal("user-id", claims["sub"])
+	assert.Equal([]interface{}{"client-id"}, claims["aud"])
+	assert.Equal("nonce-value", claims["nonce"])
+	assert.Equal(float64(authTime.Unix()), claims["auth_time"])
+	assert.Equal("session-id", claims["sid"])
+	assert.Equal("mail@mail.com", claims["email"])
+	assert.Equal("Tester McTest", claims["name"])
+	assert.NotNil(claims["iat"])
+	assert.NotNil(claims["exp"])
+
+	hint, err := issuer.ParseIDTokenHint(rawToken)
+	assert.NoError(err)
+	assert.Equal("user-id", hint.Subject)
+	assert.Equal("client-id", hint.Audience)
+	assert.Equal("session-id", hint.SessionID)
+	assert.Equal(authTime, hint.AuthTime)
+
+	expiredIssuer, err := NewRSAIDTokenIssuer("https://id.example.com", key)
+	assert.NoError(err)
+	expiredIssuer.tokenAge = -time.Hour
+	expiredToken, err := expiredIssuer.IssueIDToken(IDToken{Subject: "user-id", Audience: "client-id"})
+	assert.NoError(err)
+	_, err = issuer.ParseIDTokenHint(expiredToken)
+	assert.NoError(err)
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(err)
+	otherIssuer, err := NewRSAIDTokenIssuer("https://id.example.com", otherKey)
+	assert.NoError(err)
+	_, err = otherIssuer.ParseIDTokenHint(rawToken)
+	assert.Equal(ErrInvalidToken, err)
+
+	wrongNameIssuer, err := NewRSAIDTokenIssuer("https://other.example.com", key)
+	assert.NoError(err)
+	_, err = wrongNameIssuer.ParseIDTokenHint(rawToken)
+	assert.Equal(ErrInvalidTokenContent, err)
+
+	_, err = issuer.ParseIDTokenHint("not-a-token")
+	assert.Equal(ErrInvalidToken, err)
+
+	_, err = issuer.IssueIDToken(IDToken{Subject: "user-id"})
+	assert.Equal(ErrInvalidTokenContent, err)
+}
+
+func TestReadRSAPrivateKey(t *testing.T) {
+	assert := assert.New(t)
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(err)
+
+	pkcs1 := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	parsed, err := ReadRSAPrivateKey(bytes.NewReader(pkcs1))
+	assert.NoError(err)
+	assert.Equal(key.D, parsed.D)
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	assert.NoError(err)
+	pkcs8 := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	parsed, err = ReadRSAPrivateKey(bytes.NewReader(pkcs8))
+	assert.NoError(err)
+	assert.Equal(key.D, parsed.D)
+
+	_, err = ReadRSAPrivateKey(bytes.NewReader([]byte("not a key")))
+	assert.Equal(ErrInvalidPrivateKey, err)
+}
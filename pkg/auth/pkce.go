@@ -0,0 +1,36 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"regexp"
+)
+
+// PKCEMethodS256 the only supported PKCE code challenge method, as given in RFC 7636.
+const PKCEMethodS256 = "S256"
+
+// codeVerifierPattern allowed characters and length of a PKCE code verifier.
+var codeVerifierPattern = regexp.MustCompile(`^[A-Za-z0-9\-._~]{43,128}$`)
+
+// PKCEChallenge computes the S256 code challenge of a PKCE code verifier.
+func PKCEChallenge(verifier string) string {
+	hash := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(hash[:])
+}
+
+// VerifyPKCE checks that a code verifier is well formed and matches an S256 code challenge.
+func VerifyPKCE(verifier, challenge string) bool {
+	if !codeVerifierPattern.MatchString(verifier) {
+		return false
+	}
+
+	candidate := PKCEChallenge(verifier)
+	return subtle.ConstantTimeCompare([]byte(candidate), []byte(challenge)) == 1
+}
+
+// ValidPKCEChallenge checks that a code challenge has the form of an S256 challenge.
+func ValidPKCEChallenge(challenge string) bool {
+	decoded, err := base64.RawURLEncoding.DecodeString(challenge)
+	return err == nil && len(decoded) == sha256.Size
+}
@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPKCEChallenge(t *testing.T) {
+	// Example from RFC 7636 appendix B.
+	verifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	assert.Equal(t, "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM", PKCEChallenge(verifier))
+}
+
+func TestVerifyPKCE(t *testing.T) {
+	verifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	challenge := PKCEChallenge(verifier)
+	shortVerifier := "too-short"
+	longVerifier := strings.Repeat("a", 129)
+
+	tests := []struct {
+		name      string
+		verifier  string
+		challenge string
+		want      bool
+	}{
+		{
+			name:      "happy-path",
+			verifier:  verifier,
+			challenge: challenge,
+			want:      true,
+		},
+		{
+			name:      "sad-path-wrong-verifier",
+			verifier:  strings.Repeat("b", 43),
+			challenge: challenge,
+			want:      false,
+		},
+		{
+			name:      "sad-path-short-verifier",
+			verifier:  shortVerifier,
+			challenge: PKCEChallenge(shortVerifier),
+			want:      false,
+		},
+		{
+			name:      "sad-path-long-verifier",
+			verifier:  longVerifier,
+			challenge: PKCEChallenge(longVerifier),
+			want:      false,
+		},
+		{
+			name:      "sad-path-plain-challenge",
+			verifier:  verifier,
+			challenge: verifier,
+			want:      false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, VerifyPKCE(tt.verifier, tt.challenge))
+		})
+	}
+}
+
+func TestValidPKCEChallenge(t *testing.T) {
+	assert := assert.New(t)
+	assert.True(ValidPKCEChallenge(PKCEChallenge("any-verifier")))
+	assert.False(ValidPKCEChallenge(""))
+	assert.False(ValidPKCEChallenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk-too-long"))
+	assert.False(ValidPKCEChallenge("not base64!"))
+}
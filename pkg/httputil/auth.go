@@ -0,0 +1,188 @@
+package httputil
+
+import (
+	"strings"
+
+	"github.com/CzarSimon/user-service/pkg/auth"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Authentication header keys and values
+const (
+	AuthorizationHeader = "Authorization"
+	BearerPrefix        = "Bearer "
+	APIKeyPrefix        = "ApiKey "
+)
+
+// tokenKey context key under which the verified auth.Token is stored.
+const tokenKey = "httputil.Token"
+
+// Authenticate verifies the bearer token of a request and stores it in the gin context.
+// Requests without a bearer token are authenticated with the access token cookie, in which
+// case state changing requests must also pass the CSRF check. If the verifier implements
+// auth.APIKeyVerifier, requests may instead authenticate with the ApiKey scheme.
+// The subject of the token is added to the request scoped logger as the userId.
+func Authenticate(verifier auth.Verifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if key, ok := getAuthorization(c, APIKeyPrefix); ok {
+			authenticateAPIKey(c, verifier, key)
+			return
+		}
+
+		rawToken, ok := getAuthorization(c, BearerPrefix)
+		if !ok {
+			rawToken, ok = getCookieToken(c)
+			if !ok {
+				abortWithError(ErrUnauthorized(), c)
+				return
+			}
+
+			err := checkCSRFToken(c)
+			if err != nil {
+				abortWithError(err, c)
+				return
+			}
+		}
+
+		token, err := verifier.Verify(rawToken)
+		if err != nil {
+			abortWithError(mapAuthError(c, err), c)
+			return
+		}
+
+		setToken(token, c)
+		addLoggerFields(c, zap.String("userId", token.Subject))
+		c.Next()
+	}
+}
+
+// authenticateAPIKey verifies an API key and stores the principal it resolves to in the gin context.
+func authenticateAPIKey(c *gin.Context, verifier auth.Verifier, key string) {
+	keyVerifier, ok := verifier.(auth.APIKeyVerifier)
+	if !ok {
+		abortWithError(ErrUnauthorized(), c)
+		return
+	}
+
+	token, err := keyVerifier.VerifyAPIKey(key)
+	if err != nil {
+		abortWithError(mapAuthError(c, err), c)
+		return
+	}
+
+	setToken(token, c)
+	addLoggerFields(c, zap.String("userId", token.Subject))
+	c.Next()
+}
+
+// RequireRole only lets requests through if the authenticated token has one of the given roles.
+// Must be used after Authenticate.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, err := GetToken(c)
+		if err != nil {
+			abortWithError(err, c)
+			return
+		}
+
+		if !hasRole(token, roles) {
+			abortWithError(ErrForbidden(), c)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequirePermission only lets requests through if the authenticated token grants all of the given permissions.
+// Must be used after Authenticate.
+func RequirePermission(permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, err := GetToken(c)
+		if err != nil {
+			abortWithError(err, c)
+			return
+		}
+
+		for _, permission := range permissions {
+			if !token.HasPermission(permission) {
+				abortWithError(ErrForbidden(), c)
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// GetToken gets the authenticated token from the gin context.
+func GetToken(c *gin.Context) (auth.Token, error) {
+	val, ok := c.Get(tokenKey)
+	if !ok {
+		return auth.Token{}, ErrUnauthorized()
+	}
+
+	token, ok := val.(auth.Token)
+	if !ok {
+		return auth.Token{}, NewInternalServerError("Failed to parse token")
+	}
+
+	return token, nil
+}
+
+// setToken sets a verified token and its subject in the gin context.
+func setToken(token auth.Token, c *gin.Context) {
+	c.Set(tokenKey, token)
+	c.Set(subjectKey, token.Subject)
+}
+
+// getAuthorization reads the credentials of the authorization header if it uses the scheme of the given prefix.
+func getAuthorization(c *gin.Context, prefix string) (string, bool) {
+	header := c.GetHeader(AuthorizationHeader)
+	if len(header) <= len(prefix) || !strings.EqualFold(header[:len(prefix)], prefix) {
+		return "", false
+	}
+
+	return strings.TrimSpace(header[len(prefix):]), true
+}
+
+func getCookieToken(c *gin.Context) (string, bool) {
+	token, err := c.Cookie(AccessTokenCookie)
+	if err != nil || token == "" {
+		return "", false
+	}
+
+	return token, true
+}
+
+func hasRole(token auth.Token, roles []string) bool {
+	for _, role := range roles {
+		if token.Role == role {
+			return true
+		}
+	}
+	return false
+}
+
+// mapAuthError maps an error returned by a verifier to the error sent to the client.
+// Unexpected errors are logged rather than sent, as they may reveal internal details.
+func mapAuthError(c *gin.Context, err error) *Error {
+	if httpErr, ok := err.(*Error); ok {
+		return httpErr
+	}
+
+	switch err {
+	case auth.ErrExpiredToken, auth.ErrInvalidToken, auth.ErrInvalidTokenContent, auth.ErrInvalidAPIKey:
+		return ErrUnauthorized()
+	default:
+		LoggerFromContext(c.Request.Context()).Error("Failed to verify token", zap.Error(err))
+		return NewInternalServerError("Failed to verify token")
+	}
+}
+
+// abortWithError records an error in the gin context and stops the handler chain.
+func abortWithError(err error, c *gin.Context) {
+	c.Error(err)
+	c.Abort()
+}
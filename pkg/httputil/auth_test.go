@@ -0,0 +1,233 @@
+package httputil
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/CzarSimon/user-service/pkg/auth"
+	"github.com/CzarSimon/user-service/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthenticateAndRequireRole(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	creds := auth.JWTCredentials{
+		Issuer: "user-service-name",
+		Secret: "jwt-secret",
+	}
+	issuer := auth.NewJWTIssuer(creds)
+	verifier := auth.NewJWTVerifier(creds, time.Minute)
+
+	r := NewRouter("test-service", "1.0")
+	r.GET("/me", Authenticate(verifier), func(c *gin.Context) {
+		token, err := GetToken(c)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.String(http.StatusOK, token.Subject)
+	})
+	r.GET("/admin", Authenticate(verifier), RequireRole(models.AdminRole), SendOK)
+	r.GET("/users", Authenticate(verifier), RequirePermission(models.ReadUsersPermission), SendOK)
+
+	userToken, err := issuer.Issue("user-id", models.UserRole)
+	assert.NoError(t, err)
+	adminToken, err := issuer.Issue("admin-id", models.AdminRole)
+	assert.NoError(t, err)
+	readerToken, err := issuer.Issue("reader-id", models.UserRole, models.ReadUsersPermission)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		path       string
+		authHeader string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "happy-path",
+			path:       "/me",
+			authHeader: "Bearer " + userToken,
+			wantStatus: http.StatusOK,
+			wantBody:   "user-id",
+		},
+		{
+			name:       "happy-path-admin",
+			path:       "/admin",
+			authHeader: "Bearer " + adminToken,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "sad-path-missing-header",
+			path:       "/me",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "sad-path-wrong-scheme",
+			path:       "/me",
+			authHeader: "Basic " + userToken,
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "sad-path-invalid-token",
+			path:       "/me",
+			authHeader: "Bearer not-a-token",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "happy-path-permission",
+			path:       "/users",
+			authHeader: "Bearer " + readerToken,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "sad-path-missing-permission",
+			path:       "/users",
+			authHeader: "Bearer " + adminToken,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "sad-path-wrong-role",
+			path:       "/admin",
+			authHeader: "Bearer " + userToken,
+			wantStatus: http.StatusForbidden,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.authHeader != "" {
+				req.Header.Set(AuthorizationHeader, tt.authHeader)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantBody != "" {
+				assert.Equal(t, tt.wantBody, w.Body.String())
+			}
+		})
+	}
+}
+
+type mockAPIKeyVerifier struct {
+	auth.Verifier
+	tokens map[string]auth.Token
+}
+
+func (v *mockAPIKeyVerifier) VerifyAPIKey(key string) (auth.Token, error) {
+	token, ok := v.tokens[key]
+	if !ok {
+		return auth.Token{}, auth.ErrInvalidAPIKey
+	}
+	return token, nil
+}
+
+func TestAuthenticate_APIKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	creds := auth.JWTCredentials{
+		Issuer: "user-service-name",
+		Secret: "jwt-secret",
+	}
+	issuer := auth.NewJWTIssuer(creds)
+	jwtVerifier := auth.NewJWTVerifier(creds, time.Minute)
+	keyVerifier := &mockAPIKeyVerifier{
+		Verifier: jwtVerifier,
+		tokens: map[string]auth.Token{
+			"valid-key": {ID: "key-id", Subject: "user-id", Role: models.UserRole, Permissions: []string{models.ReadUsersPermission}},
+		},
+	}
+
+	handler := func(c *gin.Context) {
+		token, err := GetToken(c)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.String(http.StatusOK, token.Subject)
+	}
+	r := NewRouter("test-service", "1.0")
+	r.GET("/me", Authenticate(keyVerifier), RequirePermission(models.ReadUsersPermission), handler)
+	r.GET("/jwt-only", Authenticate(jwtVerifier), handler)
+
+	userToken, err := issuer.Issue("user-id", models.UserRole, models.ReadUsersPermission)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		path       string
+		authHeader string
+		wantStatus int
+	}{
+		{
+			name:       "happy-path-api-key",
+			path:       "/me",
+			authHeader: "ApiKey valid-key",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "happy-path-bearer-token",
+			path:       "/me",
+			authHeader: "Bearer " + userToken,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "sad-path-invalid-api-key",
+			path:       "/me",
+			authHeader: "ApiKey other-key",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "sad-path-token-as-api-key",
+			path:       "/me",
+			authHeader: "ApiKey " + userToken,
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "sad-path-api-keys-not-supported",
+			path:       "/jwt-only",
+			authHeader: "ApiKey valid-key",
+			wantStatus: http.StatusUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set(AuthorizationHeader, tt.authHeader)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantStatus == http.StatusOK {
+				assert.Equal(t, "user-id", w.Body.String())
+			}
+		})
+	}
+}
+
+type failingVerifier struct {
+	err error
+}
+
+func (v failingVerifier) Verify(token string) (auth.Token, error) {
+	return auth.Token{}, v.err
+}
+
+func TestAuthenticate_HidesVerifierErrors(t *testing.T) {
+	assert := assert.New(t)
+	gin.SetMode(gin.TestMode)
+	r := NewRouter("test-service", "1.0")
+	r.GET("/me", Authenticate(failingVerifier{err: errors.New("pq: connection refused")}), SendOK)
+
+	req := httptest.NewRequest(http.MethodGet, "/me", nil)
+	req.Header.Set(AuthorizationHeader, BearerPrefix+"token")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(http.StatusInternalServerError, w.Code)
+	assert.NotContains(w.Body.String(), "connection refused")
+}
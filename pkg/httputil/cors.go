@@ -0,0 +1,143 @@
+package httputil
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CORS header keys.
+const (
+	OriginHeader           = "Origin"
+	VaryHeader             = "Vary"
+	AllowOriginHeader      = "Access-Control-Allow-Origin"
+	AllowCredentialsHeader = "Access-Control-Allow-Credentials"
+	AllowMethodsHeader     = "Access-Control-Allow-Methods"
+	AllowHeadersHeader     = "Access-Control-Allow-Headers"
+	ExposeHeadersHeader    = "Access-Control-Expose-Headers"
+	MaxAgeHeader           = "Access-Control-Max-Age"
+	RequestMethodHeader    = "Access-Control-Request-Method"
+)
+
+const defaultCORSMaxAge = 10 * time.Minute
+
+// CORSConfig configuration of which cross-origin requests are allowed.
+type CORSConfig struct {
+	// AllowedOrigins origins allowed to make requests, e.g. https://app.example.com.
+	// Subdomains can be allowed with a wildcard, e.g. https://*.example.com, and any origin with *.
+	// Origins only allowed by * are answered with a literal * and never allowed to send credentials.
+	AllowedOrigins []string
+	// AllowedMethods defaults to GET, HEAD, POST, PUT, PATCH and DELETE.
+	AllowedMethods []string
+	// AllowedHeaders defaults to Accept, Accept-Language, Authorization, Content-Type, X-Request-ID
+	// and the X-CSRF-Token and X-Session-Mode headers of cookie sessions.
+	AllowedHeaders []string
+	// ExposedHeaders headers readable by clients, defaults to X-Request-ID.
+	ExposedHeaders []string
+	// AllowCredentials allows cookies and authorization headers to be sent.
+	AllowCredentials bool
+	// MaxAge how long preflight responses may be cached, defaults to 10 minutes.
+	MaxAge time.Duration
+}
+
+// CORS middleware which allows cross-origin requests from the configured origins
+// and responds to preflight requests.
+func CORS(cfg CORSConfig) gin.HandlerFunc {
+	methods := withDefault(cfg.AllowedMethods, []string{
+		http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete,
+	})
+	headers := withDefault(cfg.AllowedHeaders, []string{
+		"Accept", "Accept-Language", AuthorizationHeader, "Content-Type", RequestIDHeader, CSRFTokenHeader, SessionModeHeader,
+	})
+	exposed := withDefault(cfg.ExposedHeaders, []string{RequestIDHeader})
+	maxAge := cfg.MaxAge
+	if maxAge == 0 {
+		maxAge = defaultCORSMaxAge
+	}
+
+	allowMethods := strings.Join(methods, ", ")
+	allowHeaders := strings.Join(headers, ", ")
+	exposeHeaders := strings.Join(exposed, ", ")
+	maxAgeSeconds := strconv.Itoa(int(maxAge.Seconds()))
+	anyOrigin := containsString(cfg.AllowedOrigins, "*")
+
+	return func(c *gin.Context) {
+		origin := c.GetHeader(OriginHeader)
+		if origin == "" {
+			c.Next()
+			return
+		}
+
+		c.Writer.Header().Add(VaryHeader, OriginHeader)
+		if originAllowed(origin, cfg.AllowedOrigins) {
+			c.Header(AllowOriginHeader, origin)
+			if cfg.AllowCredentials {
+				c.Header(AllowCredentialsHeader, "true")
+			}
+		} else if anyOrigin {
+			c.Header(AllowOriginHeader, "*")
+		} else {
+			c.Next()
+			return
+		}
+
+		if c.Request.Method != http.MethodOptions || c.GetHeader(RequestMethodHeader) == "" {
+			c.Header(ExposeHeadersHeader, exposeHeaders)
+			c.Next()
+			return
+		}
+
+		c.Header(AllowMethodsHeader, allowMethods)
+		c.Header(AllowHeadersHeader, allowHeaders)
+		c.Header(MaxAgeHeader, maxAgeSeconds)
+		c.AbortWithStatus(http.StatusNoContent)
+	}
+}
+
+// originAllowed checks if an origin matches any of the allowed origins, not counting *.
+func originAllowed(origin string, allowed []string) bool {
+	origin = strings.ToLower(origin)
+	for _, pattern := range allowed {
+		pattern = strings.ToLower(pattern)
+		if pattern == origin || matchesWildcardSubdomain(origin, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesWildcardSubdomain checks if an origin is a subdomain matched by a pattern such as https://*.example.com.
+func matchesWildcardSubdomain(origin, pattern string) bool {
+	i := strings.Index(pattern, "://*.")
+	if i == -1 {
+		return false
+	}
+
+	scheme := pattern[:i+len("://")]
+	domain := pattern[i+len("://*"):]
+	if !strings.HasPrefix(origin, scheme) {
+		return false
+	}
+
+	host := origin[len(scheme):]
+	return strings.HasSuffix(host, domain) && len(host) > len(domain)
+}
+
+func withDefault(values, defaults []string) []string {
+	if len(values) == 0 {
+		return defaults
+	}
+	return values
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
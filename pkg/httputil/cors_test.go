@@ -0,0 +1,159 @@
+package httputil
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCORS(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := NewRouter("test-service", "1.0", WithCORS(CORSConfig{
+		AllowedOrigins:   []string{"https://app.example.com", "https://*.example.org"},
+		AllowCredentials: true,
+		MaxAge:           time.Hour,
+	}))
+	r.POST("/v1/login", SendOK)
+
+	tests := []struct {
+		name        string
+		method      string
+		origin      string
+		preflight   bool
+		wantStatus  int
+		wantOrigin  string
+		wantMethods string
+		wantMaxAge  string
+	}{
+		{
+			name:       "same-origin",
+			method:     http.MethodPost,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "allowed-origin",
+			method:     http.MethodPost,
+			origin:     "https://app.example.com",
+			wantStatus: http.StatusOK,
+			wantOrigin: "https://app.example.com",
+		},
+		{
+			name:       "allowed-subdomain",
+			method:     http.MethodPost,
+			origin:     "https://admin.example.org",
+			wantStatus: http.StatusOK,
+			wantOrigin: "https://admin.example.org",
+		},
+		{
+			name:        "preflight",
+			method:      http.MethodOptions,
+			origin:      "https://app.example.com",
+			preflight:   true,
+			wantStatus:  http.StatusNoContent,
+			wantOrigin:  "https://app.example.com",
+			wantMethods: "GET, HEAD, POST, PUT, PATCH, DELETE",
+			wantMaxAge:  "3600",
+		},
+		{
+			name:       "sad-path-unknown-origin",
+			method:     http.MethodPost,
+			origin:     "https://evil.com",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "sad-path-wildcard-domain-itself",
+			method:     http.MethodPost,
+			origin:     "https://example.org",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "sad-path-wildcard-wrong-scheme",
+			method:     http.MethodPost,
+			origin:     "http://admin.example.org",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "sad-path-suffix-without-dot",
+			method:     http.MethodPost,
+			origin:     "https://evilexample.org",
+			wantStatus: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/v1/login", nil)
+			if tt.origin != "" {
+				req.Header.Set(OriginHeader, tt.origin)
+			}
+			if tt.preflight {
+				req.Header.Set(RequestMethodHeader, http.MethodPost)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Equal(t, tt.wantOrigin, w.Header().Get(AllowOriginHeader))
+			assert.Equal(t, tt.wantMethods, w.Header().Get(AllowMethodsHeader))
+			assert.Equal(t, tt.wantMaxAge, w.Header().Get(MaxAgeHeader))
+			if tt.preflight {
+				assert.Contains(t, w.Header().Get(AllowHeadersHeader), CSRFTokenHeader)
+				assert.Contains(t, w.Header().Get(AllowHeadersHeader), SessionModeHeader)
+			}
+			if tt.wantOrigin != "" {
+				assert.Equal(t, "true", w.Header().Get(AllowCredentialsHeader))
+			}
+			if tt.origin != "" {
+				assert.Equal(t, OriginHeader, w.Header().Get(VaryHeader))
+			}
+		})
+	}
+}
+
+func TestCORS_AnyOrigin(t *testing.T) {
+	assert := assert.New(t)
+	gin.SetMode(gin.TestMode)
+	r := NewRouter("test-service", "1.0", WithCORS(CORSConfig{AllowedOrigins: []string{"*"}}))
+	r.GET("/v1/users", SendOK)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/users", nil)
+	req.Header.Set(OriginHeader, "https://any.com")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(http.StatusOK, w.Code)
+	assert.Equal("*", w.Header().Get(AllowOriginHeader))
+	assert.Equal(RequestIDHeader, w.Header().Get(ExposeHeadersHeader))
+	assert.Empty(w.Header().Get(AllowCredentialsHeader))
+}
+
+func TestCORS_AnyOriginWithCredentials(t *testing.T) {
+	assert := assert.New(t)
+	gin.SetMode(gin.TestMode)
+	r := NewRouter("test-service", "1.0", WithCORS(CORSConfig{
+		AllowedOrigins:   []string{"https://app.example.com", "*"},
+		AllowCredentials: true,
+	}))
+	r.GET("/v1/users", SendOK)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/users", nil)
+	req.Header.Set(OriginHeader, "https://evil.com")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(http.StatusOK, w.Code)
+	assert.Equal("*", w.Header().Get(AllowOriginHeader))
+	assert.Empty(w.Header().Get(AllowCredentialsHeader))
+
+	req = httptest.NewRequest(http.MethodGet, "/v1/users", nil)
+	req.Header.Set(OriginHeader, "https://app.example.com")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(http.StatusOK, w.Code)
+	assert.Equal("https://app.example.com", w.Header().Get(AllowOriginHeader))
+	assert.Equal("true", w.Header().Get(AllowCredentialsHeader))
+}
@@ -3,18 +3,32 @@ package httputil
 import (
 	"fmt"
 	"net/http"
-
-	"go.uber.org/zap"
+	"strings"
 
 	"github.com/CzarSimon/user-service/pkg/id"
+	"github.com/CzarSimon/user-service/pkg/models"
 	"github.com/gin-gonic/gin"
 )
 
+// ProblemContentType content type of RFC 7807 problem details.
+const ProblemContentType = "application/problem+json"
+
+// CodeValidationFailed error code of errors caused by invalid request fields.
+const CodeValidationFailed = "VALIDATION_FAILED"
+
 // Error implements the error interface with a message, unique ID and http status code.
+// Code is a stable machine readable identifier of the kind of error, which defaults to
+// the status text of the status code, e.g. NOT_FOUND.
+// MessageKey and MessageArgs are used to translate the message to the language of the client.
+// Errors lists the invalid fields of a request if the error was caused by validation.
 type Error struct {
-	ID         string
-	Message    string
-	StatusCode int
+	ID          string
+	Code        string
+	Message     string
+	MessageKey  string
+	MessageArgs []interface{}
+	StatusCode  int
+	Errors      []models.FieldError
 }
 
 // ErrUnauthorized creates an new unauthorized error.
@@ -37,6 +51,20 @@ func ErrNotFound() *Error {
 	return newStandardError(http.StatusNotFound)
 }
 
+// ErrTooManyRequests creates a new too many requests error.
+func ErrTooManyRequests() *Error {
+	return newStandardError(http.StatusTooManyRequests)
+}
+
+// NewValidationError creates a new bad request error describing the invalid fields of a request.
+func NewValidationError(fieldErrors ...models.FieldError) *Error {
+	err := NewError("Validation failed", http.StatusBadRequest).
+		WithCode(CodeValidationFailed).
+		WithMessageKey("validation.failed")
+	err.Errors = fieldErrors
+	return err
+}
+
 // NewInternalServerError creates a new internal server error.
 func NewInternalServerError(message string) *Error {
 	return NewError(message, http.StatusInternalServerError)
@@ -46,25 +74,45 @@ func NewInternalServerError(message string) *Error {
 func NewError(message string, status int) *Error {
 	return &Error{
 		ID:         id.New(),
+		Code:       defaultCode(status),
 		Message:    message,
 		StatusCode: status,
 	}
 }
 
+// WithCode sets the error code of an Error.
+func (e *Error) WithCode(code string) *Error {
+	e.Code = code
+	return e
+}
+
+// WithMessageKey sets the key and arguments used to translate the message of an Error.
+func (e *Error) WithMessageKey(key string, args ...interface{}) *Error {
+	e.MessageKey = key
+	e.MessageArgs = args
+	return e
+}
+
+// defaultCode creates an error code from the status text of a status code.
+func defaultCode(status int) string {
+	text := strings.ToUpper(http.StatusText(status))
+	return strings.Replace(text, " ", "_", -1)
+}
+
 // newStandardError creates an Error with a status and its default error message.
 func newStandardError(status int) *Error {
-	return NewError(http.StatusText(status), status)
+	key := "http." + strings.ToLower(defaultCode(status))
+	return NewError(http.StatusText(status), status).WithMessageKey(key)
 }
 
 // Error returns a string representation of the Error.
 func (e *Error) Error() string {
-	return fmt.Sprintf("id=%s statusCode=%d message=%s", e.ID, e.StatusCode, e.Message)
+	return fmt.Sprintf("id=%s statusCode=%d code=%s message=%s", e.ID, e.StatusCode, e.Code, e.Message)
 }
 
 // HandleErrors wrapper function to deal with encountered errors
 // during request handling.
 func HandleErrors() gin.HandlerFunc {
-	logger := getNamedLogger("Error Handler").Sugar()
 	return func(c *gin.Context) {
 		c.Next()
 
@@ -78,17 +126,21 @@ func HandleErrors() gin.HandlerFunc {
 		case *Error:
 			httpError = err.(*Error)
 			break
+		case *models.ValidationError:
+			httpError = NewValidationError(err.(*models.ValidationError).Errors...)
+			break
 		default:
 			httpError = NewInternalServerError(err.Error())
 			break
 		}
 
-		logError(httpError, c, logger)
+		logError(httpError, c)
 		SendError(httpError, c)
 	}
 }
 
-func logError(err *Error, c *gin.Context, logger *zap.SugaredLogger) {
+func logError(err *Error, c *gin.Context) {
+	logger := LoggerFromContext(c.Request.Context()).Sugar().With("middleware", "Error Handler")
 	logFunc := logger.Errorw
 	if err.StatusCode < 500 {
 		logFunc = logger.Infow
@@ -96,35 +148,84 @@ func logError(err *Error, c *gin.Context, logger *zap.SugaredLogger) {
 
 	logFunc(err.Message,
 		"status", err.StatusCode,
+		"code", err.Code,
 		"errorId", err.ID,
-		"requestId", GetRequestID(c))
+		"traceId", GetTraceID(c))
 }
 
 // ErrorResponse description of the error encountered during request handling.
 type ErrorResponse struct {
-	ErrorID    string `json:"errorId"`
-	RequestID  string `json:"requestId"`
-	Message    string `json:"message"`
-	Path       string `json:"path"`
-	StatusCode int    `json:"statusCode"`
+	ErrorID    string              `json:"errorId"`
+	RequestID  string              `json:"requestId"`
+	Code       string              `json:"code"`
+	Message    string              `json:"message"`
+	Path       string              `json:"path"`
+	StatusCode int                 `json:"statusCode"`
+	Errors     []models.FieldError `json:"errors,omitempty"`
 }
 
 func newErrorResponse(err *Error, c *gin.Context) ErrorResponse {
 	return ErrorResponse{
 		ErrorID:    err.ID,
 		RequestID:  GetRequestID(c),
-		Message:    err.Message,
+		Code:       err.Code,
+		Message:    localizeMessage(err, c),
 		Path:       c.Request.URL.Path,
 		StatusCode: err.StatusCode,
+		Errors:     localizeFieldErrors(err.Errors, c),
+	}
+}
+
+// ProblemDetails RFC 7807 description of the error encountered during request handling.
+// Code, ErrorID, RequestID and Errors are extension members.
+type ProblemDetails struct {
+	Type      string              `json:"type"`
+	Title     string              `json:"title"`
+	Status    int                 `json:"status"`
+	Detail    string              `json:"detail,omitempty"`
+	Instance  string              `json:"instance,omitempty"`
+	Code      string              `json:"code"`
+	ErrorID   string              `json:"errorId"`
+	RequestID string              `json:"requestId"`
+	Errors    []models.FieldError `json:"errors,omitempty"`
+}
+
+func newProblemDetails(err *Error, c *gin.Context) ProblemDetails {
+	problemType := "about:blank"
+	if err.Code != defaultCode(err.StatusCode) {
+		problemType = "urn:problem-type:" + strings.ToLower(err.Code)
+	}
+
+	return ProblemDetails{
+		Type:      problemType,
+		Title:     http.StatusText(err.StatusCode),
+		Status:    err.StatusCode,
+		Detail:    localizeMessage(err, c),
+		Instance:  c.Request.URL.Path,
+		Code:      err.Code,
+		ErrorID:   err.ID,
+		RequestID: GetRequestID(c),
+		Errors:    localizeFieldErrors(err.Errors, c),
 	}
 }
 
-// SendError formats, logs and sends a response back to the client
+// SendError formats, logs and sends a response back to the client.
+// Clients accepting application/problem+json are sent RFC 7807 problem details.
 func SendError(err *Error, c *gin.Context) {
+	if acceptsProblem(c) {
+		c.Header("Content-Type", ProblemContentType)
+		c.AbortWithStatusJSON(err.StatusCode, newProblemDetails(err, c))
+		return
+	}
+
 	errResp := newErrorResponse(err, c)
 	c.AbortWithStatusJSON(errResp.StatusCode, errResp)
 }
 
+func acceptsProblem(c *gin.Context) bool {
+	return strings.Contains(c.GetHeader("Accept"), ProblemContentType)
+}
+
 // getFirstError returns the first error in the gin.Context, nil if not present.
 func getFirstError(c *gin.Context) error {
 	allErrors := c.Errors
@@ -0,0 +1,159 @@
+package httputil
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/CzarSimon/user-service/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := NewRouter("test-service", "1.0")
+	r.GET("/validation", func(c *gin.Context) {
+		c.Error(NewValidationError(
+			models.FieldError{Field: "email", Message: "is required"},
+			models.FieldError{Field: "password", Message: "is required"},
+		))
+	})
+	r.GET("/model-validation", func(c *gin.Context) {
+		c.Error(models.SignupRequest{Email: "mail@mail.com"}.Validate())
+	})
+	r.GET("/not-found", func(c *gin.Context) {
+		c.Error(ErrNotFound())
+	})
+	r.GET("/unknown", func(c *gin.Context) {
+		c.Error(errors.New("unknown error"))
+	})
+
+	tests := []struct {
+		name        string
+		path        string
+		wantStatus  int
+		wantCode    string
+		wantMessage string
+		wantErrors  []models.FieldError
+	}{
+		{
+			name:        "validation-error",
+			path:        "/validation",
+			wantStatus:  http.StatusBadRequest,
+			wantCode:    CodeValidationFailed,
+			wantMessage: "Validation failed",
+			wantErrors: []models.FieldError{
+				{Field: "email", Message: "is required"},
+				{Field: "password", Message: "is required"},
+			},
+		},
+		{
+			name:        "model-validation-error",
+			path:        "/model-validation",
+			wantStatus:  http.StatusBadRequest,
+			wantCode:    CodeValidationFailed,
+			wantMessage: "Validation failed",
+			wantErrors: []models.FieldError{
+				{Field: "password", Message: "is required"},
+				{Field: "surname", Message: "is required"},
+				{Field: "middleAndLastName", Message: "is required"},
+			},
+		},
+		{
+			name:        "http-error",
+			path:        "/not-found",
+			wantStatus:  http.StatusNotFound,
+			wantCode:    "NOT_FOUND",
+			wantMessage: "Not Found",
+		},
+		{
+			name:        "unknown-error",
+			path:        "/unknown",
+			wantStatus:  http.StatusInternalServerError,
+			wantCode:    "INTERNAL_SERVER_ERROR",
+			wantMessage: "unknown error",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			var res ErrorResponse
+			err := json.NewDecoder(w.Body).Decode(&res)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+			assert.Equal(t, tt.wantCode, res.Code)
+			assert.Equal(t, tt.wantMessage, res.Message)
+			assert.Equal(t, tt.path, res.Path)
+			assert.Equal(t, tt.wantErrors, res.Errors)
+			assert.NotEqual(t, "", res.ErrorID)
+		})
+	}
+}
+
+func TestSendError_ProblemDetails(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := NewRouter("test-service", "1.0")
+	r.GET("/exists", func(c *gin.Context) {
+		c.Error(NewError("User already exists", http.StatusConflict).WithCode("USER_EXISTS"))
+	})
+	r.GET("/not-found", func(c *gin.Context) {
+		c.Error(ErrNotFound())
+	})
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+		wantType   string
+		wantTitle  string
+		wantDetail string
+		wantCode   string
+	}{
+		{
+			name:       "coded-error",
+			path:       "/exists",
+			wantStatus: http.StatusConflict,
+			wantType:   "urn:problem-type:user_exists",
+			wantTitle:  "Conflict",
+			wantDetail: "User already exists",
+			wantCode:   "USER_EXISTS",
+		},
+		{
+			name:       "standard-error",
+			path:       "/not-found",
+			wantStatus: http.StatusNotFound,
+			wantType:   "about:blank",
+			wantTitle:  "Not Found",
+			wantDetail: "Not Found",
+			wantCode:   "NOT_FOUND",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set("Accept", ProblemContentType+", application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Equal(t, ProblemContentType, w.Header().Get("Content-Type"))
+			var res ProblemDetails
+			err := json.NewDecoder(w.Body).Decode(&res)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantType, res.Type)
+			assert.Equal(t, tt.wantTitle, res.Title)
+			assert.Equal(t, tt.wantStatus, res.Status)
+			assert.Equal(t, tt.wantDetail, res.Detail)
+			assert.Equal(t, tt.path, res.Instance)
+			assert.Equal(t, tt.wantCode, res.Code)
+			assert.NotEqual(t, "", res.ErrorID)
+		})
+	}
+}
@@ -3,15 +3,29 @@ module github.com/CzarSimon/user-service/pkg/httputil
 go 1.12
 
 require (
-	github.com/CzarSimon/user-service/pkg/id v0.0.0-20190410205540-099a8de0e759
+	github.com/CzarSimon/user-service/pkg/auth v0.0.0-20190414213512-6f2a7ae6afb1
+	github.com/CzarSimon/user-service/pkg/id v0.0.0-20190414114824-48b5a2012d07
+	github.com/CzarSimon/user-service/pkg/models v0.0.0-20190423201041-96646a9231a8
 	github.com/gin-contrib/sse v0.0.0-20190301062529-5545eab6dad3 // indirect
 	github.com/gin-gonic/gin v1.3.0
 	github.com/golang/protobuf v1.3.1 // indirect
 	github.com/mattn/go-isatty v0.0.7 // indirect
+	github.com/prometheus/client_golang v0.9.2
+	github.com/stretchr/testify v1.7.0
 	github.com/ugorji/go v1.1.4 // indirect
+	go.opentelemetry.io/otel v1.0.0
+	go.opentelemetry.io/otel/sdk v1.0.0
+	go.opentelemetry.io/otel/trace v1.0.0
 	go.uber.org/atomic v1.3.2 // indirect
 	go.uber.org/multierr v1.1.0 // indirect
 	go.uber.org/zap v1.9.1
+	golang.org/x/text v0.3.0
 	gopkg.in/go-playground/validator.v8 v8.18.2 // indirect
 	gopkg.in/yaml.v2 v2.2.2 // indirect
 )
+
+replace (
+	github.com/CzarSimon/user-service/pkg/auth => ../auth
+	github.com/CzarSimon/user-service/pkg/id => ../id
+	github.com/CzarSimon/user-service/pkg/models => ../models
+)
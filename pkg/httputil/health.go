@@ -0,0 +1,198 @@
+package httputil
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/CzarSimon/user-service/pkg/auth"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Health and info paths registered by NewRouter.
+const (
+	LivenessPath  = "/health/live"
+	ReadinessPath = "/health/ready"
+	InfoPath      = "/info"
+)
+
+// Health statuses.
+const (
+	StatusUp   = "UP"
+	StatusDown = "DOWN"
+)
+
+// Common health check errors.
+var (
+	ErrHealthCheckTimeout = errors.New("health check timed out")
+)
+
+// HealthChecker checks that a dependency of the service is ready to be used.
+type HealthChecker interface {
+	CheckHealth(ctx context.Context) error
+}
+
+// HealthCheckerFunc function implementing HealthChecker.
+type HealthCheckerFunc func(ctx context.Context) error
+
+// CheckHealth calls the function.
+func (fn HealthCheckerFunc) CheckHealth(ctx context.Context) error {
+	return fn(ctx)
+}
+
+// Pinger dependency which can be pinged, e.g. a *sql.DB.
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// PingCheck creates a HealthChecker which pings a dependency, e.g. a *sql.DB.
+func PingCheck(pinger Pinger) HealthChecker {
+	return HealthCheckerFunc(pinger.PingContext)
+}
+
+// SigningKeyCheck creates a HealthChecker which checks that the key material of
+// issuers and verifiers is loaded, without issuing any tokens.
+func SigningKeyCheck(keys ...auth.KeyChecker) HealthChecker {
+	return HealthCheckerFunc(func(ctx context.Context) error {
+		for _, key := range keys {
+			err := key.CheckKey()
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Readiness set of named health checks which must all pass for the service to be ready.
+type Readiness struct {
+	mu      sync.RWMutex
+	checks  []healthCheck
+	timeout time.Duration
+}
+
+type healthCheck struct {
+	name    string
+	checker HealthChecker
+}
+
+// NewReadiness creates a new Readiness where each check is given a timeout to complete.
+func NewReadiness(timeout time.Duration) *Readiness {
+	return &Readiness{
+		timeout: timeout,
+	}
+}
+
+// Register registers a named health check.
+func (r *Readiness) Register(name string, checker HealthChecker) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks = append(r.checks, healthCheck{name: name, checker: checker})
+}
+
+// HealthReport result of running health checks.
+type HealthReport struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+// CheckResult result of a single health check. Error is logged
+// by the readiness route rather than sent to its unauthenticated callers.
+type CheckResult struct {
+	Status   string `json:"status"`
+	Duration string `json:"duration"`
+	Error    string `json:"-"`
+}
+
+// Check runs all registered checks concurrently and reports their results.
+// The service is only up if all checks pass.
+func (r *Readiness) Check(ctx context.Context) HealthReport {
+	r.mu.RLock()
+	checks := make([]healthCheck, len(r.checks))
+	copy(checks, r.checks)
+	r.mu.RUnlock()
+
+	results := make([]CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check healthCheck) {
+			defer wg.Done()
+			results[i] = r.run(ctx, check.checker)
+		}(i, check)
+	}
+	wg.Wait()
+
+	report := HealthReport{
+		Status: StatusUp,
+		Checks: make(map[string]CheckResult),
+	}
+	for i, check := range checks {
+		report.Checks[check.name] = results[i]
+		if results[i].Status != StatusUp {
+			report.Status = StatusDown
+		}
+	}
+
+	return report
+}
+
+// run runs a check, abandoning checkers which do not return within the timeout.
+func (r *Readiness) run(ctx context.Context, checker HealthChecker) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- checker.CheckHealth(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ErrHealthCheckTimeout
+	}
+
+	result := CheckResult{
+		Status:   StatusUp,
+		Duration: time.Since(start).String(),
+	}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// ServiceInfo name and version of the service.
+type ServiceInfo struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// attachHealthRoutes attaches the liveness, readiness and info routes to a router.
+func attachHealthRoutes(r gin.IRouter, info ServiceInfo, readiness *Readiness) {
+	r.GET(LivenessPath, SendOK)
+	r.GET(ReadinessPath, func(c *gin.Context) {
+		ctx := c.Request.Context()
+		report := readiness.Check(ctx)
+		status := http.StatusOK
+		if report.Status != StatusUp {
+			status = http.StatusServiceUnavailable
+		}
+		for name, result := range report.Checks {
+			if result.Error != "" {
+				LoggerFromContext(ctx).Error("Health check failed", zap.String("check", name), zap.String("err", result.Error))
+			}
+		}
+		c.JSON(status, report)
+	})
+	r.GET(InfoPath, func(c *gin.Context) {
+		c.JSON(http.StatusOK, info)
+	})
+}
@@ -0,0 +1,118 @@
+package httputil
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/CzarSimon/user-service/pkg/auth"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type mockPinger struct {
+	err error
+}
+
+func (p *mockPinger) PingContext(ctx context.Context) error {
+	return p.err
+}
+
+func TestHealthRoutes(t *testing.T) {
+	assert := assert.New(t)
+	gin.SetMode(gin.TestMode)
+	pinger := &mockPinger{}
+	readiness := NewReadiness(50 * time.Millisecond)
+	readiness.Register("db", PingCheck(pinger))
+	readiness.Register("slow", HealthCheckerFunc(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}))
+	core, logs := observer.New(zapcore.ErrorLevel)
+	r := NewRouter("test-service", "1.0", WithReadiness(readiness), WithLogger(zap.New(core)))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, LivenessPath, nil))
+	assert.Equal(http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, InfoPath, nil))
+	assert.Equal(http.StatusOK, w.Code)
+	var info ServiceInfo
+	assert.NoError(json.NewDecoder(w.Body).Decode(&info))
+	assert.Equal(ServiceInfo{Name: "test-service", Version: "1.0"}, info)
+
+	w = httptest.NewRecorder()
+	pinger.err = errors.New("dial tcp 10.0.0.5:3306: connection refused")
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, ReadinessPath, nil))
+	assert.Equal(http.StatusServiceUnavailable, w.Code)
+	assert.NotContains(w.Body.String(), "10.0.0.5")
+	assert.NotContains(w.Body.String(), "error")
+	var report HealthReport
+	assert.NoError(json.NewDecoder(w.Body).Decode(&report))
+	assert.Equal(StatusDown, report.Status)
+	assert.Equal(StatusDown, report.Checks["db"].Status)
+	assert.Equal(StatusDown, report.Checks["slow"].Status)
+	assert.Len(logs.FilterMessage("Health check failed").All(), 2)
+}
+
+func TestReadiness_Check(t *testing.T) {
+	assert := assert.New(t)
+	creds := auth.JWTCredentials{
+		Issuer: "user-service-name",
+		Secret: "jwt-secret",
+	}
+	missingCreds := auth.JWTCredentials{
+		Issuer: "user-service-name",
+	}
+	pinger := &mockPinger{}
+
+	readiness := NewReadiness(time.Second)
+	assert.Equal(HealthReport{Status: StatusUp, Checks: map[string]CheckResult{}}, readiness.Check(context.Background()))
+
+	readiness.Register("db", PingCheck(pinger))
+	readiness.Register("signing-key", SigningKeyCheck(auth.NewJWTIssuer(creds), auth.NewJWTVerifier(creds, time.Minute)))
+	report := readiness.Check(context.Background())
+	assert.Equal(StatusUp, report.Status)
+	assert.Len(report.Checks, 2)
+	assert.Equal(StatusUp, report.Checks["signing-key"].Status)
+
+	pinger.err = errors.New("connection refused")
+	report = readiness.Check(context.Background())
+	assert.Equal(StatusDown, report.Status)
+	assert.Equal(CheckResult{Status: StatusDown, Duration: report.Checks["db"].Duration, Error: "connection refused"}, report.Checks["db"])
+
+	readiness = NewReadiness(time.Second)
+	readiness.Register("signing-key", SigningKeyCheck(auth.NewJWTIssuer(creds), auth.NewJWTVerifier(missingCreds, time.Minute)))
+	report = readiness.Check(context.Background())
+	assert.Equal(StatusDown, report.Status)
+	assert.Equal(auth.ErrMissingKey.Error(), report.Checks["signing-key"].Error)
+
+	readiness = NewReadiness(time.Second)
+	readiness.Register("signing-key", SigningKeyCheck(auth.NewJWTIssuer(missingCreds)))
+	report = readiness.Check(context.Background())
+	assert.Equal(StatusDown, report.Status)
+	assert.Equal(auth.ErrMissingKey.Error(), report.Checks["signing-key"].Error)
+}
+
+func TestReadiness_Timeout(t *testing.T) {
+	assert := assert.New(t)
+	readiness := NewReadiness(10 * time.Millisecond)
+	readiness.Register("stuck", HealthCheckerFunc(func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	}))
+
+	start := time.Now()
+	report := readiness.Check(context.Background())
+	assert.True(time.Since(start) < 500*time.Millisecond)
+	assert.Equal(StatusDown, report.Status)
+	assert.Equal(ErrHealthCheckTimeout.Error(), report.Checks["stuck"].Error)
+}
@@ -3,23 +3,125 @@ package httputil
 import (
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
 )
 
-// NewRouter creates a default router.
-func NewRouter(name, version string) *gin.Engine {
+// RouterOption configures a router created with NewRouter.
+type RouterOption func(*routerOptions)
+
+type routerOptions struct {
+	middleware []gin.HandlerFunc
+	logger     *zap.Logger
+	readiness  *Readiness
+	cors       *CORSConfig
+	security   *SecurityHeadersConfig
+	metrics    *prometheus.Registry
+}
+
+// WithMiddleware adds middleware which is run before the default middleware,
+// so that it observes the final response of every request.
+func WithMiddleware(middleware ...gin.HandlerFunc) RouterOption {
+	return func(opts *routerOptions) {
+		opts.middleware = append(opts.middleware, middleware...)
+	}
+}
+
+// WithLogger sets the logger of the router, which is passed on to handlers in the request context
+// with the requestId and userId of the request attached. Defaults to a production logger.
+func WithLogger(logger *zap.Logger) RouterOption {
+	return func(opts *routerOptions) {
+		opts.logger = logger
+	}
+}
+
+// WithReadiness sets the checks run by the readiness route.
+// Without it the service is always reported as ready.
+func WithReadiness(readiness *Readiness) RouterOption {
+	return func(opts *routerOptions) {
+		opts.readiness = readiness
+	}
+}
+
+// WithCORS allows cross-origin requests according to a CORSConfig.
+func WithCORS(cfg CORSConfig) RouterOption {
+	return func(opts *routerOptions) {
+		opts.cors = &cfg
+	}
+}
+
+// WithMetrics records request metrics in a registry, see Metrics, and exposes them on MetricsPath.
+func WithMetrics(reg *prometheus.Registry) RouterOption {
+	return func(opts *routerOptions) {
+		opts.metrics = reg
+	}
+}
+
+// WithSecurityHeaders sets security headers on all responses according to a SecurityHeadersConfig.
+func WithSecurityHeaders(cfg SecurityHeadersConfig) RouterOption {
+	return func(opts *routerOptions) {
+		opts.security = &cfg
+	}
+}
+
+// NewRouter creates a default router with liveness, readiness and info routes,
+// see LivenessPath, ReadinessPath and InfoPath.
+func NewRouter(name, version string, opts ...RouterOption) *gin.Engine {
+	options := routerOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	if options.logger == nil {
+		options.logger = defaultLogger()
+	}
+	if options.readiness == nil {
+		options.readiness = NewReadiness(time.Second)
+	}
+
 	r := gin.New()
+	if options.metrics != nil {
+		r.Use(Metrics(options.metrics))
+	}
+	r.Use(options.middleware...)
 	r.Use(
-		Logger(),
+		Logger(options.logger),
 		gin.Recovery(),
 		ServerInfo(name, version),
-		RequestID(),
-		HandleErrors())
+		RequestID())
+	if options.security != nil {
+		r.Use(SecurityHeaders(*options.security))
+	}
+	if options.cors != nil {
+		r.Use(CORS(*options.cors))
+	}
+	r.Use(HandleErrors())
+	r.NoRoute(unmatched)
 
+	attachHealthRoutes(r, ServiceInfo{Name: name, Version: version}, options.readiness)
+	if options.metrics != nil {
+		r.GET(MetricsPath, MetricsHandler(options.metrics))
+	}
 	return r
 }
 
+// unmatched handles requests that did not match any route. Metrics and traces
+// recognize such requests by it, see routePattern.
+func unmatched(c *gin.Context) {
+	c.Error(ErrNotFound())
+}
+
+func defaultLogger() *zap.Logger {
+	logger, err := NewLogger(false)
+	if err != nil {
+		return zap.NewNop()
+	}
+	return logger
+}
+
 // SendOK sends an ok status and message to the client.
 func SendOK(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"status": "OK"})
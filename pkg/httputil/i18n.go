@@ -0,0 +1,153 @@
+package httputil
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/CzarSimon/user-service/pkg/models"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/text/language"
+)
+
+// AcceptLanguageHeader header used by clients to list their preferred languages.
+const AcceptLanguageHeader = "Accept-Language"
+
+const translatorKey = "httputil.Translator"
+
+// Translator translates message keys into the language preferred by a client.
+type Translator interface {
+	Translate(acceptLanguage, key string, args ...interface{}) (string, bool)
+}
+
+// Catalog Translator backed by messages keyed by language and message key.
+// Messages are fmt format strings which are formatted with the message arguments.
+type Catalog struct {
+	tags     []language.Tag
+	messages []map[string]string
+	matcher  language.Matcher
+}
+
+// NewCatalog creates a Catalog from messages keyed by language. Messages missing in
+// the preferred language of a client are taken from the fallback language.
+func NewCatalog(fallback string, messages map[string]map[string]string) (*Catalog, error) {
+	if _, ok := messages[fallback]; !ok {
+		return nil, fmt.Errorf("no messages found for fallback language: %s", fallback)
+	}
+
+	langs := []string{fallback}
+	for lang := range messages {
+		if lang != fallback {
+			langs = append(langs, lang)
+		}
+	}
+
+	c := &Catalog{
+		tags:     make([]language.Tag, 0, len(langs)),
+		messages: make([]map[string]string, 0, len(langs)),
+	}
+	for _, lang := range langs {
+		tag, err := language.Parse(lang)
+		if err != nil {
+			return nil, fmt.Errorf("invalid language %s: %v", lang, err)
+		}
+		c.tags = append(c.tags, tag)
+		c.messages = append(c.messages, messages[lang])
+	}
+
+	c.matcher = language.NewMatcher(c.tags)
+	return c, nil
+}
+
+// LoadCatalog loads a Catalog from a directory of JSON message files named by language, e.g. en.json.
+func LoadCatalog(dir, fallback string) (*Catalog, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make(map[string]map[string]string)
+	for _, file := range files {
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		var langMessages map[string]string
+		err = json.Unmarshal(content, &langMessages)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse message file %s: %v", file, err)
+		}
+
+		lang := strings.TrimSuffix(filepath.Base(file), ".json")
+		messages[lang] = langMessages
+	}
+
+	return NewCatalog(fallback, messages)
+}
+
+// Translate returns the message for a key in the language best matching an Accept-Language header.
+func (c *Catalog) Translate(acceptLanguage, key string, args ...interface{}) (string, bool) {
+	_, index := language.MatchStrings(c.matcher, acceptLanguage)
+	message, ok := c.messages[index][key]
+	if !ok {
+		message, ok = c.messages[0][key]
+	}
+	if !ok {
+		return "", false
+	}
+
+	return fmt.Sprintf(message, args...), true
+}
+
+// Localize makes a Translator available for translating error messages to the language requested by clients.
+func Localize(translator Translator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(translatorKey, translator)
+		c.Next()
+	}
+}
+
+// localizeMessage translates the message of an error if it has a message key and a Translator
+// has been configured, otherwise the untranslated message is returned.
+func localizeMessage(err *Error, c *gin.Context) string {
+	return translate(c, err.Message, err.MessageKey, err.MessageArgs...)
+}
+
+// localizeFieldErrors translates the messages of field errors in the same way as localizeMessage.
+func localizeFieldErrors(fieldErrors []models.FieldError, c *gin.Context) []models.FieldError {
+	if len(fieldErrors) == 0 {
+		return fieldErrors
+	}
+
+	localized := make([]models.FieldError, 0, len(fieldErrors))
+	for _, fieldErr := range fieldErrors {
+		fieldErr.Message = translate(c, fieldErr.Message, fieldErr.MessageKey, fieldErr.MessageArgs...)
+		localized = append(localized, fieldErr)
+	}
+	return localized
+}
+
+func translate(c *gin.Context, message, key string, args ...interface{}) string {
+	if key == "" {
+		return message
+	}
+
+	value, ok := c.Get(translatorKey)
+	if !ok {
+		return message
+	}
+
+	translator, ok := value.(Translator)
+	if !ok {
+		return message
+	}
+
+	translated, ok := translator.Translate(c.GetHeader(AcceptLanguageHeader), key, args...)
+	if !ok {
+		return message
+	}
+	return translated
+}
@@ -0,0 +1,188 @@
+package httputil
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/CzarSimon/user-service/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCatalog_Translate(t *testing.T) {
+	catalog, err := NewCatalog("en", map[string]map[string]string{
+		"en": {
+			"user.exists":  "User already exists",
+			"role.unknown": "Unknown role: %s",
+		},
+		"sv": {
+			"user.exists": "Användaren finns redan",
+		},
+	})
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name           string
+		acceptLanguage string
+		key            string
+		args           []interface{}
+		want           string
+		wantOK         bool
+	}{
+		{
+			name:           "happy-path",
+			acceptLanguage: "sv",
+			key:            "user.exists",
+			want:           "Användaren finns redan",
+			wantOK:         true,
+		},
+		{
+			name:           "happy-path-regional-and-weighted",
+			acceptLanguage: "de;q=0.9, sv-SE, en;q=0.5",
+			key:            "user.exists",
+			want:           "Användaren finns redan",
+			wantOK:         true,
+		},
+		{
+			name:           "happy-path-fallback-language",
+			acceptLanguage: "fr-FR",
+			key:            "user.exists",
+			want:           "User already exists",
+			wantOK:         true,
+		},
+		{
+			name:           "happy-path-missing-translation",
+			acceptLanguage: "sv",
+			key:            "role.unknown",
+			args:           []interface{}{"OWNER"},
+			want:           "Unknown role: OWNER",
+			wantOK:         true,
+		},
+		{
+			name:           "happy-path-no-header",
+			acceptLanguage: "",
+			key:            "user.exists",
+			want:           "User already exists",
+			wantOK:         true,
+		},
+		{
+			name:           "sad-path-unknown-key",
+			acceptLanguage: "sv",
+			key:            "unknown.key",
+			want:           "",
+			wantOK:         false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := catalog.Translate(tt.acceptLanguage, tt.key, tt.args...)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err = NewCatalog("en", map[string]map[string]string{"sv": {}})
+	assert.Error(t, err)
+}
+
+func TestLocalize(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	catalog, err := NewCatalog("en", map[string]map[string]string{
+		"en": {"http.not_found": "Not found"},
+		"sv": {"http.not_found": "Hittades inte"},
+	})
+	assert.NoError(t, err)
+
+	r := NewRouter("test-service", "1.0")
+	r.Use(Localize(catalog))
+	r.GET("/not-found", func(c *gin.Context) {
+		c.Error(ErrNotFound())
+	})
+	r.GET("/untranslated", func(c *gin.Context) {
+		c.Error(NewError("Some error", http.StatusConflict))
+	})
+
+	tests := []struct {
+		name        string
+		path        string
+		lang        string
+		wantMessage string
+	}{
+		{
+			name:        "translated",
+			path:        "/not-found",
+			lang:        "sv-SE,sv;q=0.9",
+			wantMessage: "Hittades inte",
+		},
+		{
+			name:        "fallback",
+			path:        "/not-found",
+			lang:        "",
+			wantMessage: "Not found",
+		},
+		{
+			name:        "no-message-key",
+			path:        "/untranslated",
+			lang:        "sv",
+			wantMessage: "Some error",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set(AcceptLanguageHeader, tt.lang)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			var res ErrorResponse
+			err := json.NewDecoder(w.Body).Decode(&res)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantMessage, res.Message)
+		})
+	}
+}
+
+func TestLocalize_FieldErrors(t *testing.T) {
+	assert := assert.New(t)
+	gin.SetMode(gin.TestMode)
+	catalog, err := NewCatalog("en", map[string]map[string]string{
+		"en": {"validation.maxLength": "must be at most %d characters"},
+		"sv": {"validation.maxLength": "får vara högst %d tecken"},
+	})
+	assert.NoError(err)
+
+	r := NewRouter("test-service", "1.0")
+	r.Use(Localize(catalog))
+	r.GET("/validation", func(c *gin.Context) {
+		c.Error(NewValidationError(
+			models.FieldError{Field: "name", Message: "must be at most 100 characters", MessageKey: "validation.maxLength", MessageArgs: []interface{}{100}},
+			models.FieldError{Field: "email", Message: "is required", MessageKey: "validation.required"},
+			models.FieldError{Field: "type", Message: "is invalid"},
+		))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/validation", nil)
+	req.Header.Set(AcceptLanguageHeader, "sv")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var res ErrorResponse
+	assert.NoError(json.NewDecoder(w.Body).Decode(&res))
+	assert.Equal([]models.FieldError{
+		{Field: "name", Message: "får vara högst 100 tecken"},
+		{Field: "email", Message: "is required"},
+		{Field: "type", Message: "is invalid"},
+	}, res.Errors)
+
+	req = httptest.NewRequest(http.MethodGet, "/validation", nil)
+	req.Header.Set(AcceptLanguageHeader, "sv")
+	req.Header.Set("Accept", ProblemContentType)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var problem ProblemDetails
+	assert.NoError(json.NewDecoder(w.Body).Decode(&problem))
+	assert.Equal("får vara högst 100 tecken", problem.Errors[0].Message)
+}
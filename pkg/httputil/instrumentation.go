@@ -1,10 +1,12 @@
 package httputil
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/CzarSimon/user-service/pkg/id"
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 )
 
 // Header keys
@@ -21,7 +23,30 @@ func ServerInfo(name, version string) gin.HandlerFunc {
 	}
 }
 
-// RequestID annotates request with unique request id.
+// RequestMetadata information about a request and the client making it,
+// which is passed on to services through the request context.
+type RequestMetadata struct {
+	RequestID string
+	IP        string
+	UserAgent string
+}
+
+type requestMetadataKey struct{}
+
+// WithRequestMetadata returns a copy of a context which carries request metadata.
+func WithRequestMetadata(ctx context.Context, md RequestMetadata) context.Context {
+	return context.WithValue(ctx, requestMetadataKey{}, md)
+}
+
+// GetRequestMetadata gets the request metadata carried by a context.
+func GetRequestMetadata(ctx context.Context) RequestMetadata {
+	md, _ := ctx.Value(requestMetadataKey{}).(RequestMetadata)
+	return md
+}
+
+// RequestID annotates request with unique request id. The request id is also
+// added to the RequestMetadata of the request context, along with the client ip and user agent,
+// and to the request scoped logger.
 func RequestID() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		requestID := c.GetHeader(RequestIDHeader)
@@ -30,6 +55,13 @@ func RequestID() gin.HandlerFunc {
 		}
 
 		setRequestID(requestID, c)
+		ctx := WithRequestMetadata(c.Request.Context(), RequestMetadata{
+			RequestID: requestID,
+			IP:        c.ClientIP(),
+			UserAgent: c.Request.UserAgent(),
+		})
+		c.Request = c.Request.WithContext(ctx)
+		addLoggerFields(c, zap.String("requestId", requestID))
 		c.Next()
 	}
 }
@@ -0,0 +1,44 @@
+package httputil
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestID(t *testing.T) {
+	assert := assert.New(t)
+	gin.SetMode(gin.TestMode)
+
+	var md RequestMetadata
+	r := NewRouter("test-service", "1.0")
+	r.GET("/test", func(c *gin.Context) {
+		md = GetRequestMetadata(c.Request.Context())
+		SendOK(c)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.Header.Set(RequestIDHeader, "request-1")
+	req.Header.Set("User-Agent", "test-agent/1.0")
+	req.RemoteAddr = "10.0.0.1:51234"
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(http.StatusOK, w.Code)
+	assert.Equal("request-1", w.Header().Get(RequestIDHeader))
+	assert.Equal(RequestMetadata{
+		RequestID: "request-1",
+		IP:        "10.0.0.1",
+		UserAgent: "test-agent/1.0",
+	}, md)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test", nil))
+	assert.NotEmpty(w.Header().Get(RequestIDHeader))
+	assert.Equal(w.Header().Get(RequestIDHeader), md.RequestID)
+
+	assert.Equal(RequestMetadata{}, GetRequestMetadata(req.Context()))
+}
@@ -1,46 +1,73 @@
 package httputil
 
 import (
+	"context"
 	"fmt"
-	"log"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
 
-// Logger request logging middleware.
-func Logger() gin.HandlerFunc {
-	logger := getNamedLogger("Logger")
+// NewLogger creates a logger. In development mode logs are human readable and written
+// from debug level, otherwise logs are sampled JSON written from info level.
+func NewLogger(development bool) (*zap.Logger, error) {
+	if development {
+		return zap.NewDevelopment()
+	}
+	return zap.NewProduction()
+}
+
+type loggerKey struct{}
+
+// ContextWithLogger returns a copy of a context which carries a logger.
+func ContextWithLogger(ctx context.Context, logger *zap.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// LoggerFromContext gets the logger carried by a context. Returns a no-op logger if the context carries none.
+func LoggerFromContext(ctx context.Context) *zap.Logger {
+	return LoggerFromContextOr(ctx, zap.NewNop())
+}
+
+// LoggerFromContextOr gets the logger carried by a context. Returns the fallback logger if the context carries none.
+func LoggerFromContextOr(ctx context.Context, fallback *zap.Logger) *zap.Logger {
+	logger, ok := ctx.Value(loggerKey{}).(*zap.Logger)
+	if !ok {
+		return fallback
+	}
+	return logger
+}
+
+// Logger request logging middleware. The logger is also passed on in the request
+// context, where later middleware attach request scoped fields to it.
+func Logger(logger *zap.Logger) gin.HandlerFunc {
+	requestLogger := logger.With(zap.String("middleware", "Logger"))
 
 	return func(c *gin.Context) {
 		start := time.Now()
 		path := c.Request.URL.Path
 		query := c.Request.URL.RawQuery
+		c.Request = c.Request.WithContext(ContextWithLogger(c.Request.Context(), logger))
 		c.Next()
 
 		end := time.Now()
 		latency := end.Sub(start)
 
-		logger.Info(fmt.Sprintf("%s %s", c.Request.Method, path),
+		requestLogger.Info(fmt.Sprintf("%s %s", c.Request.Method, path),
 			zap.Int("status", c.Writer.Status()),
 			zap.String("query", query),
 			zap.String("requestId", GetRequestID(c)),
+			zap.String("traceId", GetTraceID(c)),
 			zap.String("user-agent", c.Request.UserAgent()),
 			zap.String("time", end.Format(time.RFC3339)),
 			zap.Duration("latency", latency))
 	}
 }
 
-func getLogger() *zap.Logger {
-	logger, err := zap.NewProduction()
-	if err != nil {
-		log.Fatalln("Failed to get zap.Logger", err)
-	}
-	return logger
-}
-
-func getNamedLogger(name string) *zap.Logger {
-	logger := getLogger()
-	return logger.With(zap.String("middleware", name))
+// addLoggerFields adds fields to the logger carried by the request context.
+func addLoggerFields(c *gin.Context, fields ...zap.Field) {
+	ctx := c.Request.Context()
+	logger := LoggerFromContext(ctx).With(fields...)
+	c.Request = c.Request.WithContext(ContextWithLogger(ctx, logger))
 }
@@ -0,0 +1,72 @@
+package httputil
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/CzarSimon/user-service/pkg/auth"
+	"github.com/CzarSimon/user-service/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLogger(t *testing.T) {
+	assert := assert.New(t)
+	gin.SetMode(gin.TestMode)
+	core, logs := observer.New(zapcore.InfoLevel)
+	creds := auth.JWTCredentials{
+		Issuer: "user-service-name",
+		Secret: "jwt-secret",
+	}
+	issuer := auth.NewJWTIssuer(creds)
+	verifier := auth.NewJWTVerifier(creds, time.Minute)
+
+	r := NewRouter("test-service", "1.0", WithLogger(zap.New(core)))
+	r.GET("/me", Authenticate(verifier), func(c *gin.Context) {
+		LoggerFromContext(c.Request.Context()).Info("handled")
+		c.Error(ErrForbidden())
+	})
+
+	token, err := issuer.Issue("user-id", models.UserRole)
+	assert.NoError(err)
+	req := httptest.NewRequest(http.MethodGet, "/me", nil)
+	req.Header.Set(AuthorizationHeader, BearerPrefix+token)
+	req.Header.Set(RequestIDHeader, "request-1")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(http.StatusForbidden, w.Code)
+
+	handled := logs.FilterMessage("handled").All()
+	assert.Len(handled, 1)
+	assert.Equal("request-1", handled[0].ContextMap()["requestId"])
+	assert.Equal("user-id", handled[0].ContextMap()["userId"])
+
+	errorLogs := logs.FilterField(zap.String("middleware", "Error Handler")).All()
+	assert.Len(errorLogs, 1)
+	assert.Equal("request-1", errorLogs[0].ContextMap()["requestId"])
+	assert.Equal("user-id", errorLogs[0].ContextMap()["userId"])
+	assert.Equal(int64(http.StatusForbidden), errorLogs[0].ContextMap()["status"])
+
+	requestLogs := logs.FilterMessage("GET /me").All()
+	assert.Len(requestLogs, 1)
+	assert.Equal(int64(http.StatusForbidden), requestLogs[0].ContextMap()["status"])
+}
+
+func TestLoggerFromContext(t *testing.T) {
+	assert := assert.New(t)
+	assert.NotNil(LoggerFromContext(context.Background()))
+
+	logger := zap.NewExample()
+	ctx := ContextWithLogger(context.Background(), logger)
+	assert.Equal(logger, LoggerFromContext(ctx))
+
+	fallback := zap.NewExample()
+	assert.Equal(fallback, LoggerFromContextOr(context.Background(), fallback))
+	assert.Equal(logger, LoggerFromContextOr(ctx, fallback))
+}
@@ -0,0 +1,79 @@
+package httputil
+
+import (
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// MetricsPath conventional path to expose metrics on.
+const MetricsPath = "/metrics"
+
+// unmatchedRoute route label of requests that did not match any route.
+const unmatchedRoute = "unmatched"
+
+// Metrics request metrics middleware, records the number and latency of requests
+// by method, route and status in a prometheus.Registerer.
+// NewRouter adds it along with MetricsHandler when given WithMetrics.
+func Metrics(reg prometheus.Registerer) gin.HandlerFunc {
+	labels := []string{"method", "route", "status"}
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Number of handled HTTP requests.",
+	}, labels)
+	latency := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Latency of handled HTTP requests.",
+		Buckets: prometheus.DefBuckets,
+	}, labels)
+	reg.MustRegister(requests, latency)
+
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		labelValues := []string{c.Request.Method, routePattern(c), strconv.Itoa(status)}
+		requests.WithLabelValues(labelValues...).Inc()
+		latency.WithLabelValues(labelValues...).Observe(time.Since(start).Seconds())
+	}
+}
+
+// MetricsHandler exposes the metrics gathered by a prometheus.Gatherer.
+func MetricsHandler(gatherer prometheus.Gatherer) gin.HandlerFunc {
+	handler := promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{})
+	return gin.WrapH(handler)
+}
+
+// unmatchedHandlerName name of the handler of requests that did not match any route.
+var unmatchedHandlerName = runtime.FuncForPC(reflect.ValueOf(unmatched).Pointer()).Name()
+
+// routePattern reconstructs the route pattern of a request by replacing path parameters
+// with their names, to avoid creating a label per resource id. Requests that did not match
+// any route of a router created with NewRouter are labeled unmatchedRoute, whatever their status.
+func routePattern(c *gin.Context) string {
+	if c.HandlerName() == unmatchedHandlerName {
+		return unmatchedRoute
+	}
+	if len(c.Params) == 0 {
+		return c.Request.URL.Path
+	}
+
+	segments := strings.Split(c.Request.URL.Path, "/")
+	for _, param := range c.Params {
+		for i, segment := range segments {
+			if segment == param.Value {
+				segments[i] = ":" + param.Key
+				break
+			}
+		}
+	}
+
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,53 @@
+package httputil
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	assert := assert.New(t)
+	gin.SetMode(gin.TestMode)
+	reg := prometheus.NewRegistry()
+
+	r := NewRouter("test-service", "1.0", WithMetrics(reg), WithMiddleware(func(c *gin.Context) {
+		if strings.HasPrefix(c.Request.URL.Path, "/private/") {
+			c.AbortWithStatus(http.StatusUnauthorized)
+		}
+	}))
+	r.GET("/v1/users/:userId", SendOK)
+	r.GET("/v1/users/:userId/roles/:role", func(c *gin.Context) {
+		c.Error(ErrForbidden())
+	})
+
+	paths := []string{
+		"/v1/users/user-1",
+		"/v1/users/user-2",
+		"/v1/users/user-1/roles/ADMIN",
+		"/unknown/path",
+		"/private/user-1",
+		"/private/user-2",
+	}
+	for _, path := range paths {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, MetricsPath, nil))
+	assert.Equal(http.StatusOK, w.Code)
+
+	body := w.Body.String()
+	assert.True(strings.Contains(body, `http_requests_total{method="GET",route="/v1/users/:userId",status="200"} 2`), body)
+	assert.True(strings.Contains(body, `http_requests_total{method="GET",route="/v1/users/:userId/roles/:role",status="403"} 1`), body)
+	assert.True(strings.Contains(body, `http_requests_total{method="GET",route="unmatched",status="404"} 1`), body)
+	assert.True(strings.Contains(body, `http_requests_total{method="GET",route="unmatched",status="401"} 2`), body)
+	assert.True(strings.Contains(body, `http_request_duration_seconds_count{method="GET",route="/v1/users/:userId",status="200"} 2`), body)
+	assert.False(strings.Contains(body, "user-1"), body)
+}
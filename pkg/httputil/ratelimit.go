@@ -0,0 +1,230 @@
+package httputil
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Rate limit header keys
+const (
+	RetryAfterHeader         = "Retry-After"
+	RateLimitLimitHeader     = "RateLimit-Limit"
+	RateLimitRemainingHeader = "RateLimit-Remaining"
+	RateLimitResetHeader     = "RateLimit-Reset"
+)
+
+// subjectKey context key under which the authenticated subject is stored.
+const subjectKey = "httputil.Subject"
+
+// Limit describes the size and refill rate of a token bucket.
+// A bucket holds at most Requests tokens and is completely refilled over the duration Per.
+type Limit struct {
+	Requests int
+	Per      time.Duration
+}
+
+// NewLimit creates a new Limit.
+func NewLimit(requests int, per time.Duration) Limit {
+	return Limit{
+		Requests: requests,
+		Per:      per,
+	}
+}
+
+// refillRate returns the number of tokens added to a bucket per second.
+func (l Limit) refillRate() float64 {
+	return float64(l.Requests) / l.Per.Seconds()
+}
+
+// RateLimitResult outcome of an attempt to take a token from a bucket.
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+// RateLimitStore backend interface for keeping track of token buckets.
+type RateLimitStore interface {
+	Take(key string, limit Limit) (RateLimitResult, error)
+}
+
+// KeyFunc extracts the key a request should be rate limited by.
+type KeyFunc func(c *gin.Context) string
+
+// ClientIPKey rate limits requests by the IP address of the client.
+func ClientIPKey(c *gin.Context) string {
+	return c.ClientIP()
+}
+
+// SubjectKey rate limits requests by the authenticated subject,
+// falls back to the client IP for unauthenticated requests.
+func SubjectKey(c *gin.Context) string {
+	sub := c.GetString(subjectKey)
+	if sub == "" {
+		return ClientIPKey(c)
+	}
+	return sub
+}
+
+// RateLimiter creates rate limiting middleware backed by a shared RateLimitStore.
+type RateLimiter struct {
+	store RateLimitStore
+}
+
+// NewRateLimiter creates a new RateLimiter.
+func NewRateLimiter(store RateLimitStore) *RateLimiter {
+	return &RateLimiter{
+		store: store,
+	}
+}
+
+// Limit rate limits requests to a route. Requests are grouped by the key returned by keyFn
+// and the route name, which allows different routes to have separate limits.
+func (rl *RateLimiter) Limit(route string, limit Limit, keyFn KeyFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := fmt.Sprintf("%s:%s", route, keyFn(c))
+		res, err := rl.store.Take(key, limit)
+		if err != nil {
+			logger := LoggerFromContext(c.Request.Context()).Sugar().With("middleware", "Rate Limiter")
+			logger.Errorw("Failed to check rate limit", "route", route, "err", err)
+			c.Next()
+			return
+		}
+
+		setRateLimitHeaders(res, c)
+		if !res.Allowed {
+			c.Header(RetryAfterHeader, formatSeconds(res.RetryAfter))
+			abortWithError(ErrTooManyRequests(), c)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func setRateLimitHeaders(res RateLimitResult, c *gin.Context) {
+	c.Header(RateLimitLimitHeader, strconv.Itoa(res.Limit))
+	c.Header(RateLimitRemainingHeader, strconv.Itoa(res.Remaining))
+	c.Header(RateLimitResetHeader, formatSeconds(res.Reset))
+}
+
+// formatSeconds formats a duration as a whole number of seconds, rounded up.
+func formatSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
+
+// MemoryRateLimitStore in memory implementation of RateLimitStore.
+type MemoryRateLimitStore struct {
+	mu            sync.Mutex
+	buckets       map[string]*bucket
+	lastCleanup   time.Time
+	cleanupPeriod time.Duration
+	now           func() time.Time
+}
+
+// NewMemoryRateLimitStore creates a new MemoryRateLimitStore.
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		buckets:       make(map[string]*bucket),
+		lastCleanup:   time.Now(),
+		cleanupPeriod: time.Minute,
+		now:           time.Now,
+	}
+}
+
+// Take attempts to take a token from the bucket stored under a key.
+func (s *MemoryRateLimitStore) Take(key string, limit Limit) (RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.cleanup(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = newBucket(limit, now)
+		s.buckets[key] = b
+	}
+
+	return b.take(limit, now), nil
+}
+
+// cleanup removes buckets that have been completely refilled.
+func (s *MemoryRateLimitStore) cleanup(now time.Time) {
+	if now.Sub(s.lastCleanup) < s.cleanupPeriod {
+		return
+	}
+
+	for key, b := range s.buckets {
+		if b.isFull(now) {
+			delete(s.buckets, key)
+		}
+	}
+	s.lastCleanup = now
+}
+
+type bucket struct {
+	tokens     float64
+	capacity   float64
+	refillRate float64
+	updatedAt  time.Time
+}
+
+func newBucket(limit Limit, now time.Time) *bucket {
+	return &bucket{
+		tokens:     float64(limit.Requests),
+		capacity:   float64(limit.Requests),
+		refillRate: limit.refillRate(),
+		updatedAt:  now,
+	}
+}
+
+func (b *bucket) take(limit Limit, now time.Time) RateLimitResult {
+	b.capacity = float64(limit.Requests)
+	b.refillRate = limit.refillRate()
+	b.refill(now)
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+
+	res := RateLimitResult{
+		Allowed:   allowed,
+		Limit:     limit.Requests,
+		Remaining: int(b.tokens),
+		Reset:     b.durationUntil(b.capacity),
+	}
+	if !allowed {
+		res.RetryAfter = b.durationUntil(1)
+	}
+
+	return res
+}
+
+func (b *bucket) refill(now time.Time) {
+	elapsed := now.Sub(b.updatedAt).Seconds()
+	b.tokens = math.Min(b.capacity, b.tokens+elapsed*b.refillRate)
+	b.updatedAt = now
+}
+
+// durationUntil returns the time until the bucket holds a given number of tokens.
+func (b *bucket) durationUntil(tokens float64) time.Duration {
+	missing := tokens - b.tokens
+	if missing <= 0 {
+		return 0
+	}
+	return time.Duration(missing / b.refillRate * float64(time.Second))
+}
+
+func (b *bucket) isFull(now time.Time) bool {
+	b.refill(now)
+	return b.tokens >= b.capacity
+}
@@ -0,0 +1,89 @@
+package httputil
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryRateLimitStore(t *testing.T) {
+	assert := assert.New(t)
+
+	now := time.Now()
+	store := NewMemoryRateLimitStore()
+	store.now = func() time.Time { return now }
+	limit := NewLimit(2, 10*time.Second)
+
+	res, err := store.Take("key-1", limit)
+	assert.NoError(err)
+	assert.True(res.Allowed)
+	assert.Equal(2, res.Limit)
+	assert.Equal(1, res.Remaining)
+
+	res, err = store.Take("key-1", limit)
+	assert.NoError(err)
+	assert.True(res.Allowed)
+	assert.Equal(0, res.Remaining)
+	assert.Equal(10*time.Second, res.Reset)
+
+	res, err = store.Take("key-1", limit)
+	assert.NoError(err)
+	assert.False(res.Allowed)
+	assert.Equal(0, res.Remaining)
+	assert.Equal(5*time.Second, res.RetryAfter)
+
+	// Other keys should have separate buckets.
+	res, err = store.Take("key-2", limit)
+	assert.NoError(err)
+	assert.True(res.Allowed)
+
+	// Bucket should be refilled over time.
+	now = now.Add(5 * time.Second)
+	res, err = store.Take("key-1", limit)
+	assert.NoError(err)
+	assert.True(res.Allowed)
+	assert.Equal(0, res.Remaining)
+
+	// Full buckets should be removed on cleanup.
+	now = now.Add(2 * time.Minute)
+	store.cleanup(now)
+	assert.Len(store.buckets, 0)
+}
+
+func TestRateLimiter_Limit(t *testing.T) {
+	assert := assert.New(t)
+	gin.SetMode(gin.TestMode)
+
+	limiter := NewRateLimiter(NewMemoryRateLimitStore())
+	r := NewRouter("test-service", "1.0")
+	r.POST("/login", limiter.Limit("login", NewLimit(1, time.Minute), ClientIPKey), SendOK)
+	r.POST("/signup", limiter.Limit("signup", NewLimit(1, time.Minute), ClientIPKey), SendOK)
+
+	sendRequest := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := sendRequest("/login")
+	assert.Equal(http.StatusOK, w.Code)
+	assert.Equal("1", w.Header().Get(RateLimitLimitHeader))
+	assert.Equal("0", w.Header().Get(RateLimitRemainingHeader))
+	assert.Equal("60", w.Header().Get(RateLimitResetHeader))
+	assert.Equal("", w.Header().Get(RetryAfterHeader))
+
+	w = sendRequest("/login")
+	assert.Equal(http.StatusTooManyRequests, w.Code)
+	assert.Equal("60", w.Header().Get(RetryAfterHeader))
+	assert.Contains(w.Body.String(), "\"statusCode\":429")
+
+	// Routes should be limited separately.
+	w = sendRequest("/signup")
+	assert.Equal(http.StatusOK, w.Code)
+}
@@ -0,0 +1,63 @@
+package httputil
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecurityHeaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name string
+		cfg  SecurityHeadersConfig
+		want map[string]string
+	}{
+		{
+			name: "defaults",
+			cfg:  SecurityHeadersConfig{},
+			want: map[string]string{
+				StrictTransportSecurityHeader: "",
+				ContentTypeOptionsHeader:      "nosniff",
+				ContentSecurityPolicyHeader:   "frame-ancestors 'none'",
+				FrameOptionsHeader:            "DENY",
+				ReferrerPolicyHeader:          "no-referrer",
+			},
+		},
+		{
+			name: "configured",
+			cfg: SecurityHeadersConfig{
+				HSTSMaxAge:            365 * 24 * time.Hour,
+				HSTSIncludeSubdomains: true,
+				FrameAncestors:        []string{"'self'", "https://app.example.com"},
+				ReferrerPolicy:        "strict-origin-when-cross-origin",
+			},
+			want: map[string]string{
+				StrictTransportSecurityHeader: "max-age=31536000; includeSubDomains",
+				ContentTypeOptionsHeader:      "nosniff",
+				ContentSecurityPolicyHeader:   "frame-ancestors 'self' https://app.example.com",
+				FrameOptionsHeader:            "",
+				ReferrerPolicyHeader:          "strict-origin-when-cross-origin",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRouter("test-service", "1.0", WithSecurityHeaders(tt.cfg))
+			r.GET("/fail", func(c *gin.Context) {
+				c.Error(ErrForbidden())
+			})
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/fail", nil))
+			assert.Equal(t, http.StatusForbidden, w.Code)
+			for header, value := range tt.want {
+				assert.Equal(t, value, w.Header().Get(header), header)
+			}
+		})
+	}
+}
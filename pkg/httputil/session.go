@@ -0,0 +1,130 @@
+package httputil
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Session header keys and values.
+const (
+	SessionModeHeader = "X-Session-Mode"
+	CookieSessionMode = "cookie"
+	CSRFTokenHeader   = "X-CSRF-Token"
+)
+
+// Session cookie names.
+const (
+	AccessTokenCookie = "access_token"
+	CSRFTokenCookie   = "csrf_token"
+)
+
+// CodeInvalidCSRFToken error code of requests with a missing or mismatched csrf token.
+const CodeInvalidCSRFToken = "INVALID_CSRF_TOKEN"
+
+// CookieConfig configuration of session cookies.
+type CookieConfig struct {
+	Domain string
+	// Insecure lets cookies be sent over plain HTTP, should only be used in development.
+	Insecure bool
+	// SameSite defaults to http.SameSiteStrictMode.
+	SameSite http.SameSite
+	// MaxAge lifetime of the session cookies, they expire with the browser session if zero.
+	MaxAge time.Duration
+}
+
+// UsesCookieSession checks if the client has asked for tokens to be set as cookies
+// rather than returned in the response body.
+func UsesCookieSession(c *gin.Context) bool {
+	return strings.EqualFold(c.GetHeader(SessionModeHeader), CookieSessionMode)
+}
+
+// SetSessionCookies sets the access token as an HttpOnly cookie along with a csrf token cookie,
+// which the client must echo in the X-CSRF-Token header of state changing requests.
+func SetSessionCookies(c *gin.Context, cfg CookieConfig, accessToken string) error {
+	csrfToken, err := newCSRFToken()
+	if err != nil {
+		return err
+	}
+
+	maxAge := int(cfg.MaxAge.Seconds())
+	setCookie(c, cfg, AccessTokenCookie, accessToken, maxAge, true)
+	setCookie(c, cfg, CSRFTokenCookie, csrfToken, maxAge, false)
+	return nil
+}
+
+// ClearSessionCookies removes the session cookies from the client.
+func ClearSessionCookies(c *gin.Context, cfg CookieConfig) {
+	for _, name := range []string{AccessTokenCookie, CSRFTokenCookie} {
+		setCookie(c, cfg, name, "", -1, name != CSRFTokenCookie)
+	}
+}
+
+func setCookie(c *gin.Context, cfg CookieConfig, name, value string, maxAge int, httpOnly bool) {
+	sameSite := cfg.SameSite
+	if sameSite == 0 {
+		sameSite = http.SameSiteStrictMode
+	}
+
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		Domain:   cfg.Domain,
+		MaxAge:   maxAge,
+		Secure:   !cfg.Insecure,
+		HttpOnly: httpOnly,
+		SameSite: sameSite,
+	})
+}
+
+// CSRF double submit csrf protection middleware. State changing requests carrying an
+// access token cookie must send the value of the csrf token cookie in the X-CSRF-Token header.
+// Requests authenticated with a bearer token are not affected.
+// Authenticate applies the same check to requests it authenticates with the access token cookie.
+func CSRF() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		_, err := c.Cookie(AccessTokenCookie)
+		if err == nil {
+			err = checkCSRFToken(c)
+			if err != nil {
+				abortWithError(err, c)
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// checkCSRFToken checks that state changing requests send the csrf token cookie value in the csrf header.
+func checkCSRFToken(c *gin.Context) error {
+	switch c.Request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return nil
+	}
+
+	cookie, err := c.Cookie(CSRFTokenCookie)
+	header := c.GetHeader(CSRFTokenHeader)
+	if err != nil || cookie == "" || subtle.ConstantTimeCompare([]byte(cookie), []byte(header)) != 1 {
+		return NewError("Invalid CSRF token", http.StatusForbidden).
+			WithCode(CodeInvalidCSRFToken).
+			WithMessageKey("csrf.invalid")
+	}
+
+	return nil
+}
+
+func newCSRFToken() (string, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,166 @@
+package httputil
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/CzarSimon/user-service/pkg/auth"
+	"github.com/CzarSimon/user-service/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetSessionCookies(t *testing.T) {
+	assert := assert.New(t)
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	err := SetSessionCookies(c, CookieConfig{Domain: "example.com", MaxAge: time.Hour}, "access")
+	assert.NoError(err)
+
+	cookies := make(map[string]*http.Cookie)
+	for _, cookie := range (&http.Response{Header: w.Header()}).Cookies() {
+		cookies[cookie.Name] = cookie
+	}
+	assert.Len(cookies, 2)
+	accessCookie := cookies[AccessTokenCookie]
+	assert.True(accessCookie.HttpOnly)
+	assert.True(accessCookie.Secure)
+	assert.Equal(http.SameSiteStrictMode, accessCookie.SameSite)
+	assert.Equal(3600, accessCookie.MaxAge)
+	assert.Equal("example.com", accessCookie.Domain)
+	assert.Equal("access", accessCookie.Value)
+	assert.False(cookies[CSRFTokenCookie].HttpOnly)
+	assert.Len(cookies[CSRFTokenCookie].Value, 43)
+
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	err = SetSessionCookies(c, CookieConfig{Insecure: true, SameSite: http.SameSiteLaxMode}, "access")
+	assert.NoError(err)
+	cookies = make(map[string]*http.Cookie)
+	for _, cookie := range (&http.Response{Header: w.Header()}).Cookies() {
+		cookies[cookie.Name] = cookie
+	}
+	assert.Len(cookies, 2)
+	assert.False(cookies[AccessTokenCookie].Secure)
+	assert.Equal(http.SameSiteLaxMode, cookies[AccessTokenCookie].SameSite)
+}
+
+func TestCookieAuthentication(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	creds := auth.JWTCredentials{
+		Issuer: "user-service-name",
+		Secret: "jwt-secret",
+	}
+	issuer := auth.NewJWTIssuer(creds)
+	verifier := auth.NewJWTVerifier(creds, time.Minute)
+	token, err := issuer.Issue("user-id", models.UserRole)
+	assert.NoError(t, err)
+
+	r := NewRouter("test-service", "1.0")
+	r.GET("/me", Authenticate(verifier), SendOK)
+	r.POST("/me", Authenticate(verifier), SendOK)
+	r.POST("/logout", CSRF(), SendOK)
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		bearer      bool
+		cookie      bool
+		csrfCookie  string
+		csrfHeader  string
+		wantStatus  int
+		wantErrCode string
+	}{
+		{
+			name:       "cookie-get-without-csrf",
+			method:     http.MethodGet,
+			path:       "/me",
+			cookie:     true,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "cookie-post-with-csrf",
+			method:     http.MethodPost,
+			path:       "/me",
+			cookie:     true,
+			csrfCookie: "csrf-token",
+			csrfHeader: "csrf-token",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "bearer-post-without-csrf",
+			method:     http.MethodPost,
+			path:       "/me",
+			bearer:     true,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "csrf-middleware-without-session",
+			method:     http.MethodPost,
+			path:       "/logout",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:        "sad-path-cookie-post-without-csrf",
+			method:      http.MethodPost,
+			path:        "/me",
+			cookie:      true,
+			csrfCookie:  "csrf-token",
+			wantStatus:  http.StatusForbidden,
+			wantErrCode: CodeInvalidCSRFToken,
+		},
+		{
+			name:        "sad-path-cookie-post-mismatched-csrf",
+			method:      http.MethodPost,
+			path:        "/me",
+			cookie:      true,
+			csrfCookie:  "csrf-token",
+			csrfHeader:  "other-token",
+			wantStatus:  http.StatusForbidden,
+			wantErrCode: CodeInvalidCSRFToken,
+		},
+		{
+			name:        "sad-path-csrf-middleware-with-session",
+			method:      http.MethodPost,
+			path:        "/logout",
+			cookie:      true,
+			wantStatus:  http.StatusForbidden,
+			wantErrCode: CodeInvalidCSRFToken,
+		},
+		{
+			name:       "sad-path-no-credentials",
+			method:     http.MethodGet,
+			path:       "/me",
+			wantStatus: http.StatusUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.bearer {
+				req.Header.Set(AuthorizationHeader, BearerPrefix+token)
+			}
+			if tt.cookie {
+				req.AddCookie(&http.Cookie{Name: AccessTokenCookie, Value: token})
+			}
+			if tt.csrfCookie != "" {
+				req.AddCookie(&http.Cookie{Name: CSRFTokenCookie, Value: tt.csrfCookie})
+			}
+			if tt.csrfHeader != "" {
+				req.Header.Set(CSRFTokenHeader, tt.csrfHeader)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantErrCode != "" {
+				assert.Contains(t, w.Body.String(), tt.wantErrCode)
+			}
+		})
+	}
+}
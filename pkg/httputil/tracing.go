@@ -0,0 +1,54 @@
+package httputil
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/CzarSimon/user-service/pkg/httputil"
+
+// Tracing tracing middleware, starts a server span for each request using the global
+// tracer provider. Traces are continued from the W3C traceparent header of incoming requests
+// and the span is made available to handlers through the request context.
+// Should be passed to NewRouter with WithMiddleware to observe the status of error responses.
+func Tracing() gin.HandlerFunc {
+	tracer := otel.Tracer(tracerName)
+	propagator := propagation.TraceContext{}
+
+	return func(c *gin.Context) {
+		ctx := propagator.Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+		ctx, span := tracer.Start(ctx, "HTTP "+c.Request.Method, trace.WithSpanKind(trace.SpanKindServer))
+		defer span.End()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+
+		status := c.Writer.Status()
+		route := routePattern(c)
+		span.SetName(c.Request.Method + " " + route)
+		span.SetAttributes(
+			attribute.String("http.method", c.Request.Method),
+			attribute.String("http.route", route),
+			attribute.Int("http.status_code", status),
+			attribute.String("http.request_id", GetRequestID(c)),
+		)
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	}
+}
+
+// GetTraceID returns the trace id of the request, empty if the request is not traced.
+func GetTraceID(c *gin.Context) string {
+	spanContext := trace.SpanContextFromContext(c.Request.Context())
+	if !spanContext.HasTraceID() {
+		return ""
+	}
+	return spanContext.TraceID().String()
+}
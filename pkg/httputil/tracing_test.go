@@ -0,0 +1,63 @@
+package httputil
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracing(t *testing.T) {
+	assert := assert.New(t)
+	gin.SetMode(gin.TestMode)
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+
+	var handlerSpan trace.SpanContext
+	r := NewRouter("test-service", "1.0", WithMiddleware(Tracing()))
+	r.GET("/v1/users/:userId", func(c *gin.Context) {
+		handlerSpan = trace.SpanContextFromContext(c.Request.Context())
+		c.Error(NewInternalServerError("Failed"))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/users/user-1", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(http.StatusInternalServerError, w.Code)
+
+	spans := exporter.GetSpans()
+	assert.Len(spans, 1)
+	span := spans[0]
+	assert.Equal("GET /v1/users/:userId", span.Name)
+	assert.Equal(trace.SpanKindServer, span.SpanKind)
+	assert.Equal("4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext.TraceID().String())
+	assert.Equal("00f067aa0ba902b7", span.Parent.SpanID().String())
+	assert.True(span.Parent.IsRemote())
+	assert.Equal(span.SpanContext.SpanID(), handlerSpan.SpanID())
+	assert.Contains(span.Attributes, attribute.Int("http.status_code", http.StatusInternalServerError))
+	assert.Contains(span.Attributes, attribute.String("http.route", "/v1/users/:userId"))
+
+	exporter.Reset()
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/users/user-2", nil))
+	spans = exporter.GetSpans()
+	assert.Len(spans, 1)
+	assert.False(spans[0].Parent.IsValid())
+	assert.True(spans[0].SpanContext.IsValid())
+
+	exporter.Reset()
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/unknown/user-1", nil))
+	assert.Equal(http.StatusNotFound, w.Code)
+	spans = exporter.GetSpans()
+	assert.Len(spans, 1)
+	assert.Equal("GET unmatched", spans[0].Name)
+}
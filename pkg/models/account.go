@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"github.com/CzarSimon/user-service/pkg/id"
+)
+
+// LoginEvent record of a login attempt for an existing user.
+type LoginEvent struct {
+	ID        string    `json:"id,omitempty"`
+	UserID    string    `json:"userId,omitempty"`
+	Success   bool      `json:"success"`
+	IP        string    `json:"ip,omitempty"`
+	UserAgent string    `json:"userAgent,omitempty"`
+	CreatedAt time.Time `json:"createdAt,omitempty"`
+}
+
+// NewLoginEvent creates a new LoginEvent.
+func NewLoginEvent(userID string, success bool, client ClientInfo) LoginEvent {
+	return LoginEvent{
+		ID:        id.New(),
+		UserID:    userID,
+		Success:   success,
+		IP:        client.IP,
+		UserAgent: client.UserAgent,
+		CreatedAt: now(),
+	}
+}
+
+// DeleteAccountRequest request body for a user to delete their account.
+type DeleteAccountRequest struct {
+	UserID   string `json:"userId,omitempty"`
+	Password string `json:"password,omitempty"`
+}
+
+// AccountExport archive of the data stored about a user.
+type AccountExport struct {
+	User         User         `json:"user"`
+	LoginHistory []LoginEvent `json:"loginHistory"`
+	Sessions     []Session    `json:"sessions"`
+	ExportedAt   time.Time    `json:"exportedAt"`
+}
@@ -0,0 +1,108 @@
+package models
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Pagination limits.
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 500
+)
+
+// Common pagination errors.
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+// UserQuery filter and pagination parameters for listing users.
+type UserQuery struct {
+	Role          string
+	EmailPrefix   string
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	After         UserCursor
+	Limit         int
+}
+
+// UserCursor position in a list of users ordered by creation date and id.
+type UserCursor struct {
+	CreatedAt time.Time
+	UserID    string
+}
+
+// NewUserCursor creates a cursor pointing at a user.
+func NewUserCursor(user User) UserCursor {
+	return UserCursor{
+		CreatedAt: user.CreatedAt,
+		UserID:    user.ID,
+	}
+}
+
+// IsZero checks if the cursor points at the start of the list.
+func (c UserCursor) IsZero() bool {
+	return c.UserID == "" && c.CreatedAt.IsZero()
+}
+
+// Encode encodes the cursor as an opaque string.
+func (c UserCursor) Encode() string {
+	if c.IsZero() {
+		return ""
+	}
+
+	raw := fmt.Sprintf("%s|%s", c.CreatedAt.UTC().Format(time.RFC3339Nano), c.UserID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeUserCursor decodes a cursor encoded with UserCursor.Encode.
+func DecodeUserCursor(encoded string) (UserCursor, error) {
+	if encoded == "" {
+		return UserCursor{}, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return UserCursor{}, ErrInvalidCursor
+	}
+
+	parts := strings.SplitN(string(raw), "|", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return UserCursor{}, ErrInvalidCursor
+	}
+
+	createdAt, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return UserCursor{}, ErrInvalidCursor
+	}
+
+	return UserCursor{
+		CreatedAt: createdAt,
+		UserID:    parts[1],
+	}, nil
+}
+
+// UserPage page of users and the cursor to the next page, empty if there are no more users.
+type UserPage struct {
+	Users      []User `json:"users"`
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// ChangeRoleRequest request body for changing the roles of a user.
+// The first role becomes the primary role of the user.
+type ChangeRoleRequest struct {
+	UserID string   `json:"userId,omitempty"`
+	Roles  []string `json:"roles,omitempty"`
+}
+
+// Apply applies the roles in the request to a user and bumps its version.
+func (r ChangeRoleRequest) Apply(user User) User {
+	user.Role = r.Roles[0]
+	user.Roles = r.Roles
+	user.Version++
+	user.UpdatedAt = now()
+	return user
+}
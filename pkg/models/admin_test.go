@@ -0,0 +1,38 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/CzarSimon/user-service/pkg/id"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserCursor(t *testing.T) {
+	assert := assert.New(t)
+
+	user := NewUser("mail@mail.com", "fname", "lname", UserRole, Credentials{})
+	cursor := NewUserCursor(user)
+	encoded := cursor.Encode()
+	assert.NotEqual("", encoded)
+
+	decoded, err := DecodeUserCursor(encoded)
+	assert.NoError(err)
+	assert.Equal(user.ID, decoded.UserID)
+	assert.True(user.CreatedAt.Equal(decoded.CreatedAt))
+
+	decoded, err = DecodeUserCursor("")
+	assert.NoError(err)
+	assert.True(decoded.IsZero())
+	assert.Equal("", decoded.Encode())
+
+	invalid := []string{
+		"not base64 !",
+		UserCursor{CreatedAt: time.Now()}.Encode(),
+		UserCursor{UserID: id.New()}.Encode()[2:],
+	}
+	for _, encoded := range invalid {
+		_, err = DecodeUserCursor(encoded)
+		assert.Equal(ErrInvalidCursor, err, encoded)
+	}
+}
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"time"
+
+	"github.com/CzarSimon/user-service/pkg/id"
+)
+
+// APIKey long lived credential a user creates for scripts and tools, which authenticates as the user.
+// Keys are only stored as their prefix, which identifies the key, and a hash of the full key.
+// Keys without an expiry are valid until revoked.
+type APIKey struct {
+	ID         string    `json:"id"`
+	UserID     string    `json:"userId"`
+	Name       string    `json:"name"`
+	Prefix     string    `json:"prefix"`
+	KeyHash    string    `json:"-"`
+	CreatedAt  time.Time `json:"createdAt"`
+	ExpiresAt  time.Time `json:"expiresAt,omitempty"`
+	LastUsedAt time.Time `json:"lastUsedAt,omitempty"`
+	RevokedAt  time.Time `json:"revokedAt,omitempty"`
+}
+
+// NewAPIKey creates a new APIKey for a user.
+func NewAPIKey(userID, prefix, keyHash string, req CreateAPIKeyRequest) APIKey {
+	return APIKey{
+		ID:        id.New(),
+		UserID:    userID,
+		Name:      req.Name,
+		Prefix:    prefix,
+		KeyHash:   keyHash,
+		CreatedAt: now(),
+		ExpiresAt: req.ExpiresAt.UTC(),
+	}
+}
+
+// IsRevoked checks if the key has been revoked.
+func (k APIKey) IsRevoked() bool {
+	return !k.RevokedAt.IsZero()
+}
+
+// IsExpired checks if the key has an expiry which has passed.
+func (k APIKey) IsExpired() bool {
+	return !k.ExpiresAt.IsZero() && !now().Before(k.ExpiresAt)
+}
+
+// CreateAPIKeyRequest request body for creating an API key. ExpiresAt is optional.
+type CreateAPIKeyRequest struct {
+	Name      string    `json:"name,omitempty"`
+	ExpiresAt time.Time `json:"expiresAt,omitempty"`
+}
+
+// Validate checks that the request contains a name and that the expiry, if any, is in the future.
+func (r CreateAPIKeyRequest) Validate() error {
+	var v validator
+	v.required("name", r.Name, MaxNameLength)
+	if !r.ExpiresAt.IsZero() && !now().Before(r.ExpiresAt) {
+		v.add("expiresAt", "validation.future", "must be in the future")
+	}
+	return v.err()
+}
+
+// CreatedAPIKey response to the creation of an API key. The key is only returned once, when it is created.
+type CreatedAPIKey struct {
+	APIKey APIKey `json:"apiKey"`
+	Key    string `json:"key"`
+}
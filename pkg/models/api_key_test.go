@@ -0,0 +1,34 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIKey(t *testing.T) {
+	assert := assert.New(t)
+
+	req := CreateAPIKeyRequest{Name: "Deploy script"}
+	assert.NoError(req.Validate())
+	key := NewAPIKey("user-id", "prefix", "hash", req)
+	assert.NotEmpty(key.ID)
+	assert.Equal("user-id", key.UserID)
+	assert.True(key.ExpiresAt.IsZero())
+	assert.False(key.IsExpired())
+	assert.False(key.IsRevoked())
+
+	req.ExpiresAt = time.Now().Add(time.Hour)
+	assert.NoError(req.Validate())
+	key = NewAPIKey("user-id", "prefix", "hash", req)
+	assert.False(key.IsExpired())
+	key.ExpiresAt = time.Now().UTC().Add(-time.Second)
+	assert.True(key.IsExpired())
+
+	key.RevokedAt = time.Now().UTC()
+	assert.True(key.IsRevoked())
+
+	assert.Error(CreateAPIKeyRequest{}.Validate())
+	assert.Error(CreateAPIKeyRequest{Name: "Deploy script", ExpiresAt: time.Now().Add(-time.Hour)}.Validate())
+}
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"time"
+
+	"github.com/CzarSimon/user-service/pkg/id"
+)
+
+// Audit event types.
+const (
+	AuditSignup          = "SIGNUP"
+	AuditLogin           = "LOGIN"
+	AuditPasswordChange  = "PASSWORD_CHANGE"
+	AuditTokenRevocation = "TOKEN_REVOCATION"
+	AuditRoleChange      = "ROLE_CHANGE"
+)
+
+// Audit event outcomes.
+const (
+	AuditSuccess = "SUCCESS"
+	AuditFailure = "FAILURE"
+)
+
+// AuditEvent record of a security relevant action. ActorID is the user performing
+// the action and SubjectID the user it was performed on, which are the same for
+// actions users perform on themselves.
+type AuditEvent struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	ActorID   string    `json:"actorId,omitempty"`
+	SubjectID string    `json:"subjectId,omitempty"`
+	IP        string    `json:"ip,omitempty"`
+	UserAgent string    `json:"userAgent,omitempty"`
+	RequestID string    `json:"requestId,omitempty"`
+	Outcome   string    `json:"outcome"`
+	Reason    string    `json:"reason,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// NewAuditEvent creates a new AuditEvent. The outcome is a failure if a reason is given.
+func NewAuditEvent(eventType, actorID, subjectID, reason string) AuditEvent {
+	outcome := AuditSuccess
+	if reason != "" {
+		outcome = AuditFailure
+	}
+
+	return AuditEvent{
+		ID:        id.New(),
+		Type:      eventType,
+		ActorID:   actorID,
+		SubjectID: subjectID,
+		Outcome:   outcome,
+		Reason:    reason,
+		CreatedAt: now(),
+	}
+}
+
+// AuditQuery filter parameters for querying the audit log, which returns the newest events first.
+type AuditQuery struct {
+	Type      string
+	ActorID   string
+	SubjectID string
+	Outcome   string
+	After     time.Time
+	Before    time.Time
+	Limit     int
+}
@@ -0,0 +1,122 @@
+package models
+
+import (
+	"errors"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/CzarSimon/user-service/pkg/id"
+	"golang.org/x/net/idna"
+)
+
+// maxLocalPartLength limit of the part before @ in an email address as given in RFC 5321.
+const maxLocalPartLength = 64
+
+// Common email address errors.
+var (
+	ErrEmptyEmail   = errors.New("email is required")
+	ErrInvalidEmail = errors.New("invalid email address")
+)
+
+// EmailNormalizer normalizes email addresses so that equivalent addresses
+// are stored and looked up in the same form.
+type EmailNormalizer struct {
+	LowercaseLocalPart bool
+}
+
+// DefaultEmailNormalizer treats email addresses as case insensitive.
+var DefaultEmailNormalizer = EmailNormalizer{LowercaseLocalPart: true}
+
+// Normalize trims whitespace, lowercases and IDNA encodes the domain and optionally
+// lowercases the local part of an email address. Returns an error if the address is not
+// syntactically valid.
+func (n EmailNormalizer) Normalize(email string) (string, error) {
+	email = strings.TrimSpace(email)
+	if email == "" {
+		return "", ErrEmptyEmail
+	}
+
+	at := strings.LastIndex(email, "@")
+	if at < 1 || at == len(email)-1 {
+		return "", ErrInvalidEmail
+	}
+
+	local, domain := email[:at], email[at+1:]
+	if len(local) > maxLocalPartLength {
+		return "", ErrInvalidEmail
+	}
+
+	if n.LowercaseLocalPart {
+		local = strings.ToLower(local)
+	}
+
+	domain, err := idna.Lookup.ToASCII(strings.ToLower(domain))
+	if err != nil {
+		return "", ErrInvalidEmail
+	}
+
+	normalized := local + "@" + domain
+	if len(normalized) > MaxEmailLength {
+		return "", ErrInvalidEmail
+	}
+
+	addr, err := mail.ParseAddress(normalized)
+	if err != nil || addr.Address != normalized || addr.Name != "" {
+		return "", ErrInvalidEmail
+	}
+
+	return normalized, nil
+}
+
+// ChangeEmailRequest request body for a request to change email address.
+type ChangeEmailRequest struct {
+	UserID   string `json:"userId,omitempty"`
+	NewEmail string `json:"newEmail,omitempty"`
+	Password string `json:"password,omitempty"`
+}
+
+// EmailTokenRequest request body for confirming or reverting an email change.
+type EmailTokenRequest struct {
+	Token string `json:"token,omitempty"`
+}
+
+// EmailChange pending or completed change of a users email address.
+// Tokens are only stored as hashes.
+type EmailChange struct {
+	ID               string
+	UserID           string
+	OldEmail         string
+	NewEmail         string
+	ConfirmTokenHash string
+	RevertTokenHash  string
+	CreatedAt        time.Time
+	ExpiresAt        time.Time
+	ConfirmedAt      time.Time
+	RevertableUntil  time.Time
+	RevertedAt       time.Time
+}
+
+// NewEmailChange creates a new EmailChange that must be confirmed within a given duration.
+func NewEmailChange(user User, newEmail, confirmTokenHash string, validFor time.Duration) EmailChange {
+	createdAt := now()
+	return EmailChange{
+		ID:               id.New(),
+		UserID:           user.ID,
+		OldEmail:         user.Email,
+		NewEmail:         newEmail,
+		ConfirmTokenHash: confirmTokenHash,
+		CreatedAt:        createdAt,
+		ExpiresAt:        createdAt.Add(validFor),
+	}
+}
+
+// Confirmable checks if the change can still be confirmed.
+func (c EmailChange) Confirmable() bool {
+	return c.ConfirmedAt.IsZero() && now().Before(c.ExpiresAt)
+}
+
+// Revertable checks if the change has been confirmed and can still be reverted.
+func (c EmailChange) Revertable() bool {
+	return !c.ConfirmedAt.IsZero() && c.RevertedAt.IsZero() && now().Before(c.RevertableUntil)
+}
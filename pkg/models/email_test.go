@@ -0,0 +1,122 @@
+package models
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmailChange(t *testing.T) {
+	assert := assert.New(t)
+
+	user := NewUser("old@mail.com", "fname", "lname", UserRole, Credentials{})
+	change := NewEmailChange(user, "new@mail.com", "token-hash", time.Hour)
+	assert.Equal(user.ID, change.UserID)
+	assert.Equal("old@mail.com", change.OldEmail)
+	assert.Equal("new@mail.com", change.NewEmail)
+	assert.True(change.Confirmable())
+	assert.False(change.Revertable())
+
+	change.ConfirmedAt = now()
+	change.RevertableUntil = now().Add(time.Hour)
+	assert.False(change.Confirmable())
+	assert.True(change.Revertable())
+
+	change.RevertedAt = now()
+	assert.False(change.Revertable())
+
+	expired := NewEmailChange(user, "new@mail.com", "token-hash", -time.Minute)
+	assert.False(expired.Confirmable())
+}
+
+func TestEmailNormalizer_Normalize(t *testing.T) {
+	tests := []struct {
+		name       string
+		normalizer EmailNormalizer
+		email      string
+		want       string
+		wantErr    error
+	}{
+		{
+			name:       "happy-path",
+			normalizer: DefaultEmailNormalizer,
+			email:      "  Foo.Bar@Example.COM ",
+			want:       "foo.bar@example.com",
+		},
+		{
+			name:       "happy-path-keep-local-case",
+			normalizer: EmailNormalizer{LowercaseLocalPart: false},
+			email:      "Foo@Example.com",
+			want:       "Foo@example.com",
+		},
+		{
+			name:       "happy-path-idna-domain",
+			normalizer: DefaultEmailNormalizer,
+			email:      "user@Bücher.example",
+			want:       "user@xn--bcher-kva.example",
+		},
+		{
+			name:       "happy-path-plus-address",
+			normalizer: DefaultEmailNormalizer,
+			email:      "user+tag@mail.com",
+			want:       "user+tag@mail.com",
+		},
+		{
+			name:       "sad-path-empty",
+			normalizer: DefaultEmailNormalizer,
+			email:      "   ",
+			wantErr:    ErrEmptyEmail,
+		},
+		{
+			name:       "sad-path-missing-at",
+			normalizer: DefaultEmailNormalizer,
+			email:      "mail.com",
+			wantErr:    ErrInvalidEmail,
+		},
+		{
+			name:       "sad-path-missing-local-part",
+			normalizer: DefaultEmailNormalizer,
+			email:      "@mail.com",
+			wantErr:    ErrInvalidEmail,
+		},
+		{
+			name:       "sad-path-missing-domain",
+			normalizer: DefaultEmailNormalizer,
+			email:      "user@",
+			wantErr:    ErrInvalidEmail,
+		},
+		{
+			name:       "sad-path-display-name",
+			normalizer: DefaultEmailNormalizer,
+			email:      "User <user@mail.com>",
+			wantErr:    ErrInvalidEmail,
+		},
+		{
+			name:       "sad-path-whitespace-in-local-part",
+			normalizer: DefaultEmailNormalizer,
+			email:      "us er@mail.com",
+			wantErr:    ErrInvalidEmail,
+		},
+		{
+			name:       "sad-path-invalid-domain",
+			normalizer: DefaultEmailNormalizer,
+			email:      "user@exa_mple..com",
+			wantErr:    ErrInvalidEmail,
+		},
+		{
+			name:       "sad-path-local-part-too-long",
+			normalizer: DefaultEmailNormalizer,
+			email:      strings.Repeat("a", 65) + "@mail.com",
+			wantErr:    ErrInvalidEmail,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.normalizer.Normalize(tt.email)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
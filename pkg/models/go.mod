@@ -5,4 +5,9 @@ go 1.12
 require (
 	github.com/CzarSimon/user-service/pkg/id v0.0.0-20190410202449-fff9f20481f6
 	github.com/stretchr/testify v1.3.0
+	golang.org/x/net v0.0.0-20190404232315-eb5bcb51f2a3
+)
+
+replace (
+	github.com/CzarSimon/user-service/pkg/id => ../id
 )
@@ -0,0 +1,340 @@
+package models
+
+import (
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/CzarSimon/user-service/pkg/id"
+)
+
+// OAuth client types as given in RFC 6749. Confidential clients authenticate
+// with a client secret, public clients rely on PKCE alone.
+const (
+	ConfidentialClient = "confidential"
+	PublicClient       = "public"
+)
+
+// OAuth grant and response types.
+const (
+	AuthorizationCodeGrant = "authorization_code"
+	RefreshTokenGrant      = "refresh_token"
+	ClientCredentialsGrant = "client_credentials"
+	CodeResponseType       = "code"
+	BearerTokenType        = "Bearer"
+)
+
+// OAuth request field limits.
+const (
+	MaxClientIDLength    = 100
+	MaxClientNameLength  = 100
+	MaxRedirectURILength = 2000
+	MaxScopeLength       = 1000
+	MaxStateLength       = 500
+)
+
+// clientScopes scopes which clients may be allowed to request, the OpenID Connect scopes and the known permissions.
+var clientScopes = []string{
+	OpenIDScope,
+	ProfileScope,
+	EmailScope,
+	ReadUsersPermission,
+	WriteUsersPermission,
+	AssignRolesPermission,
+	ReadAuditLogPermission,
+	WriteClientsPermission,
+	WriteServiceAccountsPermission,
+	IntrospectTokensPermission,
+}
+
+// OAuthClient application registered to request tokens on behalf of users.
+// Scopes lists the scopes the client is allowed to request. Client secrets are only stored as hashes.
+type OAuthClient struct {
+	ID                     string    `json:"clientId"`
+	Name                   string    `json:"name"`
+	Type                   string    `json:"type"`
+	RedirectURIs           []string  `json:"redirectUris"`
+	PostLogoutRedirectURIs []string  `json:"postLogoutRedirectUris,omitempty"`
+	Scopes                 []string  `json:"scopes"`
+	SecretHash             string    `json:"-"`
+	Salt                   string    `json:"-"`
+	CreatedAt              time.Time `json:"createdAt"`
+}
+
+// IsConfidential checks if the client must authenticate with a client secret.
+func (c OAuthClient) IsConfidential() bool {
+	return c.Type == ConfidentialClient
+}
+
+// HasRedirectURI checks if a redirect uri has been registered for the client. URIs must match exactly.
+func (c OAuthClient) HasRedirectURI(uri string) bool {
+	return containsString(c.RedirectURIs, uri)
+}
+
+// HasPostLogoutRedirectURI checks if a uri has been registered for the client to redirect users to after logout.
+func (c OAuthClient) HasPostLogoutRedirectURI(uri string) bool {
+	return containsString(c.PostLogoutRedirectURIs, uri)
+}
+
+// AllowsScopes checks if the client is allowed to request every one of a list of scopes.
+func (c OAuthClient) AllowsScopes(scopes []string) bool {
+	return containsAll(c.Scopes, scopes)
+}
+
+// RegisterClientRequest request body for registering an OAuth client.
+type RegisterClientRequest struct {
+	Name                   string   `json:"name,omitempty"`
+	Type                   string   `json:"type,omitempty"`
+	RedirectURIs           []string `json:"redirectUris,omitempty"`
+	PostLogoutRedirectURIs []string `json:"postLogoutRedirectUris,omitempty"`
+	Scopes                 []string `json:"scopes,omitempty"`
+}
+
+// Validate checks that the request contains all required fields within their limits.
+// Redirect uris must not contain a fragment and must use https, or http on a loopback host.
+// Public clients, such as native apps, may also use private-use schemes as given in RFC 8252.
+// Scopes must be OpenID Connect scopes or known permissions.
+func (r RegisterClientRequest) Validate() error {
+	var v validator
+	v.required("name", r.Name, MaxClientNameLength)
+	if r.Type != ConfidentialClient && r.Type != PublicClient {
+		v.add("type", "validation.clientType", "must be one of confidential, public")
+	}
+
+	if len(r.RedirectURIs) == 0 {
+		v.add("redirectUris", "validation.required", "is required")
+	}
+	native := r.Type == PublicClient
+	for _, uri := range r.RedirectURIs {
+		if !validRedirectURI(uri, native) {
+			v.add("redirectUris", "validation.redirectUris", "must be https or loopback http uris without fragments")
+			break
+		}
+	}
+	for _, uri := range r.PostLogoutRedirectURIs {
+		if !validRedirectURI(uri, native) {
+			v.add("postLogoutRedirectUris", "validation.redirectUris", "must be https or loopback http uris without fragments")
+			break
+		}
+	}
+	if !containsAll(clientScopes, r.Scopes) {
+		v.add("scopes", "validation.scopes", "must be permissions or OpenID Connect scopes")
+	}
+
+	return v.err()
+}
+
+// Client creates a new OAuth client from a registration request.
+func (r RegisterClientRequest) Client(secretHash, salt string) OAuthClient {
+	return OAuthClient{
+		ID:                     id.New(),
+		Name:                   r.Name,
+		Type:                   r.Type,
+		RedirectURIs:           r.RedirectURIs,
+		PostLogoutRedirectURIs: r.PostLogoutRedirectURIs,
+		Scopes:                 r.Scopes,
+		SecretHash:             secretHash,
+		Salt:                   salt,
+		CreatedAt:              now(),
+	}
+}
+
+// ClientRegistration response to the registration of an OAuth client.
+// The client secret is only returned once, when a confidential client is registered.
+type ClientRegistration struct {
+	Client       OAuthClient `json:"client"`
+	ClientSecret string      `json:"clientSecret,omitempty"`
+}
+
+// AuthorizeRequest parameters of an OAuth authorization request as given in RFC 6749 and RFC 7636,
+// along with the OpenID Connect nonce. Approved is set when the user answers the consent prompt.
+type AuthorizeRequest struct {
+	ResponseType        string `form:"response_type" json:"response_type,omitempty"`
+	ClientID            string `form:"client_id" json:"client_id,omitempty"`
+	RedirectURI         string `form:"redirect_uri" json:"redirect_uri,omitempty"`
+	Scope               string `form:"scope" json:"scope,omitempty"`
+	State               string `form:"state" json:"state,omitempty"`
+	CodeChallenge       string `form:"code_challenge" json:"code_challenge,omitempty"`
+	CodeChallengeMethod string `form:"code_challenge_method" json:"code_challenge_method,omitempty"`
+	Nonce               string `form:"nonce" json:"nonce,omitempty"`
+	Approved            bool   `form:"approved" json:"approved,omitempty"`
+}
+
+// Validate checks that the request contains all required fields within their limits.
+func (r AuthorizeRequest) Validate() error {
+	var v validator
+	v.required("client_id", r.ClientID, MaxClientIDLength)
+	v.required("redirect_uri", r.RedirectURI, MaxRedirectURILength)
+	v.maxLength("scope", r.Scope, MaxScopeLength)
+	v.maxLength("state", r.State, MaxStateLength)
+	v.maxLength("nonce", r.Nonce, MaxStateLength)
+	return v.err()
+}
+
+// ConsentPrompt what a user is asked to approve in response to an authorization request.
+type ConsentPrompt struct {
+	ClientID   string   `json:"clientId"`
+	ClientName string   `json:"clientName"`
+	Scopes     []string `json:"scopes"`
+}
+
+// AuthorizationCode short lived code exchanged by a client for tokens. Codes are only stored as hashes.
+// SessionID and AuthTime identify the session of the user who approved the request and when it was created.
+type AuthorizationCode struct {
+	CodeHash      string
+	ClientID      string
+	UserID        string
+	RedirectURI   string
+	Scopes        []string
+	CodeChallenge string
+	Nonce         string
+	SessionID     string
+	AuthTime      time.Time
+	CreatedAt     time.Time
+	ExpiresAt     time.Time
+}
+
+// NewAuthorizationCode creates a new AuthorizationCode for an approved authorization request.
+func NewAuthorizationCode(codeHash, userID string, req AuthorizeRequest, scopes []string, validFor time.Duration) AuthorizationCode {
+	createdAt := now()
+	return AuthorizationCode{
+		CodeHash:      codeHash,
+		ClientID:      req.ClientID,
+		UserID:        userID,
+		RedirectURI:   req.RedirectURI,
+		Scopes:        scopes,
+		CodeChallenge: req.CodeChallenge,
+		Nonce:         req.Nonce,
+		CreatedAt:     createdAt,
+		ExpiresAt:     createdAt.Add(validFor),
+	}
+}
+
+// IsExpired checks if the code can no longer be exchanged.
+func (c AuthorizationCode) IsExpired() bool {
+	return !now().Before(c.ExpiresAt)
+}
+
+// RefreshToken long lived token used by a client to get new access tokens.
+// Tokens are only stored as hashes and are rotated on every use. SessionID and
+// AuthTime are carried over from the authorization code the first token was issued for.
+type RefreshToken struct {
+	TokenHash string
+	ClientID  string
+	UserID    string
+	Scopes    []string
+	SessionID string
+	AuthTime  time.Time
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	RevokedAt time.Time
+}
+
+// NewRefreshToken creates a new RefreshToken.
+func NewRefreshToken(tokenHash, clientID, userID string, scopes []string, validFor time.Duration) RefreshToken {
+	createdAt := now()
+	return RefreshToken{
+		TokenHash: tokenHash,
+		ClientID:  clientID,
+		UserID:    userID,
+		Scopes:    scopes,
+		CreatedAt: createdAt,
+		ExpiresAt: createdAt.Add(validFor),
+	}
+}
+
+// IsValid checks if the token has neither been revoked nor expired.
+func (t RefreshToken) IsValid() bool {
+	return t.RevokedAt.IsZero() && now().Before(t.ExpiresAt)
+}
+
+// AllowsScopes checks if every one of a list of scopes was granted with the token.
+func (t RefreshToken) AllowsScopes(scopes []string) bool {
+	return containsAll(t.Scopes, scopes)
+}
+
+// TokenRequest parameters of a request to the OAuth token endpoint.
+// Client credentials may be sent either as form parameters or with basic auth.
+type TokenRequest struct {
+	GrantType    string `form:"grant_type"`
+	Code         string `form:"code"`
+	RedirectURI  string `form:"redirect_uri"`
+	CodeVerifier string `form:"code_verifier"`
+	RefreshToken string `form:"refresh_token"`
+	Scope        string `form:"scope"`
+	ClientID     string `form:"client_id"`
+	ClientSecret string `form:"client_secret"`
+}
+
+// TokenResponse successful response of the OAuth token endpoint as given in RFC 6749.
+// IDToken is only set if the client has been granted the openid scope.
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+	IDToken      string `json:"id_token,omitempty"`
+}
+
+// ParseScope splits a space delimited OAuth scope parameter into its scopes.
+func ParseScope(scope string) []string {
+	return strings.Fields(scope)
+}
+
+// FormatScope joins scopes into a space delimited OAuth scope parameter.
+func FormatScope(scopes []string) string {
+	return strings.Join(scopes, " ")
+}
+
+// validRedirectURI checks that a uri is safe to redirect users to with an authorization response.
+// Private-use schemes must be in reverse domain notation, such as com.example.app, which rules out
+// schemes like javascript and data. They are only allowed for native clients.
+func validRedirectURI(uri string, native bool) bool {
+	if len(uri) > MaxRedirectURILength || strings.Contains(uri, "#") {
+		return false
+	}
+
+	u, err := url.Parse(uri)
+	if err != nil || !u.IsAbs() {
+		return false
+	}
+
+	switch u.Scheme {
+	case "https":
+		return u.Host != ""
+	case "http":
+		return isLoopback(u.Hostname())
+	default:
+		return native && strings.Contains(u.Scheme, ".")
+	}
+}
+
+// isLoopback checks if a host refers to the local machine, where http is allowed as traffic never leaves it.
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func containsAll(values, subset []string) bool {
+	for _, value := range subset {
+		if !containsString(values, value) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
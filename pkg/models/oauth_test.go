@@ -0,0 +1,230 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOAuthClient(t *testing.T) {
+	assert := assert.New(t)
+
+	req := RegisterClientRequest{
+		Name:         "Mobile app",
+		Type:         PublicClient,
+		RedirectURIs: []string{"com.example.app:/callback"},
+		Scopes:       []string{"openid", ReadUsersPermission},
+	}
+	client := req.Client("", "")
+	assert.NotEmpty(client.ID)
+	assert.False(client.IsConfidential())
+	assert.True(client.HasRedirectURI("com.example.app:/callback"))
+	assert.False(client.HasRedirectURI("com.example.app:/callback/other"))
+	assert.True(client.AllowsScopes([]string{"openid"}))
+	assert.True(client.AllowsScopes(nil))
+	assert.False(client.AllowsScopes([]string{"openid", WriteUsersPermission}))
+}
+
+func TestRegisterClientRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     RegisterClientRequest
+		wantErr bool
+	}{
+		{
+			name: "happy-path",
+			req: RegisterClientRequest{
+				Name:         "Web app",
+				Type:         ConfidentialClient,
+				RedirectURIs: []string{"https://app.example.com/callback"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "happy-path-loopback-http",
+			req: RegisterClientRequest{
+				Name:         "Web app",
+				Type:         ConfidentialClient,
+				RedirectURIs: []string{"http://127.0.0.1:8080/callback", "http://localhost/callback"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "happy-path-native-app",
+			req: RegisterClientRequest{
+				Name:         "Web app",
+				Type:         PublicClient,
+				RedirectURIs: []string{"com.example.app:/callback"},
+				Scopes:       []string{"openid", "profile", "email", ReadUsersPermission},
+			},
+			wantErr: false,
+		},
+		{
+			name: "sad-path-custom-scheme-confidential-client",
+			req: RegisterClientRequest{
+				Name:         "Web app",
+				Type:         ConfidentialClient,
+				RedirectURIs: []string{"com.example.app:/callback"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "sad-path-javascript-redirect-uri",
+			req: RegisterClientRequest{
+				Name:         "Web app",
+				Type:         PublicClient,
+				RedirectURIs: []string{"javascript:alert(document.cookie)"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "sad-path-data-redirect-uri",
+			req: RegisterClientRequest{
+				Name:         "Web app",
+				Type:         PublicClient,
+				RedirectURIs: []string{"data:text/html,<script>alert(1)</script>"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "sad-path-http-redirect-uri",
+			req: RegisterClientRequest{
+				Name:         "Web app",
+				Type:         ConfidentialClient,
+				RedirectURIs: []string{"http://app.example.com/callback"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "sad-path-javascript-post-logout-redirect-uri",
+			req: RegisterClientRequest{
+				Name:                   "Web app",
+				Type:                   ConfidentialClient,
+				RedirectURIs:           []string{"https://app.example.com/callback"},
+				PostLogoutRedirectURIs: []string{"javascript:alert(1)"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "sad-path-unknown-scope",
+			req: RegisterClientRequest{
+				Name:         "Web app",
+				Type:         ConfidentialClient,
+				RedirectURIs: []string{"https://app.example.com/callback"},
+				Scopes:       []string{"openid", "users:delete"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "sad-path-unknown-type",
+			req: RegisterClientRequest{
+				Name:         "Web app",
+				Type:         "trusted",
+				RedirectURIs: []string{"https://app.example.com/callback"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "sad-path-no-redirect-uris",
+			req: RegisterClientRequest{
+				Name: "Web app",
+				Type: ConfidentialClient,
+			},
+			wantErr: true,
+		},
+		{
+			name: "sad-path-relative-redirect-uri",
+			req: RegisterClientRequest{
+				Name:         "Web app",
+				Type:         ConfidentialClient,
+				RedirectURIs: []string{"/callback"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "sad-path-redirect-uri-with-fragment",
+			req: RegisterClientRequest{
+				Name:         "Web app",
+				Type:         ConfidentialClient,
+				RedirectURIs: []string{"https://app.example.com/callback#token"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "sad-path-missing-name",
+			req: RegisterClientRequest{
+				Type:         PublicClient,
+				RedirectURIs: []string{"https://app.example.com/callback"},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Validate()
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestAuthorizationCodeAndRefreshToken(t *testing.T) {
+	assert := assert.New(t)
+
+	req := AuthorizeRequest{ClientID: "client-id", RedirectURI: "https://app.example.com/callback", CodeChallenge: "challenge"}
+	code := NewAuthorizationCode("code-hash", "user-id", req, []string{"openid"}, time.Minute)
+	assert.Equal("client-id", code.ClientID)
+	assert.Equal("https://app.example.com/callback", code.RedirectURI)
+	assert.Equal("challenge", code.CodeChallenge)
+	assert.False(code.IsExpired())
+	assert.True(NewAuthorizationCode("code-hash", "user-id", req, nil, -time.Minute).IsExpired())
+
+	token := NewRefreshToken("token-hash", "client-id", "user-id", []string{"openid"}, time.Hour)
+	assert.True(token.IsValid())
+	assert.True(token.AllowsScopes([]string{"openid"}))
+	assert.False(token.AllowsScopes([]string{"openid", ReadUsersPermission}))
+	token.RevokedAt = now()
+	assert.False(token.IsValid())
+	assert.False(NewRefreshToken("token-hash", "client-id", "user-id", nil, -time.Hour).IsValid())
+}
+
+func TestScope(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal([]string{"openid", "users:read"}, ParseScope(" openid  users:read "))
+	assert.Empty(ParseScope(""))
+	assert.Equal("openid users:read", FormatScope([]string{"openid", "users:read"}))
+}
+
+func TestNewUserInfo(t *testing.T) {
+	assert := assert.New(t)
+	user := NewUser("mail@mail.com", "Tester", "Mc Test", UserRole, Credentials{})
+
+	assert.Equal(UserInfo{Subject: user.ID}, NewUserInfo(user, []string{OpenIDScope}))
+	assert.Equal(UserInfo{
+		Subject: user.ID,
+		Email:   "mail@mail.com",
+		Name:    "Tester Mc Test",
+	}, NewUserInfo(user, []string{OpenIDScope, EmailScope, ProfileScope}))
+
+	user.MiddleAndLastName = ""
+	assert.Equal("Tester", user.FullName())
+}
+
+func TestServiceAccount(t *testing.T) {
+	assert := assert.New(t)
+
+	req := CreateServiceAccountRequest{
+		Name:        "Nightly export",
+		Permissions: []string{ReadUsersPermission, ReadAuditLogPermission},
+	}
+	assert.NoError(req.Validate())
+	assert.Error(CreateServiceAccountRequest{Name: "Nightly export"}.Validate())
+	assert.Error(CreateServiceAccountRequest{Permissions: req.Permissions}.Validate())
+
+	account := req.ServiceAccount("admin-id", "hash", "salt")
+	assert.NotEmpty(account.ID)
+	assert.Equal("admin-id", account.CreatedBy)
+	assert.True(account.AllowsScopes([]string{ReadUsersPermission}))
+	assert.True(account.AllowsScopes(nil))
+	assert.False(account.AllowsScopes([]string{WriteUsersPermission}))
+}
@@ -0,0 +1,62 @@
+package models
+
+import "strings"
+
+// OpenID Connect scopes. Clients granted the openid scope get an ID token, while the profile
+// and email scopes give access to the name and email claims of the user.
+const (
+	OpenIDScope  = "openid"
+	ProfileScope = "profile"
+	EmailScope   = "email"
+)
+
+// UserInfo claims about a user returned by the OpenID Connect userinfo endpoint.
+type UserInfo struct {
+	Subject string `json:"sub"`
+	Email   string `json:"email,omitempty"`
+	Name    string `json:"name,omitempty"`
+}
+
+// NewUserInfo creates the UserInfo of a user, with the claims allowed by a list of granted scopes.
+func NewUserInfo(user User, scopes []string) UserInfo {
+	info := UserInfo{Subject: user.ID}
+	if containsString(scopes, EmailScope) {
+		info.Email = user.Email
+	}
+	if containsString(scopes, ProfileScope) {
+		info.Name = user.FullName()
+	}
+	return info
+}
+
+// FullName returns the full name of a user.
+func (u User) FullName() string {
+	return strings.TrimSpace(u.Surname + " " + u.MiddleAndLastName)
+}
+
+// EndSessionRequest parameters of an OpenID Connect RP-initiated logout request.
+type EndSessionRequest struct {
+	IDTokenHint           string `form:"id_token_hint"`
+	ClientID              string `form:"client_id"`
+	PostLogoutRedirectURI string `form:"post_logout_redirect_uri"`
+	State                 string `form:"state"`
+}
+
+// ProviderMetadata OpenID Connect discovery document describing the provider.
+type ProviderMetadata struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	UserinfoEndpoint                  string   `json:"userinfo_endpoint"`
+	JWKSURI                           string   `json:"jwks_uri"`
+	EndSessionEndpoint                string   `json:"end_session_endpoint"`
+	IntrospectionEndpoint             string   `json:"introspection_endpoint"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+	ClaimsSupported                   []string `json:"claims_supported"`
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+
+	"github.com/CzarSimon/user-service/pkg/id"
+)
+
+// ServiceAccount non-human principal, such as a backend job, which gets tokens on its own behalf
+// with the OAuth client credentials grant. Permissions are granted to the account directly rather
+// than through roles, and tokens may be scoped down to a subset of them. Secrets are only stored as hashes.
+type ServiceAccount struct {
+	ID          string    `json:"clientId"`
+	Name        string    `json:"name"`
+	Permissions []string  `json:"permissions"`
+	SecretHash  string    `json:"-"`
+	Salt        string    `json:"-"`
+	CreatedBy   string    `json:"createdBy"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// AllowsScopes checks if every one of a list of scopes is a permission of the account.
+func (a ServiceAccount) AllowsScopes(scopes []string) bool {
+	return containsAll(a.Permissions, scopes)
+}
+
+// CreateServiceAccountRequest request body for creating a service account.
+type CreateServiceAccountRequest struct {
+	Name        string   `json:"name,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
+}
+
+// Validate checks that the request contains all required fields within their limits.
+func (r CreateServiceAccountRequest) Validate() error {
+	var v validator
+	v.required("name", r.Name, MaxClientNameLength)
+	if len(r.Permissions) == 0 {
+		v.add("permissions", "validation.required", "is required")
+	}
+	return v.err()
+}
+
+// ServiceAccount creates a new service account from a creation request.
+func (r CreateServiceAccountRequest) ServiceAccount(createdBy, secretHash, salt string) ServiceAccount {
+	return ServiceAccount{
+		ID:          id.New(),
+		Name:        r.Name,
+		Permissions: r.Permissions,
+		SecretHash:  secretHash,
+		Salt:        salt,
+		CreatedBy:   createdBy,
+		CreatedAt:   now(),
+	}
+}
+
+// ServiceAccountCredentials response to the creation of a service account.
+// The client secret is only returned once, when the account is created.
+type ServiceAccountCredentials struct {
+	Account      ServiceAccount `json:"account"`
+	ClientSecret string         `json:"clientSecret"`
+}
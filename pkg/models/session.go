@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// Session record of a login, identified by the id of the issued token.
+type Session struct {
+	ID         string    `json:"id"`
+	UserID     string    `json:"userId"`
+	IP         string    `json:"ip,omitempty"`
+	UserAgent  string    `json:"userAgent,omitempty"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastSeenAt time.Time `json:"lastSeenAt"`
+	RevokedAt  time.Time `json:"revokedAt,omitempty"`
+	Current    bool      `json:"current"`
+}
+
+// NewSession creates a new Session for an issued token.
+func NewSession(tokenID, userID string, client ClientInfo) Session {
+	createdAt := now()
+	return Session{
+		ID:         tokenID,
+		UserID:     userID,
+		IP:         client.IP,
+		UserAgent:  client.UserAgent,
+		CreatedAt:  createdAt,
+		LastSeenAt: createdAt,
+	}
+}
+
+// IsRevoked checks if the session has been revoked.
+func (s Session) IsRevoked() bool {
+	return !s.RevokedAt.IsZero()
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"sort"
 	"time"
 
 	"github.com/CzarSimon/user-service/pkg/id"
@@ -11,16 +12,80 @@ const (
 	AnonymousRole = "ANONYMOUS"
 	UserRole      = "USER"
 	AdminRole     = "ADMIN"
+	ServiceRole   = "SERVICE"
+	ClientRole    = "CLIENT"
 )
 
-// User holds data about an application user.
+// Permission constants.
+const (
+	ReadUsersPermission            = "users:read"
+	WriteUsersPermission           = "users:write"
+	AssignRolesPermission          = "roles:assign"
+	ReadAuditLogPermission         = "audit:read"
+	WriteClientsPermission         = "clients:write"
+	WriteServiceAccountsPermission = "serviceaccounts:write"
+	IntrospectTokensPermission     = "tokens:introspect"
+)
+
+// Role named set of permissions that can be held by users.
+type Role struct {
+	Name        string   `json:"name,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
+}
+
+// DefaultRoles returns the roles that are known to the user service by default.
+func DefaultRoles() []Role {
+	return []Role{
+		{Name: AnonymousRole, Permissions: []string{}},
+		{Name: UserRole, Permissions: []string{}},
+		{
+			Name: AdminRole,
+			Permissions: []string{
+				ReadUsersPermission,
+				WriteUsersPermission,
+				AssignRolesPermission,
+				ReadAuditLogPermission,
+				WriteClientsPermission,
+				WriteServiceAccountsPermission,
+				IntrospectTokensPermission,
+			},
+		},
+	}
+}
+
+// Permissions returns the unique set of permissions granted by a list of roles.
+func Permissions(roles []Role) []string {
+	seen := make(map[string]bool)
+	permissions := make([]string, 0)
+	for _, role := range roles {
+		for _, permission := range role.Permissions {
+			if seen[permission] {
+				continue
+			}
+			seen[permission] = true
+			permissions = append(permissions, permission)
+		}
+	}
+
+	sort.Strings(permissions)
+	return permissions
+}
+
+// User holds data about an application user. Role is the primary role of the user
+// which is included in issued tokens, while Roles lists every role held by the user.
+// DeletedAt is only set once the user has been scheduled for deletion.
 type User struct {
 	ID                string      `json:"id,omitempty"`
 	Email             string      `json:"email,omitempty"`
 	Surname           string      `json:"surname,omitempty"`
 	MiddleAndLastName string      `json:"middleAndLastName,omitempty"`
 	Role              string      `json:"role,omitempty"`
+	Roles             []string    `json:"roles,omitempty"`
+	Disabled          bool        `json:"disabled"`
+	Version           int         `json:"version"`
 	CreatedAt         time.Time   `json:"createdAt,omitempty"`
+	UpdatedAt         time.Time   `json:"updatedAt,omitempty"`
+	DeletedAt         *time.Time  `json:"deletedAt,omitempty"`
 	Credentials       Credentials `json:"-"`
 }
 
@@ -32,17 +97,40 @@ func NewUser(email, surname, lastName, role string, credentials Credentials) Use
 		credentials.UserID = userID
 	}
 
+	createdAt := now()
 	return User{
 		ID:                userID,
 		Email:             email,
 		Surname:           surname,
 		MiddleAndLastName: lastName,
 		Role:              role,
-		CreatedAt:         now(),
+		Roles:             []string{role},
+		Version:           1,
+		CreatedAt:         createdAt,
+		UpdatedAt:         createdAt,
 		Credentials:       credentials,
 	}
 }
 
+// HasRole checks if the user holds a given role.
+func (u User) HasRole(role string) bool {
+	if u.Role == role {
+		return true
+	}
+
+	for _, r := range u.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// IsDeleted checks if the user has been scheduled for deletion.
+func (u User) IsDeleted() bool {
+	return u.DeletedAt != nil
+}
+
 // SignupRequest request body for a user signup.
 type SignupRequest struct {
 	Email             string `json:"email,omitempty"`
@@ -52,6 +140,17 @@ type SignupRequest struct {
 	MiddleAndLastName string `json:"middleAndLastName,omitempty"`
 }
 
+// Validate checks that the request contains all required fields within their limits.
+func (s SignupRequest) Validate() error {
+	var v validator
+	v.required("email", s.Email, MaxEmailLength)
+	v.required("password", s.Password, MaxPasswordLength)
+	v.maxLength("repeatPassword", s.RepeatPassword, MaxPasswordLength)
+	v.required("surname", s.Surname, MaxNameLength)
+	v.required("middleAndLastName", s.MiddleAndLastName, MaxNameLength)
+	return v.err()
+}
+
 // User creates a new user from a signup request.
 func (s *SignupRequest) User(credentials Credentials) User {
 	return NewUser(s.Email, s.Surname, s.MiddleAndLastName, UserRole, credentials)
@@ -59,8 +158,23 @@ func (s *SignupRequest) User(credentials Credentials) User {
 
 // LoginRequest request body for a user login.
 type LoginRequest struct {
-	Email    string `json:"email,omitempty"`
-	Password string `json:"password,omitempty"`
+	Email    string     `json:"email,omitempty"`
+	Password string     `json:"password,omitempty"`
+	Client   ClientInfo `json:"-"`
+}
+
+// Validate checks that the request contains all required fields within their limits.
+func (r LoginRequest) Validate() error {
+	var v validator
+	v.required("email", r.Email, MaxEmailLength)
+	v.required("password", r.Password, MaxPasswordLength)
+	return v.err()
+}
+
+// ClientInfo information about the client making a request.
+type ClientInfo struct {
+	IP        string `json:"ip,omitempty"`
+	UserAgent string `json:"userAgent,omitempty"`
 }
 
 // ChangePasswordRequest request body for a request to change password.
@@ -71,6 +185,38 @@ type ChangePasswordRequest struct {
 	RepeatPassword string `json:"repeatPassword,omitempty"`
 }
 
+// Validate checks that the request contains all required fields within their limits.
+func (r ChangePasswordRequest) Validate() error {
+	var v validator
+	v.required("oldPassword", r.OldPassword, MaxPasswordLength)
+	v.required("newPassword", r.NewPassword, MaxPasswordLength)
+	v.maxLength("repeatPassword", r.RepeatPassword, MaxPasswordLength)
+	return v.err()
+}
+
+// UpdateProfileRequest request body for a partial update of a users profile.
+// Fields that are nil are left unchanged. Version must match the current version of the user.
+type UpdateProfileRequest struct {
+	UserID            string  `json:"userId,omitempty"`
+	Surname           *string `json:"surname,omitempty"`
+	MiddleAndLastName *string `json:"middleAndLastName,omitempty"`
+	Version           int     `json:"version,omitempty"`
+}
+
+// Apply applies the changes in the request to a user and bumps its version.
+func (r UpdateProfileRequest) Apply(user User) User {
+	if r.Surname != nil {
+		user.Surname = *r.Surname
+	}
+	if r.MiddleAndLastName != nil {
+		user.MiddleAndLastName = *r.MiddleAndLastName
+	}
+
+	user.Version++
+	user.UpdatedAt = now()
+	return user
+}
+
 // LoginResponse response for login and signup requests.
 type LoginResponse struct {
 	Token        string `json:"token,omitempty"`
@@ -1,7 +1,9 @@
 package models
 
 import (
+	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/CzarSimon/user-service/pkg/id"
 	"github.com/stretchr/testify/assert"
@@ -42,3 +44,74 @@ func TestNewUser(t *testing.T) {
 	assert.NotEqual(preUserID, user.ID)
 	assert.NotEqual("", user.ID)
 }
+
+func TestUserHasRole(t *testing.T) {
+	assert := assert.New(t)
+
+	user := NewUser("mail@mail.com", "fname", "lname", UserRole, Credentials{})
+	assert.True(user.HasRole(UserRole))
+	assert.False(user.HasRole(AdminRole))
+
+	user.Roles = append(user.Roles, AdminRole)
+	assert.True(user.HasRole(UserRole))
+	assert.True(user.HasRole(AdminRole))
+}
+
+func TestUserIsDeleted(t *testing.T) {
+	assert := assert.New(t)
+
+	user := NewUser("mail@mail.com", "fname", "lname", UserRole, Credentials{})
+	assert.False(user.IsDeleted())
+	body, err := json.Marshal(user)
+	assert.NoError(err)
+	assert.NotContains(string(body), "deletedAt")
+
+	deletedAt := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	user.DeletedAt = &deletedAt
+	assert.True(user.IsDeleted())
+	body, err = json.Marshal(user)
+	assert.NoError(err)
+	assert.Contains(string(body), `"deletedAt":"2020-01-02T03:04:05Z"`)
+}
+
+func TestPermissions(t *testing.T) {
+	assert := assert.New(t)
+
+	roles := []Role{
+		{Name: "READER", Permissions: []string{ReadUsersPermission}},
+		{Name: "WRITER", Permissions: []string{WriteUsersPermission, ReadUsersPermission}},
+	}
+	assert.Equal([]string{ReadUsersPermission, WriteUsersPermission}, Permissions(roles))
+	assert.Equal([]string{}, Permissions(nil))
+	assert.Equal([]string{
+		ReadAuditLogPermission,
+		WriteClientsPermission,
+		AssignRolesPermission,
+		WriteServiceAccountsPermission,
+		IntrospectTokensPermission,
+		ReadUsersPermission,
+		WriteUsersPermission,
+	}, Permissions(DefaultRoles()))
+}
+
+func TestUpdateProfileRequestApply(t *testing.T) {
+	assert := assert.New(t)
+
+	user := NewUser("mail@mail.com", "fname", "lname", UserRole, Credentials{})
+	assert.Equal(1, user.Version)
+	assert.Equal(user.CreatedAt, user.UpdatedAt)
+
+	surname := "new-fname"
+	req := UpdateProfileRequest{
+		UserID:  user.ID,
+		Surname: &surname,
+		Version: user.Version,
+	}
+	updated := req.Apply(user)
+	assert.Equal("new-fname", updated.Surname)
+	assert.Equal("lname", updated.MiddleAndLastName)
+	assert.Equal(2, updated.Version)
+	assert.True(updated.UpdatedAt.After(user.UpdatedAt) || updated.UpdatedAt.Equal(user.UpdatedAt))
+	assert.Equal(user.CreatedAt, updated.CreatedAt)
+	assert.Equal("fname", user.Surname)
+}
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Request field limits.
+const (
+	MaxEmailLength    = 254
+	MaxPasswordLength = 256
+	MaxNameLength     = 100
+)
+
+// FieldError description of why a request field is invalid.
+// MessageKey and MessageArgs are used to translate the message to the language of the client.
+type FieldError struct {
+	Field       string        `json:"field"`
+	Message     string        `json:"message"`
+	MessageKey  string        `json:"-"`
+	MessageArgs []interface{} `json:"-"`
+}
+
+// ValidationError error describing every invalid field of a request.
+type ValidationError struct {
+	Errors []FieldError
+}
+
+// Error returns a string representation of the ValidationError.
+func (e *ValidationError) Error() string {
+	fields := make([]string, 0, len(e.Errors))
+	for _, fieldErr := range e.Errors {
+		fields = append(fields, fieldErr.Field+" "+fieldErr.Message)
+	}
+
+	return "validation failed: " + strings.Join(fields, ", ")
+}
+
+// validator collects field errors of a request.
+type validator struct {
+	errors []FieldError
+}
+
+// add adds a field error with a message key and a message formatted from args.
+func (v *validator) add(field, key, format string, args ...interface{}) {
+	v.errors = append(v.errors, FieldError{
+		Field:       field,
+		Message:     fmt.Sprintf(format, args...),
+		MessageKey:  key,
+		MessageArgs: args,
+	})
+}
+
+// required checks that a field is not blank and not longer than maxLength runes.
+func (v *validator) required(field, value string, maxLength int) {
+	if strings.TrimSpace(value) == "" {
+		v.add(field, "validation.required", "is required")
+		return
+	}
+
+	v.maxLength(field, value, maxLength)
+}
+
+func (v *validator) maxLength(field, value string, maxLength int) {
+	if utf8.RuneCountInString(value) > maxLength {
+		v.add(field, "validation.maxLength", "must be at most %d characters", maxLength)
+	}
+}
+
+// err returns a ValidationError if any field errors have been collected, otherwise nil.
+func (v *validator) err() error {
+	if len(v.errors) == 0 {
+		return nil
+	}
+
+	return &ValidationError{Errors: v.errors}
+}
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignupRequest_Validate(t *testing.T) {
+	assert := assert.New(t)
+
+	req := SignupRequest{
+		Email:             "mail@mail.com",
+		Password:          "secret-drowssap",
+		RepeatPassword:    "secret-drowssap",
+		Surname:           "Tester",
+		MiddleAndLastName: "McTest",
+	}
+	assert.NoError(req.Validate())
+
+	err := SignupRequest{
+		Email:             "  ",
+		Password:          strings.Repeat("a", MaxPasswordLength+1),
+		MiddleAndLastName: strings.Repeat("ö", MaxNameLength),
+	}.Validate()
+	verr, ok := err.(*ValidationError)
+	assert.True(ok)
+	assert.Equal([]FieldError{
+		{Field: "email", Message: "is required", MessageKey: "validation.required"},
+		{Field: "password", Message: "must be at most 256 characters", MessageKey: "validation.maxLength", MessageArgs: []interface{}{256}},
+		{Field: "surname", Message: "is required", MessageKey: "validation.required"},
+	}, verr.Errors)
+	assert.Equal("validation failed: email is required, password must be at most 256 characters, surname is required", err.Error())
+}
+
+func TestLoginRequest_Validate(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.NoError(LoginRequest{Email: "mail@mail.com", Password: "secret"}.Validate())
+
+	err := LoginRequest{Email: strings.Repeat("a", MaxEmailLength+1)}.Validate()
+	verr, ok := err.(*ValidationError)
+	assert.True(ok)
+	assert.Len(verr.Errors, 2)
+	assert.Equal("email", verr.Errors[0].Field)
+	assert.Equal("password", verr.Errors[1].Field)
+}
+
+func TestChangePasswordRequest_Validate(t *testing.T) {
+	assert := assert.New(t)
+
+	req := ChangePasswordRequest{
+		OldPassword:    "old-secret",
+		NewPassword:    "new-secret",
+		RepeatPassword: "new-secret",
+	}
+	assert.NoError(req.Validate())
+
+	err := ChangePasswordRequest{RepeatPassword: strings.Repeat("a", MaxPasswordLength+1)}.Validate()
+	verr, ok := err.(*ValidationError)
+	assert.True(ok)
+	assert.Equal([]FieldError{
+		{Field: "oldPassword", Message: "is required", MessageKey: "validation.required"},
+		{Field: "newPassword", Message: "is required", MessageKey: "validation.required"},
+		{Field: "repeatPassword", Message: "must be at most 256 characters", MessageKey: "validation.maxLength", MessageArgs: []interface{}{256}},
+	}, verr.Errors)
+}
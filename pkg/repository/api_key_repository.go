@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/CzarSimon/user-service/pkg/models"
+)
+
+// Common API key errors
+var (
+	ErrNoSuchAPIKey = errors.New("no such api key")
+	ErrAPIKeyExists = errors.New("api key already exists")
+)
+
+// APIKeyRepository storage of API keys.
+type APIKeyRepository interface {
+	// Save stores a new key. Returns ErrAPIKeyExists if the prefix is taken, as prefixes must be unique.
+	Save(ctx context.Context, key models.APIKey) error
+	// Find finds a key by id, including revoked keys. Returns ErrNoSuchAPIKey if not found.
+	Find(ctx context.Context, id string) (models.APIKey, error)
+	// FindByPrefix finds a key by its prefix, including revoked keys. Returns ErrNoSuchAPIKey if not found.
+	FindByPrefix(ctx context.Context, prefix string) (models.APIKey, error)
+	// FindByUserID returns the keys of a user which have not been revoked.
+	FindByUserID(ctx context.Context, userID string) ([]models.APIKey, error)
+	UpdateLastUsed(ctx context.Context, id string, lastUsedAt time.Time) error
+	// Revoke marks a key as revoked. Returns ErrNoSuchAPIKey if not found.
+	Revoke(ctx context.Context, id string, revokedAt time.Time) error
+	// RevokeByUserID marks every key of a user which has not been revoked as revoked.
+	RevokeByUserID(ctx context.Context, userID string, revokedAt time.Time) error
+	DeleteByUserID(ctx context.Context, userID string) error
+}
@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/CzarSimon/user-service/pkg/models"
+)
+
+// AuditTableSchema schema of the table used by the SQLAuditLog.
+const AuditTableSchema = `CREATE TABLE IF NOT EXISTS audit_event (
+  id VARCHAR(50) NOT NULL PRIMARY KEY,
+  type VARCHAR(50) NOT NULL,
+  actor_id VARCHAR(50) NOT NULL,
+  subject_id VARCHAR(50) NOT NULL,
+  ip VARCHAR(45) NOT NULL,
+  user_agent VARCHAR(512) NOT NULL,
+  request_id VARCHAR(100) NOT NULL,
+  outcome VARCHAR(20) NOT NULL,
+  reason VARCHAR(50) NOT NULL,
+  created_at TIMESTAMP NOT NULL
+)`
+
+const auditColumns = "id, type, actor_id, subject_id, ip, user_agent, request_id, outcome, reason, created_at"
+
+// Widths of the varchar columns of the audit_event table, see AuditTableSchema.
+const (
+	auditIDWidth        = 50
+	auditTypeWidth      = 50
+	auditIPWidth        = 45
+	auditUserAgentWidth = 512
+	auditRequestIDWidth = 100
+	auditOutcomeWidth   = 20
+	auditReasonWidth    = 50
+)
+
+// SQLAuditLog audit log stored in the audit_event table of a SQL database, see AuditTableSchema.
+// Events are only ever inserted. Queries use ? placeholders.
+type SQLAuditLog struct {
+	db *sql.DB
+}
+
+// NewSQLAuditLog creates an audit log stored in a SQL database.
+func NewSQLAuditLog(db *sql.DB) *SQLAuditLog {
+	return &SQLAuditLog{
+		db: db,
+	}
+}
+
+// Write inserts an event into the audit_event table.
+func (l *SQLAuditLog) Write(ctx context.Context, event models.AuditEvent) error {
+	query := "INSERT INTO audit_event(" + auditColumns + ") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	_, err := l.db.ExecContext(ctx, query, auditEventArgs(event)...)
+	return err
+}
+
+// auditEventArgs returns the values of an event to insert into the columns of the audit_event table.
+// Values are truncated to the widths of their columns, as some, like the user agent, are supplied by clients
+// and an event should not be lost because a value is too long.
+func auditEventArgs(event models.AuditEvent) []interface{} {
+	return []interface{}{
+		truncate(event.ID, auditIDWidth),
+		truncate(event.Type, auditTypeWidth),
+		truncate(event.ActorID, auditIDWidth),
+		truncate(event.SubjectID, auditIDWidth),
+		truncate(event.IP, auditIPWidth),
+		truncate(event.UserAgent, auditUserAgentWidth),
+		truncate(event.RequestID, auditRequestIDWidth),
+		truncate(event.Outcome, auditOutcomeWidth),
+		truncate(event.Reason, auditReasonWidth),
+		event.CreatedAt,
+	}
+}
+
+// truncate shortens a value to at most width characters.
+func truncate(value string, width int) string {
+	if utf8.RuneCountInString(value) <= width {
+		return value
+	}
+	return string([]rune(value)[:width])
+}
+
+// Query returns the events matching the query, newest first.
+func (l *SQLAuditLog) Query(ctx context.Context, query models.AuditQuery) ([]models.AuditEvent, error) {
+	stmt, args := auditQuerySQL(query)
+	rows, err := l.db.QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := make([]models.AuditEvent, 0)
+	for rows.Next() {
+		var e models.AuditEvent
+		err = rows.Scan(&e.ID, &e.Type, &e.ActorID, &e.SubjectID, &e.IP, &e.UserAgent, &e.RequestID, &e.Outcome, &e.Reason, &e.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+
+	return events, rows.Err()
+}
+
+// auditQuerySQL builds the select statement and arguments of an audit query.
+func auditQuerySQL(query models.AuditQuery) (string, []interface{}) {
+	conditions := make([]string, 0)
+	args := make([]interface{}, 0)
+	addCondition := func(condition string, arg interface{}) {
+		conditions = append(conditions, condition)
+		args = append(args, arg)
+	}
+
+	if query.Type != "" {
+		addCondition("type = ?", query.Type)
+	}
+	if query.ActorID != "" {
+		addCondition("actor_id = ?", query.ActorID)
+	}
+	if query.SubjectID != "" {
+		addCondition("subject_id = ?", query.SubjectID)
+	}
+	if query.Outcome != "" {
+		addCondition("outcome = ?", query.Outcome)
+	}
+	if !query.After.IsZero() {
+		addCondition("created_at > ?", query.After)
+	}
+	if !query.Before.IsZero() {
+		addCondition("created_at < ?", query.Before)
+	}
+
+	stmt := "SELECT " + auditColumns + " FROM audit_event"
+	if len(conditions) > 0 {
+		stmt += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	stmt += " ORDER BY created_at DESC, id DESC LIMIT ?"
+	args = append(args, query.Limit)
+
+	return stmt, args
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/CzarSimon/user-service/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_auditQuerySQL(t *testing.T) {
+	after := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		query    models.AuditQuery
+		wantStmt string
+		wantArgs []interface{}
+	}{
+		{
+			name:     "no-filters",
+			query:    models.AuditQuery{Limit: 50},
+			wantStmt: "SELECT " + auditColumns + " FROM audit_event ORDER BY created_at DESC, id DESC LIMIT ?",
+			wantArgs: []interface{}{50},
+		},
+		{
+			name: "all-filters",
+			query: models.AuditQuery{
+				Type:      models.AuditLogin,
+				ActorID:   "user-1",
+				SubjectID: "user-2",
+				Outcome:   models.AuditFailure,
+				After:     after,
+				Before:    after.Add(time.Hour),
+				Limit:     10,
+			},
+			wantStmt: "SELECT " + auditColumns + " FROM audit_event " +
+				"WHERE type = ? AND actor_id = ? AND subject_id = ? AND outcome = ? AND created_at > ? AND created_at < ? " +
+				"ORDER BY created_at DESC, id DESC LIMIT ?",
+			wantArgs: []interface{}{models.AuditLogin, "user-1", "user-2", models.AuditFailure, after, after.Add(time.Hour), 10},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stmt, args := auditQuerySQL(tt.query)
+			assert.Equal(t, tt.wantStmt, stmt)
+			assert.Equal(t, tt.wantArgs, args)
+		})
+	}
+}
+
+func Test_auditEventArgs(t *testing.T) {
+	assert := assert.New(t)
+	event := models.NewAuditEvent(models.AuditLogin, "user-1", "user-1", "")
+	event.IP = "10.0.0.1"
+	event.UserAgent = "Mozilla/5.0 " + strings.Repeat("å", 600)
+	event.RequestID = "request-1"
+
+	args := auditEventArgs(event)
+	assert.Len(args, strings.Count(auditColumns, ",")+1)
+	assert.Equal(event.ID, args[0])
+	assert.Equal("10.0.0.1", args[4])
+	userAgent := args[5].(string)
+	assert.Equal(auditUserAgentWidth, utf8.RuneCountInString(userAgent))
+	assert.True(utf8.ValidString(userAgent))
+	assert.True(strings.HasPrefix(event.UserAgent, userAgent))
+	assert.Equal("request-1", args[6])
+	assert.Equal(event.CreatedAt, args[9])
+}
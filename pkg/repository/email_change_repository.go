@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/CzarSimon/user-service/pkg/models"
+)
+
+// Common email change errors
+var (
+	ErrNoSuchEmailChange = errors.New("no such email change")
+)
+
+// EmailChangeRepository storage of pending and completed email changes.
+type EmailChangeRepository interface {
+	Save(ctx context.Context, change models.EmailChange) error
+	FindByConfirmToken(ctx context.Context, tokenHash string) (models.EmailChange, error)
+	FindByRevertToken(ctx context.Context, tokenHash string) (models.EmailChange, error)
+	Update(ctx context.Context, change models.EmailChange) error
+	DeleteByUserID(ctx context.Context, userID string) error
+}
@@ -5,4 +5,11 @@ go 1.12
 require (
 	github.com/CzarSimon/user-service/pkg/models v0.0.0-20190423193538-97a479935a48
 	github.com/CzarSimon/user-service/pkg/repository/repotest v0.0.0-20190414180801-c727ef98bd13 // indirect
+	github.com/stretchr/testify v1.3.0
+)
+
+replace (
+	github.com/CzarSimon/user-service/pkg/id => ../id
+	github.com/CzarSimon/user-service/pkg/models => ../models
+	github.com/CzarSimon/user-service/pkg/repository/repotest => ./repotest
 )
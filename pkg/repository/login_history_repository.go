@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/CzarSimon/user-service/pkg/models"
+)
+
+// LoginHistoryRepository storage of login attempts.
+type LoginHistoryRepository interface {
+	Save(ctx context.Context, event models.LoginEvent) error
+	FindByUserID(ctx context.Context, userID string) ([]models.LoginEvent, error)
+	DeleteByUserID(ctx context.Context, userID string) error
+}
@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/CzarSimon/user-service/pkg/models"
+)
+
+// Common OAuth errors
+var (
+	ErrNoSuchClient            = errors.New("no such client")
+	ErrNoSuchAuthorizationCode = errors.New("no such authorization code")
+	ErrNoSuchRefreshToken      = errors.New("no such refresh token")
+)
+
+// OAuthClientRepository storage of registered OAuth clients.
+type OAuthClientRepository interface {
+	Save(ctx context.Context, client models.OAuthClient) error
+	// Find finds a client by id. Returns ErrNoSuchClient if not found.
+	Find(ctx context.Context, id string) (models.OAuthClient, error)
+}
+
+// AuthorizationCodeRepository storage of issued authorization codes.
+type AuthorizationCodeRepository interface {
+	Save(ctx context.Context, code models.AuthorizationCode) error
+	// Consume atomically finds and deletes a code by its hash, so that every code can only be
+	// exchanged once. Returns ErrNoSuchAuthorizationCode if not found.
+	Consume(ctx context.Context, codeHash string) (models.AuthorizationCode, error)
+}
+
+// RefreshTokenRepository storage of refresh tokens issued to OAuth clients.
+type RefreshTokenRepository interface {
+	Save(ctx context.Context, token models.RefreshToken) error
+	// Find finds a token by its hash, including revoked tokens. Returns ErrNoSuchRefreshToken if not found.
+	Find(ctx context.Context, tokenHash string) (models.RefreshToken, error)
+	// Consume atomically marks a token as revoked unless it already has been, so that every token
+	// can only be exchanged once. Returns ErrNoSuchRefreshToken if no unrevoked token was found.
+	Consume(ctx context.Context, tokenHash string, revokedAt time.Time) error
+	// RevokeByUserID marks every token issued on behalf of a user which has not been revoked as revoked.
+	RevokeByUserID(ctx context.Context, userID string, revokedAt time.Time) error
+	DeleteByUserID(ctx context.Context, userID string) error
+}
@@ -0,0 +1,123 @@
+package repotest
+
+import (
+	"context"
+	"time"
+
+	"github.com/CzarSimon/user-service/pkg/models"
+)
+
+// MockAPIKeyRepo mock implementation of repository.APIKeyRepository.
+type MockAPIKeyRepo struct {
+	SaveErr         error
+	SaveArg         models.APIKey
+	SaveInvocations int
+
+	FindKey         models.APIKey
+	FindErr         error
+	FindArg         string
+	FindInvocations int
+
+	FindByPrefixKey         models.APIKey
+	FindByPrefixErr         error
+	FindByPrefixArg         string
+	FindByPrefixInvocations int
+
+	FindByUserIDKeys        []models.APIKey
+	FindByUserIDErr         error
+	FindByUserIDArg         string
+	FindByUserIDInvocations int
+
+	UpdateLastUsedErr         error
+	UpdateLastUsedArg         string
+	UpdateLastUsedInvocations int
+
+	RevokeErr         error
+	RevokeArg         string
+	RevokeInvocations int
+
+	RevokeByUserIDErr         error
+	RevokeByUserIDArg         string
+	RevokeByUserIDInvocations int
+
+	DeleteByUserIDErr         error
+	DeleteByUserIDArg         string
+	DeleteByUserIDInvocations int
+}
+
+// Save mock implementation of saving an api key.
+func (kr *MockAPIKeyRepo) Save(ctx context.Context, key models.APIKey) error {
+	kr.SaveArg = key
+	kr.SaveInvocations++
+	return kr.SaveErr
+}
+
+// Find mock implementation of finding an api key by id.
+func (kr *MockAPIKeyRepo) Find(ctx context.Context, id string) (models.APIKey, error) {
+	kr.FindArg = id
+	kr.FindInvocations++
+	return kr.FindKey, kr.FindErr
+}
+
+// FindByPrefix mock implementation of finding an api key by its prefix.
+func (kr *MockAPIKeyRepo) FindByPrefix(ctx context.Context, prefix string) (models.APIKey, error) {
+	kr.FindByPrefixArg = prefix
+	kr.FindByPrefixInvocations++
+	return kr.FindByPrefixKey, kr.FindByPrefixErr
+}
+
+// FindByUserID mock implementation of finding the api keys of a user.
+func (kr *MockAPIKeyRepo) FindByUserID(ctx context.Context, userID string) ([]models.APIKey, error) {
+	kr.FindByUserIDArg = userID
+	kr.FindByUserIDInvocations++
+	return kr.FindByUserIDKeys, kr.FindByUserIDErr
+}
+
+// UpdateLastUsed mock implementation of updating when an api key was last used.
+func (kr *MockAPIKeyRepo) UpdateLastUsed(ctx context.Context, id string, lastUsedAt time.Time) error {
+	kr.UpdateLastUsedArg = id
+	kr.UpdateLastUsedInvocations++
+	return kr.UpdateLastUsedErr
+}
+
+// Revoke mock implementation of revoking an api key.
+func (kr *MockAPIKeyRepo) Revoke(ctx context.Context, id string, revokedAt time.Time) error {
+	kr.RevokeArg = id
+	kr.RevokeInvocations++
+	return kr.RevokeErr
+}
+
+// RevokeByUserID mock implementation of revoking the api keys of a user.
+func (kr *MockAPIKeyRepo) RevokeByUserID(ctx context.Context, userID string, revokedAt time.Time) error {
+	kr.RevokeByUserIDArg = userID
+	kr.RevokeByUserIDInvocations++
+	return kr.RevokeByUserIDErr
+}
+
+// DeleteByUserID mock implementation of deleting the api keys of a user.
+func (kr *MockAPIKeyRepo) DeleteByUserID(ctx context.Context, userID string) error {
+	kr.DeleteByUserIDArg = userID
+	kr.DeleteByUserIDInvocations++
+	return kr.DeleteByUserIDErr
+}
+
+// UnsetArgs unsets all recoreded arguments and invocations.
+func (kr *MockAPIKeyRepo) UnsetArgs() {
+	kr.SaveInvocations = 0
+	kr.FindInvocations = 0
+	kr.FindByPrefixInvocations = 0
+	kr.FindByUserIDInvocations = 0
+	kr.UpdateLastUsedInvocations = 0
+	kr.RevokeInvocations = 0
+	kr.RevokeByUserIDInvocations = 0
+	kr.DeleteByUserIDInvocations = 0
+
+	kr.SaveArg = models.APIKey{}
+	kr.FindArg = ""
+	kr.FindByPrefixArg = ""
+	kr.FindByUserIDArg = ""
+	kr.UpdateLastUsedArg = ""
+	kr.RevokeArg = ""
+	kr.RevokeByUserIDArg = ""
+	kr.DeleteByUserIDArg = ""
+}
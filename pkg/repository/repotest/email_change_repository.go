@@ -0,0 +1,82 @@
+package repotest
+
+import (
+	"context"
+
+	"github.com/CzarSimon/user-service/pkg/models"
+)
+
+// MockEmailChangeRepo mock implementation of repository.EmailChangeRepository.
+type MockEmailChangeRepo struct {
+	SaveErr         error
+	SaveArg         models.EmailChange
+	SaveInvocations int
+
+	FindByConfirmTokenChange      models.EmailChange
+	FindByConfirmTokenErr         error
+	FindByConfirmTokenArg         string
+	FindByConfirmTokenInvocations int
+
+	FindByRevertTokenChange      models.EmailChange
+	FindByRevertTokenErr         error
+	FindByRevertTokenArg         string
+	FindByRevertTokenInvocations int
+
+	UpdateErr         error
+	UpdateArg         models.EmailChange
+	UpdateInvocations int
+
+	DeleteByUserIDErr         error
+	DeleteByUserIDArg         string
+	DeleteByUserIDInvocations int
+}
+
+// Save mock implementation of saving an email change.
+func (er *MockEmailChangeRepo) Save(ctx context.Context, change models.EmailChange) error {
+	er.SaveArg = change
+	er.SaveInvocations++
+	return er.SaveErr
+}
+
+// FindByConfirmToken mock implementation of finding an email change by its confirmation token hash.
+func (er *MockEmailChangeRepo) FindByConfirmToken(ctx context.Context, tokenHash string) (models.EmailChange, error) {
+	er.FindByConfirmTokenArg = tokenHash
+	er.FindByConfirmTokenInvocations++
+	return er.FindByConfirmTokenChange, er.FindByConfirmTokenErr
+}
+
+// FindByRevertToken mock implementation of finding an email change by its revert token hash.
+func (er *MockEmailChangeRepo) FindByRevertToken(ctx context.Context, tokenHash string) (models.EmailChange, error) {
+	er.FindByRevertTokenArg = tokenHash
+	er.FindByRevertTokenInvocations++
+	return er.FindByRevertTokenChange, er.FindByRevertTokenErr
+}
+
+// Update mock implementation of updating an email change.
+func (er *MockEmailChangeRepo) Update(ctx context.Context, change models.EmailChange) error {
+	er.UpdateArg = change
+	er.UpdateInvocations++
+	return er.UpdateErr
+}
+
+// DeleteByUserID mock implementation of deleting the email changes of a user.
+func (er *MockEmailChangeRepo) DeleteByUserID(ctx context.Context, userID string) error {
+	er.DeleteByUserIDArg = userID
+	er.DeleteByUserIDInvocations++
+	return er.DeleteByUserIDErr
+}
+
+// UnsetArgs unsets all recoreded arguments and invocations.
+func (er *MockEmailChangeRepo) UnsetArgs() {
+	er.SaveInvocations = 0
+	er.FindByConfirmTokenInvocations = 0
+	er.FindByRevertTokenInvocations = 0
+	er.UpdateInvocations = 0
+	er.DeleteByUserIDInvocations = 0
+
+	er.SaveArg = models.EmailChange{}
+	er.FindByConfirmTokenArg = ""
+	er.FindByRevertTokenArg = ""
+	er.UpdateArg = models.EmailChange{}
+	er.DeleteByUserIDArg = ""
+}
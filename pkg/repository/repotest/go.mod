@@ -6,3 +6,8 @@ require (
 	github.com/CzarSimon/user-service/pkg/models v0.0.0-20190423193538-97a479935a48
 	github.com/mimir-news/pkg v0.0.0-20190121200947-8a9f96ba3037
 )
+
+replace (
+	github.com/CzarSimon/user-service/pkg/id => ../../id
+	github.com/CzarSimon/user-service/pkg/models => ../../models
+)
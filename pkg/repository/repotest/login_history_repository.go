@@ -0,0 +1,55 @@
+package repotest
+
+import (
+	"context"
+
+	"github.com/CzarSimon/user-service/pkg/models"
+)
+
+// MockLoginHistoryRepo mock implementation of repository.LoginHistoryRepository.
+type MockLoginHistoryRepo struct {
+	SaveErr         error
+	SaveArg         models.LoginEvent
+	SaveInvocations int
+
+	FindByUserIDEvents      []models.LoginEvent
+	FindByUserIDErr         error
+	FindByUserIDArg         string
+	FindByUserIDInvocations int
+
+	DeleteByUserIDErr         error
+	DeleteByUserIDArg         string
+	DeleteByUserIDInvocations int
+}
+
+// Save mock implementation of saving a login event.
+func (lr *MockLoginHistoryRepo) Save(ctx context.Context, event models.LoginEvent) error {
+	lr.SaveArg = event
+	lr.SaveInvocations++
+	return lr.SaveErr
+}
+
+// FindByUserID mock implementation of finding the login history of a user.
+func (lr *MockLoginHistoryRepo) FindByUserID(ctx context.Context, userID string) ([]models.LoginEvent, error) {
+	lr.FindByUserIDArg = userID
+	lr.FindByUserIDInvocations++
+	return lr.FindByUserIDEvents, lr.FindByUserIDErr
+}
+
+// DeleteByUserID mock implementation of deleting the login history of a user.
+func (lr *MockLoginHistoryRepo) DeleteByUserID(ctx context.Context, userID string) error {
+	lr.DeleteByUserIDArg = userID
+	lr.DeleteByUserIDInvocations++
+	return lr.DeleteByUserIDErr
+}
+
+// UnsetArgs unsets all recoreded arguments and invocations.
+func (lr *MockLoginHistoryRepo) UnsetArgs() {
+	lr.SaveInvocations = 0
+	lr.FindByUserIDInvocations = 0
+	lr.DeleteByUserIDInvocations = 0
+
+	lr.SaveArg = models.LoginEvent{}
+	lr.FindByUserIDArg = ""
+	lr.DeleteByUserIDArg = ""
+}
@@ -0,0 +1,160 @@
+package repotest
+
+import (
+	"context"
+	"time"
+
+	"github.com/CzarSimon/user-service/pkg/models"
+)
+
+// MockOAuthClientRepo mock implementation of repository.OAuthClientRepository.
+type MockOAuthClientRepo struct {
+	SaveErr         error
+	SaveArg         models.OAuthClient
+	SaveInvocations int
+
+	FindClient      models.OAuthClient
+	FindErr         error
+	FindArg         string
+	FindInvocations int
+}
+
+// Save mock implementation of saving a client.
+func (cr *MockOAuthClientRepo) Save(ctx context.Context, client models.OAuthClient) error {
+	cr.SaveArg = client
+	cr.SaveInvocations++
+	return cr.SaveErr
+}
+
+// Find mock implementation of finding a client by id.
+func (cr *MockOAuthClientRepo) Find(ctx context.Context, id string) (models.OAuthClient, error) {
+	cr.FindArg = id
+	cr.FindInvocations++
+	return cr.FindClient, cr.FindErr
+}
+
+// UnsetArgs unsets all recoreded arguments and invocations.
+func (cr *MockOAuthClientRepo) UnsetArgs() {
+	cr.SaveInvocations = 0
+	cr.FindInvocations = 0
+
+	cr.SaveArg = models.OAuthClient{}
+	cr.FindArg = ""
+}
+
+// MockAuthorizationCodeRepo mock implementation of repository.AuthorizationCodeRepository.
+type MockAuthorizationCodeRepo struct {
+	SaveErr         error
+	SaveArg         models.AuthorizationCode
+	SaveInvocations int
+
+	ConsumeCode        models.AuthorizationCode
+	ConsumeErr         error
+	ConsumeArg         string
+	ConsumeInvocations int
+
+	RevokeByUserIDErr         error
+	RevokeByUserIDArg         string
+	RevokeByUserIDInvocations int
+
+	DeleteByUserIDErr         error
+	DeleteByUserIDArg         string
+	DeleteByUserIDInvocations int
+}
+
+// Save mock implementation of saving an authorization code.
+func (cr *MockAuthorizationCodeRepo) Save(ctx context.Context, code models.AuthorizationCode) error {
+	cr.SaveArg = code
+	cr.SaveInvocations++
+	return cr.SaveErr
+}
+
+// Consume mock implementation of finding and deleting an authorization code.
+func (cr *MockAuthorizationCodeRepo) Consume(ctx context.Context, codeHash string) (models.AuthorizationCode, error) {
+	cr.ConsumeArg = codeHash
+	cr.ConsumeInvocations++
+	return cr.ConsumeCode, cr.ConsumeErr
+}
+
+// UnsetArgs unsets all recoreded arguments and invocations.
+func (cr *MockAuthorizationCodeRepo) UnsetArgs() {
+	cr.SaveInvocations = 0
+	cr.ConsumeInvocations = 0
+
+	cr.SaveArg = models.AuthorizationCode{}
+	cr.ConsumeArg = ""
+}
+
+// MockRefreshTokenRepo mock implementation of repository.RefreshTokenRepository.
+type MockRefreshTokenRepo struct {
+	SaveErr         error
+	SaveArg         models.RefreshToken
+	SaveInvocations int
+
+	FindToken       models.RefreshToken
+	FindErr         error
+	FindArg         string
+	FindInvocations int
+
+	ConsumeErr         error
+	ConsumeArg         string
+	ConsumeInvocations int
+
+	RevokeByUserIDErr         error
+	RevokeByUserIDArg         string
+	RevokeByUserIDInvocations int
+
+	DeleteByUserIDErr         error
+	DeleteByUserIDArg         string
+	DeleteByUserIDInvocations int
+}
+
+// Save mock implementation of saving a refresh token.
+func (tr *MockRefreshTokenRepo) Save(ctx context.Context, token models.RefreshToken) error {
+	tr.SaveArg = token
+	tr.SaveInvocations++
+	return tr.SaveErr
+}
+
+// Find mock implementation of finding a refresh token by its hash.
+func (tr *MockRefreshTokenRepo) Find(ctx context.Context, tokenHash string) (models.RefreshToken, error) {
+	tr.FindArg = tokenHash
+	tr.FindInvocations++
+	return tr.FindToken, tr.FindErr
+}
+
+// Consume mock implementation of revoking a refresh token which has not been revoked.
+func (tr *MockRefreshTokenRepo) Consume(ctx context.Context, tokenHash string, revokedAt time.Time) error {
+	tr.ConsumeArg = tokenHash
+	tr.ConsumeInvocations++
+	return tr.ConsumeErr
+}
+
+// RevokeByUserID mock implementation of revoking the refresh tokens of a user.
+func (tr *MockRefreshTokenRepo) RevokeByUserID(ctx context.Context, userID string, revokedAt time.Time) error {
+	tr.RevokeByUserIDArg = userID
+	tr.RevokeByUserIDInvocations++
+	return tr.RevokeByUserIDErr
+}
+
+// DeleteByUserID mock implementation of deleting the refresh tokens of a user.
+func (tr *MockRefreshTokenRepo) DeleteByUserID(ctx context.Context, userID string) error {
+	tr.DeleteByUserIDArg = userID
+	tr.DeleteByUserIDInvocations++
+	return tr.DeleteByUserIDErr
+}
+
+// UnsetArgs unsets all recoreded arguments and invocations.
+func (tr *MockRefreshTokenRepo) UnsetArgs() {
+	tr.SaveInvocations = 0
+	tr.FindInvocations = 0
+	tr.ConsumeInvocations = 0
+	tr.RevokeByUserIDInvocations = 0
+	tr.DeleteByUserIDInvocations = 0
+
+	tr.SaveArg = models.RefreshToken{}
+	tr.FindArg = ""
+	tr.ConsumeArg = ""
+	tr.RevokeByUserIDArg = ""
+	tr.DeleteByUserIDArg = ""
+}
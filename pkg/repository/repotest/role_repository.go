@@ -0,0 +1,56 @@
+package repotest
+
+import (
+	"context"
+
+	"github.com/CzarSimon/user-service/pkg/models"
+)
+
+// MockRoleRepo mock implementation of repository.RoleRepository.
+type MockRoleRepo struct {
+	FindRole        models.Role
+	FindErr         error
+	FindArg         string
+	FindInvocations int
+
+	FindByNamesRoles       []models.Role
+	FindByNamesErr         error
+	FindByNamesArg         []string
+	FindByNamesInvocations int
+
+	SaveErr         error
+	SaveArg         models.Role
+	SaveInvocations int
+}
+
+// Find mock implementation of finding a role by name.
+func (rr *MockRoleRepo) Find(ctx context.Context, name string) (models.Role, error) {
+	rr.FindArg = name
+	rr.FindInvocations++
+	return rr.FindRole, rr.FindErr
+}
+
+// FindByNames mock implementation of finding several roles by name.
+func (rr *MockRoleRepo) FindByNames(ctx context.Context, names []string) ([]models.Role, error) {
+	rr.FindByNamesArg = names
+	rr.FindByNamesInvocations++
+	return rr.FindByNamesRoles, rr.FindByNamesErr
+}
+
+// Save mock implementation of saving a role.
+func (rr *MockRoleRepo) Save(ctx context.Context, role models.Role) error {
+	rr.SaveArg = role
+	rr.SaveInvocations++
+	return rr.SaveErr
+}
+
+// UnsetArgs unsets all recoreded arguments and invocations.
+func (rr *MockRoleRepo) UnsetArgs() {
+	rr.FindInvocations = 0
+	rr.FindByNamesInvocations = 0
+	rr.SaveInvocations = 0
+
+	rr.FindArg = ""
+	rr.FindByNamesArg = nil
+	rr.SaveArg = models.Role{}
+}
@@ -0,0 +1,66 @@
+package repotest
+
+import (
+	"context"
+
+	"github.com/CzarSimon/user-service/pkg/models"
+)
+
+// MockServiceAccountRepo mock implementation of repository.ServiceAccountRepository.
+type MockServiceAccountRepo struct {
+	SaveErr         error
+	SaveArg         models.ServiceAccount
+	SaveInvocations int
+
+	FindAccount     models.ServiceAccount
+	FindErr         error
+	FindArg         string
+	FindInvocations int
+
+	FindAllAccounts    []models.ServiceAccount
+	FindAllErr         error
+	FindAllInvocations int
+
+	DeleteErr         error
+	DeleteArg         string
+	DeleteInvocations int
+}
+
+// Save mock implementation of saving a service account.
+func (ar *MockServiceAccountRepo) Save(ctx context.Context, account models.ServiceAccount) error {
+	ar.SaveArg = account
+	ar.SaveInvocations++
+	return ar.SaveErr
+}
+
+// Find mock implementation of finding a service account by id.
+func (ar *MockServiceAccountRepo) Find(ctx context.Context, id string) (models.ServiceAccount, error) {
+	ar.FindArg = id
+	ar.FindInvocations++
+	return ar.FindAccount, ar.FindErr
+}
+
+// FindAll mock implementation of finding all service accounts.
+func (ar *MockServiceAccountRepo) FindAll(ctx context.Context) ([]models.ServiceAccount, error) {
+	ar.FindAllInvocations++
+	return ar.FindAllAccounts, ar.FindAllErr
+}
+
+// Delete mock implementation of deleting a service account.
+func (ar *MockServiceAccountRepo) Delete(ctx context.Context, id string) error {
+	ar.DeleteArg = id
+	ar.DeleteInvocations++
+	return ar.DeleteErr
+}
+
+// UnsetArgs unsets all recoreded arguments and invocations.
+func (ar *MockServiceAccountRepo) UnsetArgs() {
+	ar.SaveInvocations = 0
+	ar.FindInvocations = 0
+	ar.FindAllInvocations = 0
+	ar.DeleteInvocations = 0
+
+	ar.SaveArg = models.ServiceAccount{}
+	ar.FindArg = ""
+	ar.DeleteArg = ""
+}
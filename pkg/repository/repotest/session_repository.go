@@ -0,0 +1,109 @@
+package repotest
+
+import (
+	"context"
+	"time"
+
+	"github.com/CzarSimon/user-service/pkg/models"
+)
+
+// MockSessionRepo mock implementation of repository.SessionRepository.
+type MockSessionRepo struct {
+	SaveErr         error
+	SaveArg         models.Session
+	SaveInvocations int
+
+	FindSession     models.Session
+	FindErr         error
+	FindArg         string
+	FindInvocations int
+
+	FindByUserIDSessions    []models.Session
+	FindByUserIDErr         error
+	FindByUserIDArg         string
+	FindByUserIDInvocations int
+
+	UpdateLastSeenErr         error
+	UpdateLastSeenArg         string
+	UpdateLastSeenInvocations int
+
+	RevokeErr         error
+	RevokeArgs        []string
+	RevokeInvocations int
+
+	RevokeByUserIDErr         error
+	RevokeByUserIDArg         string
+	RevokeByUserIDInvocations int
+
+	DeleteByUserIDErr         error
+	DeleteByUserIDArg         string
+	DeleteByUserIDInvocations int
+}
+
+// Save mock implementation of saving a session.
+func (sr *MockSessionRepo) Save(ctx context.Context, session models.Session) error {
+	sr.SaveArg = session
+	sr.SaveInvocations++
+	return sr.SaveErr
+}
+
+// Find mock implementation of finding a session by id.
+func (sr *MockSessionRepo) Find(ctx context.Context, id string) (models.Session, error) {
+	sr.FindArg = id
+	sr.FindInvocations++
+	return sr.FindSession, sr.FindErr
+}
+
+// FindByUserID mock implementation of finding the sessions of a user.
+func (sr *MockSessionRepo) FindByUserID(ctx context.Context, userID string) ([]models.Session, error) {
+	sr.FindByUserIDArg = userID
+	sr.FindByUserIDInvocations++
+	return sr.FindByUserIDSessions, sr.FindByUserIDErr
+}
+
+// UpdateLastSeen mock implementation of updating when a session was last seen.
+func (sr *MockSessionRepo) UpdateLastSeen(ctx context.Context, id string, lastSeenAt time.Time) error {
+	sr.UpdateLastSeenArg = id
+	sr.UpdateLastSeenInvocations++
+	return sr.UpdateLastSeenErr
+}
+
+// Revoke mock implementation of revoking a session.
+func (sr *MockSessionRepo) Revoke(ctx context.Context, id string, revokedAt time.Time) error {
+	sr.RevokeArgs = append(sr.RevokeArgs, id)
+	sr.RevokeInvocations++
+	return sr.RevokeErr
+}
+
+// RevokeByUserID mock implementation of revoking the sessions of a user.
+func (sr *MockSessionRepo) RevokeByUserID(ctx context.Context, userID string, revokedAt time.Time) error {
+	sr.RevokeByUserIDArg = userID
+	sr.RevokeByUserIDInvocations++
+	return sr.RevokeByUserIDErr
+}
+
+// DeleteByUserID mock implementation of deleting the sessions of a user.
+func (sr *MockSessionRepo) DeleteByUserID(ctx context.Context, userID string) error {
+	sr.DeleteByUserIDArg = userID
+	sr.DeleteByUserIDInvocations++
+	return sr.DeleteByUserIDErr
+}
+
+// UnsetArgs unsets all recoreded arguments and invocations.
+func (sr *MockSessionRepo) UnsetArgs() {
+	sr.SaveInvocations = 0
+	sr.FindInvocations = 0
+	sr.FindByUserIDInvocations = 0
+	sr.UpdateLastSeenInvocations = 0
+	sr.RevokeInvocations = 0
+	sr.RevokeByUserIDInvocations = 0
+	sr.DeleteByUserIDInvocations = 0
+
+	sr.SaveArg = models.Session{}
+	sr.FindArg = ""
+	sr.FindByUserIDArg = ""
+	sr.UpdateLastSeenArg = ""
+	sr.RevokeArgs = nil
+	sr.RevokeByUserIDArg = ""
+	sr.DeleteByUserIDArg = ""
+}
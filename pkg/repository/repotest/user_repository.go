@@ -1,6 +1,9 @@
 package repotest
 
 import (
+	"context"
+	"time"
+
 	"github.com/CzarSimon/user-service/pkg/models"
 )
 
@@ -23,44 +26,166 @@ type MockUserRepo struct {
 	UpdateCredentialsErr         error
 	UpdateCredentialsArg         models.Credentials
 	UpdateCredentialsInvocations int
+
+	ChangeEmailErr         error
+	ChangeEmailIDArg       string
+	ChangeEmailOldArg      string
+	ChangeEmailNewArg      string
+	ChangeEmailInvocations int
+
+	UpdateProfileErr         error
+	UpdateProfileArg         models.User
+	UpdateProfileVersionArg  int
+	UpdateProfileInvocations int
+
+	ListUsers       []models.User
+	ListErr         error
+	ListArg         models.UserQuery
+	ListInvocations int
+
+	UpdateRolesErr         error
+	UpdateRolesArg         models.User
+	UpdateRolesInvocations int
+
+	SetDisabledErr         error
+	SetDisabledIDArg       string
+	SetDisabledArg         bool
+	SetDisabledInvocations int
+
+	DeleteErr         error
+	DeleteArg         string
+	DeleteInvocations int
+
+	MarkDeletedErr         error
+	MarkDeletedIDArg       string
+	MarkDeletedArg         time.Time
+	MarkDeletedInvocations int
+
+	FindDeletedBeforeUsers       []models.User
+	FindDeletedBeforeErr         error
+	FindDeletedBeforeArg         time.Time
+	FindDeletedBeforeInvocations int
 }
 
 // Find mock implementation of finding a user by id.
-func (ur *MockUserRepo) Find(id string) (models.User, error) {
+func (ur *MockUserRepo) Find(ctx context.Context, id string) (models.User, error) {
 	ur.FindArg = id
 	ur.FindInvocations++
 	return ur.FindUser, ur.FindErr
 }
 
 // FindByEmail mock implementation of finding a user by email.
-func (ur *MockUserRepo) FindByEmail(email string) (models.User, error) {
+func (ur *MockUserRepo) FindByEmail(ctx context.Context, email string) (models.User, error) {
 	ur.FindByEmailArg = email
 	ur.FindByEmailInvocations++
 	return ur.FindByEmailUser, ur.FindByEmailErr
 }
 
 // Save mock implementation of saving a user.
-func (ur *MockUserRepo) Save(user models.User) error {
+func (ur *MockUserRepo) Save(ctx context.Context, user models.User) error {
 	ur.SaveArg = user
 	ur.SaveInvocations++
 	return ur.SaveErr
 }
 
 // UpdateCredentials mock implementation of updating a users authentication credentials.
-func (ur *MockUserRepo) UpdateCredentials(credentials models.Credentials) error {
+func (ur *MockUserRepo) UpdateCredentials(ctx context.Context, credentials models.Credentials) error {
 	ur.UpdateCredentialsArg = credentials
 	ur.UpdateCredentialsInvocations++
 	return ur.UpdateCredentialsErr
 }
 
+// ChangeEmail mock implementation of changing the email of a user.
+func (ur *MockUserRepo) ChangeEmail(ctx context.Context, id, oldEmail, newEmail string) error {
+	ur.ChangeEmailIDArg = id
+	ur.ChangeEmailOldArg = oldEmail
+	ur.ChangeEmailNewArg = newEmail
+	ur.ChangeEmailInvocations++
+	return ur.ChangeEmailErr
+}
+
+// UpdateProfile mock implementation of updating a users profile.
+func (ur *MockUserRepo) UpdateProfile(ctx context.Context, user models.User, expectedVersion int) error {
+	ur.UpdateProfileArg = user
+	ur.UpdateProfileVersionArg = expectedVersion
+	ur.UpdateProfileInvocations++
+	return ur.UpdateProfileErr
+}
+
+// List mock implementation of listing users.
+func (ur *MockUserRepo) List(ctx context.Context, query models.UserQuery) ([]models.User, error) {
+	ur.ListArg = query
+	ur.ListInvocations++
+	return ur.ListUsers, ur.ListErr
+}
+
+// UpdateRoles mock implementation of updating the roles of a user.
+func (ur *MockUserRepo) UpdateRoles(ctx context.Context, user models.User) error {
+	ur.UpdateRolesArg = user
+	ur.UpdateRolesInvocations++
+	return ur.UpdateRolesErr
+}
+
+// SetDisabled mock implementation of disabling or enabling a user.
+func (ur *MockUserRepo) SetDisabled(ctx context.Context, id string, disabled bool) error {
+	ur.SetDisabledIDArg = id
+	ur.SetDisabledArg = disabled
+	ur.SetDisabledInvocations++
+	return ur.SetDisabledErr
+}
+
+// Delete mock implementation of deleting a user.
+func (ur *MockUserRepo) Delete(ctx context.Context, id string) error {
+	ur.DeleteArg = id
+	ur.DeleteInvocations++
+	return ur.DeleteErr
+}
+
+// MarkDeleted mock implementation of soft deleting a user.
+func (ur *MockUserRepo) MarkDeleted(ctx context.Context, id string, deletedAt time.Time) error {
+	ur.MarkDeletedIDArg = id
+	ur.MarkDeletedArg = deletedAt
+	ur.MarkDeletedInvocations++
+	return ur.MarkDeletedErr
+}
+
+// FindDeletedBefore mock implementation of finding users soft deleted before a given time.
+func (ur *MockUserRepo) FindDeletedBefore(ctx context.Context, t time.Time) ([]models.User, error) {
+	ur.FindDeletedBeforeArg = t
+	ur.FindDeletedBeforeInvocations++
+	return ur.FindDeletedBeforeUsers, ur.FindDeletedBeforeErr
+}
+
 // UnsetArgs unsets all recoreded arguments and invocations.
 func (ur *MockUserRepo) UnsetArgs() {
 	ur.FindInvocations = 0
 	ur.FindByEmailInvocations = 0
 	ur.SaveInvocations = 0
 	ur.UpdateCredentialsInvocations = 0
+	ur.ChangeEmailInvocations = 0
+	ur.UpdateProfileInvocations = 0
+	ur.ListInvocations = 0
+	ur.UpdateRolesInvocations = 0
+	ur.SetDisabledInvocations = 0
+	ur.DeleteInvocations = 0
+	ur.MarkDeletedInvocations = 0
+	ur.FindDeletedBeforeInvocations = 0
 
 	ur.FindArg = ""
 	ur.FindByEmailArg = ""
 	ur.SaveArg = models.User{}
+	ur.UpdateCredentialsArg = models.Credentials{}
+	ur.ChangeEmailIDArg = ""
+	ur.ChangeEmailOldArg = ""
+	ur.ChangeEmailNewArg = ""
+	ur.UpdateProfileArg = models.User{}
+	ur.UpdateProfileVersionArg = 0
+	ur.ListArg = models.UserQuery{}
+	ur.UpdateRolesArg = models.User{}
+	ur.SetDisabledIDArg = ""
+	ur.SetDisabledArg = false
+	ur.DeleteArg = ""
+	ur.MarkDeletedIDArg = ""
+	ur.MarkDeletedArg = time.Time{}
+	ur.FindDeletedBeforeArg = time.Time{}
 }
@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/CzarSimon/user-service/pkg/models"
+)
+
+// Common role errors
+var (
+	ErrNoSuchRole = errors.New("no such role")
+)
+
+// RoleRepository storage of roles and the permissions they grant.
+type RoleRepository interface {
+	Find(ctx context.Context, name string) (models.Role, error)
+	FindByNames(ctx context.Context, names []string) ([]models.Role, error)
+	Save(ctx context.Context, role models.Role) error
+}
@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/CzarSimon/user-service/pkg/models"
+)
+
+// Common service account errors
+var (
+	ErrNoSuchServiceAccount = errors.New("no such service account")
+)
+
+// ServiceAccountRepository storage of service accounts.
+type ServiceAccountRepository interface {
+	Save(ctx context.Context, account models.ServiceAccount) error
+	// Find finds a service account by its client id. Returns ErrNoSuchServiceAccount if not found.
+	Find(ctx context.Context, id string) (models.ServiceAccount, error)
+	FindAll(ctx context.Context) ([]models.ServiceAccount, error)
+	// Delete deletes a service account. Returns ErrNoSuchServiceAccount if not found.
+	Delete(ctx context.Context, id string) error
+}
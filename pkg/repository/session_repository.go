@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/CzarSimon/user-service/pkg/models"
+)
+
+// Common session errors
+var (
+	ErrNoSuchSession = errors.New("no such session")
+)
+
+// SessionRepository storage of user sessions.
+type SessionRepository interface {
+	Save(ctx context.Context, session models.Session) error
+	// Find finds a session by id, including revoked sessions. Returns ErrNoSuchSession if not found.
+	Find(ctx context.Context, id string) (models.Session, error)
+	// FindByUserID returns the sessions of a user which have not been revoked.
+	FindByUserID(ctx context.Context, userID string) ([]models.Session, error)
+	UpdateLastSeen(ctx context.Context, id string, lastSeenAt time.Time) error
+	// Revoke marks a session as revoked. Returns ErrNoSuchSession if not found.
+	Revoke(ctx context.Context, id string, revokedAt time.Time) error
+	// RevokeByUserID marks every session of a user which has not been revoked as revoked.
+	RevokeByUserID(ctx context.Context, userID string, revokedAt time.Time) error
+	DeleteByUserID(ctx context.Context, userID string) error
+}
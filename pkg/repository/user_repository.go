@@ -1,21 +1,41 @@
 package repository
 
 import (
+	"context"
 	"errors"
+	"time"
 
 	"github.com/CzarSimon/user-service/pkg/models"
 )
 
 // Common user errors
 var (
-	ErrNoSuchUser = errors.New("no such user")
-	ErrUserExists = errors.New("user already exists")
+	ErrNoSuchUser      = errors.New("no such user")
+	ErrUserExists      = errors.New("user already exists")
+	ErrVersionConflict = errors.New("user has been modified")
 )
 
 // UserRepository does stuff.
 type UserRepository interface {
-	Find(id string) (models.User, error)
-	FindByEmail(email string) (models.User, error)
-	Save(user models.User) error
-	UpdateCredentials(credentials models.Credentials) error
+	Find(ctx context.Context, id string) (models.User, error)
+	// FindByEmail finds a user by email address. Emails passed to the repository are normalized
+	// by the caller, implementations should still enforce uniqueness case insensitively.
+	FindByEmail(ctx context.Context, email string) (models.User, error)
+	// Save stores a new user. Returns ErrUserExists if the email is taken.
+	Save(ctx context.Context, user models.User) error
+	UpdateCredentials(ctx context.Context, credentials models.Credentials) error
+	// ChangeEmail atomically changes the email of a user from oldEmail to newEmail.
+	// Returns ErrUserExists if newEmail is taken and ErrVersionConflict if the user no longer has oldEmail.
+	ChangeEmail(ctx context.Context, id, oldEmail, newEmail string) error
+	// UpdateProfile stores an updated user profile, only if the stored user still has the expected version.
+	// Returns ErrVersionConflict otherwise.
+	UpdateProfile(ctx context.Context, user models.User, expectedVersion int) error
+	// List returns users matching the query ordered by creation date and id, starting after the query cursor.
+	List(ctx context.Context, query models.UserQuery) ([]models.User, error)
+	UpdateRoles(ctx context.Context, user models.User) error
+	SetDisabled(ctx context.Context, id string, disabled bool) error
+	Delete(ctx context.Context, id string) error
+	// MarkDeleted soft deletes a user, which is later purged with Delete.
+	MarkDeleted(ctx context.Context, id string, deletedAt time.Time) error
+	FindDeletedBefore(ctx context.Context, t time.Time) ([]models.User, error)
 }
@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/CzarSimon/user-service/pkg/auth"
+	"github.com/CzarSimon/user-service/pkg/httputil"
+	"github.com/CzarSimon/user-service/pkg/models"
+	"github.com/CzarSimon/user-service/pkg/repository"
+	"go.uber.org/zap"
+)
+
+// AccountService service responsible for users managing their own accounts and data.
+type AccountService interface {
+	DeleteAccount(ctx context.Context, principal auth.Token, req models.DeleteAccountRequest) error
+	ExportData(ctx context.Context, principal auth.Token, userID string) (models.AccountExport, error)
+	PurgeDeletedAccounts(ctx context.Context) (int, error)
+}
+
+// AccountServiceOption configures optional dependencies of an AccountService.
+type AccountServiceOption func(*accountSvc)
+
+// WithAccountSessions includes the sessions of users in exports, revokes them when
+// the account is deleted and purges them with the account.
+func WithAccountSessions(sessionRepo repository.SessionRepository) AccountServiceOption {
+	return func(svc *accountSvc) {
+		svc.data.sessionRepo = traceSessionRepo(sessionRepo)
+	}
+}
+
+// WithAccountAPIKeys revokes the api keys of users when the account is deleted and purges them with the account.
+func WithAccountAPIKeys(keyRepo repository.APIKeyRepository) AccountServiceOption {
+	return func(svc *accountSvc) {
+		svc.data.keyRepo = traceAPIKeyRepo(keyRepo)
+	}
+}
+
+// WithAccountEmailChanges purges the email changes of users with the account.
+func WithAccountEmailChanges(changeRepo repository.EmailChangeRepository) AccountServiceOption {
+	return func(svc *accountSvc) {
+		svc.data.changeRepo = traceEmailChangeRepo(changeRepo)
+	}
+}
+
+// WithAccountRefreshTokens revokes the refresh tokens issued on behalf of users when
+// the account is deleted and purges them with the account.
+func WithAccountRefreshTokens(tokenRepo repository.RefreshTokenRepository) AccountServiceOption {
+	return func(svc *accountSvc) {
+		svc.data.tokenRepo = traceRefreshTokenRepo(tokenRepo)
+	}
+}
+
+// WithAccountLogger sets the logger used when the account service is called outside of a request.
+func WithAccountLogger(log *zap.Logger) AccountServiceOption {
+	return func(svc *accountSvc) {
+		svc.log = log
+	}
+}
+
+// NewAccountService creates a new AccountService. Deleted accounts are kept for
+// the duration of the grace period before they are purged.
+func NewAccountService(hasher auth.Hasher, userRepo repository.UserRepository, loginRepo repository.LoginHistoryRepository, gracePeriod time.Duration, opts ...AccountServiceOption) AccountService {
+	svc := &accountSvc{
+		hasher:      hasher,
+		userRepo:    traceUserRepo(userRepo),
+		data:        userData{loginRepo: traceLoginHistoryRepo(loginRepo)},
+		gracePeriod: gracePeriod,
+	}
+
+	for _, opt := range opts {
+		opt(svc)
+	}
+	return svc
+}
+
+type accountSvc struct {
+	logging
+	hasher      auth.Hasher
+	userRepo    repository.UserRepository
+	data        userData
+	gracePeriod time.Duration
+}
+
+func (svc *accountSvc) DeleteAccount(ctx context.Context, principal auth.Token, req models.DeleteAccountRequest) error {
+	ctx = svc.withLogger(ctx)
+	if principal.Subject == "" || principal.Subject != req.UserID {
+		return httputil.ErrForbidden()
+	}
+
+	user, err := findUser(ctx, svc.userRepo, req.UserID)
+	if err != nil {
+		return err
+	}
+
+	err = verifyPassword(ctx, svc.hasher, req.Password, user.Credentials.Salt, user.Credentials.PasswordHash)
+	if err != nil {
+		return errInvalidCredentials()
+	}
+
+	if !user.IsDeleted() {
+		err = svc.userRepo.MarkDeleted(ctx, user.ID, time.Now().UTC())
+		if err != nil {
+			logger(ctx).Errorw("Failed to mark user as deleted", "userId", user.ID, "err", err)
+			return httputil.NewInternalServerError("Failed to delete account")
+		}
+	}
+
+	err = svc.data.revokeCredentials(ctx, user.ID)
+	if err != nil {
+		logger(ctx).Errorw("Failed to revoke credentials of deleted user", "userId", user.ID, "err", err)
+		return httputil.NewInternalServerError("Failed to delete account")
+	}
+
+	return nil
+}
+
+func (svc *accountSvc) ExportData(ctx context.Context, principal auth.Token, userID string) (models.AccountExport, error) {
+	ctx = svc.withLogger(ctx)
+	err := assertUserAccess(principal, userID, models.ReadUsersPermission)
+	if err != nil {
+		return models.AccountExport{}, err
+	}
+
+	user, err := findUser(ctx, svc.userRepo, userID)
+	if err != nil {
+		return models.AccountExport{}, err
+	}
+
+	loginHistory, err := svc.data.loginRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		logger(ctx).Errorw("Failed to find login history", "userId", userID, "err", err)
+		return models.AccountExport{}, httputil.NewInternalServerError("Failed to export data")
+	}
+
+	sessions, err := svc.findSessions(ctx, userID)
+	if err != nil {
+		logger(ctx).Errorw("Failed to find sessions", "userId", userID, "err", err)
+		return models.AccountExport{}, httputil.NewInternalServerError("Failed to export data")
+	}
+
+	return models.AccountExport{
+		User:         user,
+		LoginHistory: loginHistory,
+		Sessions:     sessions,
+		ExportedAt:   time.Now().UTC(),
+	}, nil
+}
+
+func (svc *accountSvc) findSessions(ctx context.Context, userID string) ([]models.Session, error) {
+	if svc.data.sessionRepo == nil {
+		return []models.Session{}, nil
+	}
+	return svc.data.sessionRepo.FindByUserID(ctx, userID)
+}
+
+// PurgeDeletedAccounts permanently deletes accounts whose grace period has passed.
+// Returns the number of purged accounts.
+func (svc *accountSvc) PurgeDeletedAccounts(ctx context.Context) (int, error) {
+	ctx = svc.withLogger(ctx)
+	deadline := time.Now().UTC().Add(-svc.gracePeriod)
+	users, err := svc.userRepo.FindDeletedBefore(ctx, deadline)
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, user := range users {
+		err = svc.purge(ctx, user.ID)
+		if err != nil {
+			logger(ctx).Errorw("Failed to purge account", "userId", user.ID, "err", err)
+			continue
+		}
+		purged++
+	}
+
+	return purged, nil
+}
+
+func (svc *accountSvc) purge(ctx context.Context, userID string) error {
+	err := svc.data.purge(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	err = svc.userRepo.Delete(ctx, userID)
+	if err == repository.ErrNoSuchUser {
+		return nil
+	}
+	return err
+}
+
+// StartPurgeJob purges deleted accounts at a given interval until the returned stop function is called.
+// Failures are logged with the given logger.
+func StartPurgeJob(svc AccountService, interval time.Duration, log *zap.Logger) func() {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+	ctx := httputil.ContextWithLogger(context.Background(), log)
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				purged, err := svc.PurgeDeletedAccounts(ctx)
+				if err != nil {
+					logger(ctx).Errorw("Failed to purge deleted accounts", "err", err)
+				} else if purged > 0 {
+					logger(ctx).Infow("Purged deleted accounts", "count", purged)
+				}
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+	}
+}
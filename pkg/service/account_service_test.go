@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/CzarSimon/user-service/pkg/auth"
+	"github.com/CzarSimon/user-service/pkg/id"
+	"github.com/CzarSimon/user-service/pkg/models"
+	"github.com/CzarSimon/user-service/pkg/repository/repotest"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_accountSvc_DeleteAccount(t *testing.T) {
+	user := testUser()
+	deletedUser := testUser()
+	deletedAt := time.Now().UTC()
+	deletedUser.DeletedAt = &deletedAt
+
+	tests := []struct {
+		name            string
+		userRepo        *repotest.MockUserRepo
+		principal       auth.Token
+		req             models.DeleteAccountRequest
+		wantMarkDeleted int
+		wantErr         int
+	}{
+		{
+			name:      "happy-path",
+			userRepo:  &repotest.MockUserRepo{FindUser: user},
+			principal: auth.Token{Subject: user.ID, Role: models.UserRole},
+			req: models.DeleteAccountRequest{
+				UserID:   user.ID,
+				Password: "secret-drowssap",
+			},
+			wantMarkDeleted: 1,
+		},
+		{
+			name:      "happy-path-already-deleted",
+			userRepo:  &repotest.MockUserRepo{FindUser: deletedUser},
+			principal: auth.Token{Subject: deletedUser.ID, Role: models.UserRole},
+			req: models.DeleteAccountRequest{
+				UserID:   deletedUser.ID,
+				Password: "secret-drowssap",
+			},
+			wantMarkDeleted: 0,
+		},
+		{
+			name:      "sad-path-wrong-password",
+			userRepo:  &repotest.MockUserRepo{FindUser: user},
+			principal: auth.Token{Subject: user.ID, Role: models.UserRole},
+			req: models.DeleteAccountRequest{
+				UserID:   user.ID,
+				Password: "wrong-password",
+			},
+			wantErr: http.StatusUnauthorized,
+		},
+		{
+			name:      "sad-path-admin-deleting-other-user",
+			userRepo:  &repotest.MockUserRepo{FindUser: user},
+			principal: adminPrincipal(),
+			req: models.DeleteAccountRequest{
+				UserID:   user.ID,
+				Password: "secret-drowssap",
+			},
+			wantErr: http.StatusForbidden,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sessionRepo := &repotest.MockSessionRepo{}
+			keyRepo := &repotest.MockAPIKeyRepo{}
+			tokenRepo := &repotest.MockRefreshTokenRepo{}
+			svc := NewAccountService(
+				hasher, tt.userRepo, &repotest.MockLoginHistoryRepo{}, 30*24*time.Hour,
+				WithAccountSessions(sessionRepo), WithAccountAPIKeys(keyRepo), WithAccountRefreshTokens(tokenRepo),
+			)
+			err := svc.DeleteAccount(context.Background(), tt.principal, tt.req)
+			if tt.wantErr != 0 {
+				assertStatus(t, tt.wantErr, err)
+				assert.Equal(t, 0, tt.userRepo.MarkDeletedInvocations)
+				assert.Equal(t, 0, sessionRepo.RevokeByUserIDInvocations)
+				assert.Equal(t, 0, keyRepo.RevokeByUserIDInvocations)
+				assert.Equal(t, 0, tokenRepo.RevokeByUserIDInvocations)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantMarkDeleted, tt.userRepo.MarkDeletedInvocations)
+			assert.Equal(t, 0, tt.userRepo.DeleteInvocations)
+			assert.Equal(t, tt.req.UserID, sessionRepo.RevokeByUserIDArg)
+			assert.Equal(t, tt.req.UserID, keyRepo.RevokeByUserIDArg)
+			assert.Equal(t, tt.req.UserID, tokenRepo.RevokeByUserIDArg)
+		})
+	}
+}
+
+func Test_accountSvc_DeleteAccount_SessionRevocationFailure(t *testing.T) {
+	assert := assert.New(t)
+	user := testUser()
+	userRepo := &repotest.MockUserRepo{FindUser: user}
+	sessionRepo := &repotest.MockSessionRepo{RevokeByUserIDErr: errors.New("db failure")}
+	keyRepo := &repotest.MockAPIKeyRepo{}
+	svc := NewAccountService(hasher, userRepo, &repotest.MockLoginHistoryRepo{}, time.Hour, WithAccountSessions(sessionRepo), WithAccountAPIKeys(keyRepo))
+
+	req := models.DeleteAccountRequest{UserID: user.ID, Password: "secret-drowssap"}
+	err := svc.DeleteAccount(context.Background(), auth.Token{Subject: user.ID, Role: models.UserRole}, req)
+	assertStatus(t, http.StatusInternalServerError, err)
+	assert.Equal(1, userRepo.MarkDeletedInvocations)
+	assert.Equal(0, keyRepo.RevokeByUserIDInvocations)
+}
+
+func Test_accountSvc_ExportData(t *testing.T) {
+	assert := assert.New(t)
+	user := testUser()
+	events := []models.LoginEvent{
+		models.NewLoginEvent(user.ID, true, models.ClientInfo{IP: "10.0.0.1", UserAgent: "test-agent"}),
+	}
+	sessions := []models.Session{
+		models.NewSession(id.New(), user.ID, models.ClientInfo{IP: "10.0.0.1", UserAgent: "test-agent"}),
+	}
+	userRepo := &repotest.MockUserRepo{FindUser: user}
+	loginRepo := &repotest.MockLoginHistoryRepo{FindByUserIDEvents: events}
+	sessionRepo := &repotest.MockSessionRepo{FindByUserIDSessions: sessions}
+	svc := NewAccountService(hasher, userRepo, loginRepo, time.Hour, WithAccountSessions(sessionRepo))
+
+	export, err := svc.ExportData(context.Background(), auth.Token{Subject: user.ID, Role: models.UserRole}, user.ID)
+	assert.NoError(err)
+	assert.Equal(user, export.User)
+	assert.Equal(events, export.LoginHistory)
+	assert.Equal(sessions, export.Sessions)
+	assert.Equal(user.ID, loginRepo.FindByUserIDArg)
+	assert.False(export.ExportedAt.IsZero())
+
+	_, err = svc.ExportData(context.Background(), auth.Token{Subject: id.New(), Role: models.UserRole}, user.ID)
+	assertStatus(t, http.StatusForbidden, err)
+
+	_, err = svc.ExportData(context.Background(), adminPrincipal(), user.ID)
+	assert.NoError(err)
+}
+
+func Test_accountSvc_PurgeDeletedAccounts(t *testing.T) {
+	assert := assert.New(t)
+	users := []models.User{testUser(), testUser()}
+	userRepo := &repotest.MockUserRepo{FindDeletedBeforeUsers: users}
+	loginRepo := &repotest.MockLoginHistoryRepo{}
+	sessionRepo := &repotest.MockSessionRepo{}
+	keyRepo := &repotest.MockAPIKeyRepo{}
+	changeRepo := &repotest.MockEmailChangeRepo{}
+	tokenRepo := &repotest.MockRefreshTokenRepo{}
+	svc := NewAccountService(
+		hasher, userRepo, loginRepo, time.Hour,
+		WithAccountSessions(sessionRepo), WithAccountAPIKeys(keyRepo), WithAccountEmailChanges(changeRepo),
+		WithAccountRefreshTokens(tokenRepo),
+	)
+
+	purged, err := svc.PurgeDeletedAccounts(context.Background())
+	assert.NoError(err)
+	assert.Equal(2, purged)
+	assert.Equal(2, userRepo.DeleteInvocations)
+	assert.Equal(2, loginRepo.DeleteByUserIDInvocations)
+	assert.Equal(2, sessionRepo.DeleteByUserIDInvocations)
+	assert.Equal(2, keyRepo.DeleteByUserIDInvocations)
+	assert.Equal(2, changeRepo.DeleteByUserIDInvocations)
+	assert.Equal(2, tokenRepo.DeleteByUserIDInvocations)
+	assert.Equal(users[1].ID, keyRepo.DeleteByUserIDArg)
+	assert.Equal(users[1].ID, tokenRepo.DeleteByUserIDArg)
+	assert.Equal(users[1].ID, changeRepo.DeleteByUserIDArg)
+	assert.True(userRepo.FindDeletedBeforeArg.Before(time.Now().UTC().Add(-59 * time.Minute)))
+
+	userRepo.UnsetArgs()
+	keyRepo.DeleteByUserIDErr = errors.New("db failure")
+	purged, err = svc.PurgeDeletedAccounts(context.Background())
+	assert.NoError(err)
+	assert.Equal(0, purged)
+	assert.Equal(0, userRepo.DeleteInvocations)
+
+	userRepo.UnsetArgs()
+	loginRepo.UnsetArgs()
+	loginRepo.DeleteByUserIDErr = errors.New("db failure")
+	purged, err = svc.PurgeDeletedAccounts(context.Background())
+	assert.NoError(err)
+	assert.Equal(0, purged)
+	assert.Equal(0, userRepo.DeleteInvocations)
+
+	userRepo.FindDeletedBeforeErr = errors.New("db failure")
+	_, err = svc.PurgeDeletedAccounts(context.Background())
+	assert.Error(err)
+}
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/CzarSimon/user-service/pkg/auth"
+	"github.com/CzarSimon/user-service/pkg/httputil"
+	"github.com/CzarSimon/user-service/pkg/models"
+	"github.com/CzarSimon/user-service/pkg/repository"
+)
+
+func (svc *userSvc) ListUsers(ctx context.Context, principal auth.Token, query models.UserQuery) (models.UserPage, error) {
+	ctx = svc.withLogger(ctx)
+	err := assertPermission(principal, models.ReadUsersPermission)
+	if err != nil {
+		return models.UserPage{}, err
+	}
+
+	limit := pageSize(query.Limit)
+	query.Limit = limit + 1
+	users, err := svc.userRepo.List(ctx, query)
+	if err != nil {
+		logger(ctx).Errorw("Failed to list users", "err", err)
+		return models.UserPage{}, httputil.NewInternalServerError("Failed to list users")
+	}
+
+	if len(users) <= limit {
+		return models.UserPage{Users: users}, nil
+	}
+
+	users = users[:limit]
+	return models.UserPage{
+		Users:      users,
+		NextCursor: models.NewUserCursor(users[limit-1]).Encode(),
+	}, nil
+}
+
+func (svc *userSvc) ChangeRole(ctx context.Context, principal auth.Token, req models.ChangeRoleRequest) (models.User, error) {
+	ctx = svc.withLogger(ctx)
+	user, err := svc.changeRole(ctx, principal, req)
+	audit(ctx, svc.auditSink, models.AuditRoleChange, principal.Subject, req.UserID, err)
+	return user, err
+}
+
+func (svc *userSvc) changeRole(ctx context.Context, principal auth.Token, req models.ChangeRoleRequest) (models.User, error) {
+	err := assertPermission(principal, models.AssignRolesPermission)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	err = svc.assertRolesExist(ctx, req.Roles)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	user, err := svc.findUser(ctx, req.UserID)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	user = req.Apply(user)
+	err = svc.userRepo.UpdateRoles(ctx, user)
+	if err != nil {
+		logger(ctx).Errorw("Failed to update roles", "userId", user.ID, "err", err)
+		return models.User{}, httputil.NewInternalServerError("Failed to update roles")
+	}
+
+	err = svc.userData().revokeSessions(ctx, user.ID)
+	if err != nil {
+		logger(ctx).Errorw("Failed to revoke sessions after role change", "userId", user.ID, "err", err)
+		return models.User{}, httputil.NewInternalServerError("Failed to revoke sessions")
+	}
+
+	return user, nil
+}
+
+func (svc *userSvc) assertRolesExist(ctx context.Context, names []string) error {
+	if len(names) == 0 {
+		return httputil.NewError("At least one role is required", http.StatusBadRequest).
+			WithCode(CodeRoleRequired).
+			WithMessageKey("role.required")
+	}
+
+	roles, err := svc.roleRepo.FindByNames(ctx, names)
+	if err != nil {
+		logger(ctx).Errorw("Failed to find roles", "roles", names, "err", err)
+		return httputil.NewInternalServerError("Failed to find roles")
+	}
+
+	known := make(map[string]bool)
+	for _, role := range roles {
+		known[role.Name] = true
+	}
+
+	for _, name := range names {
+		if !known[name] {
+			return httputil.NewError("Unknown role: "+name, http.StatusBadRequest).
+				WithCode(CodeUnknownRole).
+				WithMessageKey("role.unknown", name)
+		}
+	}
+
+	return nil
+}
+
+func (svc *userSvc) DisableUser(ctx context.Context, principal auth.Token, id string) error {
+	ctx = svc.withLogger(ctx)
+	return svc.setDisabled(ctx, principal, id, true)
+}
+
+func (svc *userSvc) EnableUser(ctx context.Context, principal auth.Token, id string) error {
+	ctx = svc.withLogger(ctx)
+	return svc.setDisabled(ctx, principal, id, false)
+}
+
+func (svc *userSvc) setDisabled(ctx context.Context, principal auth.Token, id string, disabled bool) error {
+	err := assertPermission(principal, models.WriteUsersPermission)
+	if err != nil {
+		return err
+	}
+
+	err = svc.userRepo.SetDisabled(ctx, id, disabled)
+	if err == repository.ErrNoSuchUser {
+		return errUserNotFound()
+	} else if err != nil {
+		logger(ctx).Errorw("Failed to set disabled", "userId", id, "disabled", disabled, "err", err)
+		return httputil.NewInternalServerError("Failed to update user")
+	}
+
+	if !disabled {
+		return nil
+	}
+
+	err = svc.userData().revokeCredentials(ctx, id)
+	if err != nil {
+		logger(ctx).Errorw("Failed to revoke credentials of disabled user", "userId", id, "err", err)
+		return httputil.NewInternalServerError("Failed to revoke credentials")
+	}
+
+	return nil
+}
+
+func (svc *userSvc) DeleteUser(ctx context.Context, principal auth.Token, id string) error {
+	ctx = svc.withLogger(ctx)
+	err := assertPermission(principal, models.WriteUsersPermission)
+	if err != nil {
+		return err
+	}
+
+	user, err := svc.findUser(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	err = svc.userData().purge(ctx, user.ID)
+	if err != nil {
+		logger(ctx).Errorw("Failed to purge user data", "userId", user.ID, "err", err)
+		return httputil.NewInternalServerError("Failed to delete user")
+	}
+
+	err = svc.userRepo.Delete(ctx, user.ID)
+	if err == repository.ErrNoSuchUser {
+		return errUserNotFound()
+	} else if err != nil {
+		logger(ctx).Errorw("Failed to delete user", "userId", user.ID, "err", err)
+		return httputil.NewInternalServerError("Failed to delete user")
+	}
+
+	return nil
+}
+
+// userData returns the repositories storing the credentials and personal data of users which have been configured.
+func (svc *userSvc) userData() userData {
+	return userData{
+		loginRepo:   svc.loginRepo,
+		sessionRepo: svc.sessionRepo,
+		keyRepo:     svc.keyRepo,
+		changeRepo:  svc.changeRepo,
+		tokenRepo:   svc.tokenRepo,
+	}
+}
+
+// pageSize returns the requested page size bounded by the default and max page sizes.
+func pageSize(limit int) int {
+	if limit <= 0 {
+		return models.DefaultPageSize
+	}
+
+	if limit > models.MaxPageSize {
+		return models.MaxPageSize
+	}
+
+	return limit
+}
@@ -0,0 +1,294 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/CzarSimon/user-service/pkg/auth"
+	"github.com/CzarSimon/user-service/pkg/httputil"
+	"github.com/CzarSimon/user-service/pkg/id"
+	"github.com/CzarSimon/user-service/pkg/models"
+	"github.com/CzarSimon/user-service/pkg/repository"
+	"github.com/CzarSimon/user-service/pkg/repository/repotest"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_userSvc_ListUsers(t *testing.T) {
+	users := make([]models.User, 0)
+	createdAt := time.Now().UTC()
+	for i := 0; i < 3; i++ {
+		user := models.NewUser("mail@mail.com", "Tester", "McTest", models.UserRole, models.Credentials{})
+		user.CreatedAt = createdAt.Add(time.Duration(i) * time.Second)
+		users = append(users, user)
+	}
+
+	type args struct {
+		principal auth.Token
+		query     models.UserQuery
+	}
+	type want struct {
+		users      []models.User
+		nextCursor string
+		queryLimit int
+	}
+	tests := []struct {
+		name     string
+		userRepo *repotest.MockUserRepo
+		args     args
+		want     want
+		wantErr  int
+	}{
+		{
+			name: "happy-path-last-page",
+			userRepo: &repotest.MockUserRepo{
+				ListUsers: users,
+			},
+			args: args{
+				principal: adminPrincipal(),
+				query:     models.UserQuery{Role: models.UserRole},
+			},
+			want: want{
+				users:      users,
+				nextCursor: "",
+				queryLimit: models.DefaultPageSize + 1,
+			},
+		},
+		{
+			name: "happy-path-more-pages",
+			userRepo: &repotest.MockUserRepo{
+				ListUsers: users,
+			},
+			args: args{
+				principal: adminPrincipal(),
+				query:     models.UserQuery{Limit: 2},
+			},
+			want: want{
+				users:      users[:2],
+				nextCursor: models.NewUserCursor(users[1]).Encode(),
+				queryLimit: 3,
+			},
+		},
+		{
+			name: "happy-path-limit-above-max",
+			userRepo: &repotest.MockUserRepo{
+				ListUsers: users,
+			},
+			args: args{
+				principal: adminPrincipal(),
+				query:     models.UserQuery{Limit: 10000},
+			},
+			want: want{
+				users:      users,
+				queryLimit: models.MaxPageSize + 1,
+			},
+		},
+		{
+			name: "sad-path-missing-permission",
+			userRepo: &repotest.MockUserRepo{
+				ListUsers: users,
+			},
+			args: args{
+				principal: auth.Token{Subject: id.New(), Role: models.AdminRole},
+			},
+			wantErr: http.StatusForbidden,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewUserService(hasher, issuer, tt.userRepo, roleRepo)
+			got, err := svc.ListUsers(context.Background(), tt.args.principal, tt.args.query)
+			if tt.wantErr != 0 {
+				assertStatus(t, tt.wantErr, err)
+				assert.Equal(t, 0, tt.userRepo.ListInvocations)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want.users, got.Users)
+			assert.Equal(t, tt.want.nextCursor, got.NextCursor)
+			assert.Equal(t, tt.want.queryLimit, tt.userRepo.ListArg.Limit)
+			assert.Equal(t, tt.args.query.Role, tt.userRepo.ListArg.Role)
+		})
+	}
+}
+
+func Test_userSvc_ChangeRole(t *testing.T) {
+	user := models.NewUser("mail@mail.com", "Tester", "McTest", models.UserRole, models.Credentials{})
+	rolesRepo := &repotest.MockRoleRepo{
+		FindByNamesRoles: []models.Role{{Name: models.AdminRole}, {Name: models.UserRole}},
+	}
+	roleAdmin := adminPrincipal()
+	roleAdmin.Permissions = append(roleAdmin.Permissions, models.AssignRolesPermission)
+
+	tests := []struct {
+		name      string
+		userRepo  *repotest.MockUserRepo
+		principal auth.Token
+		req       models.ChangeRoleRequest
+		wantErr   int
+	}{
+		{
+			name: "happy-path",
+			userRepo: &repotest.MockUserRepo{
+				FindUser: user,
+			},
+			principal: roleAdmin,
+			req: models.ChangeRoleRequest{
+				UserID: user.ID,
+				Roles:  []string{models.AdminRole, models.UserRole},
+			},
+		},
+		{
+			name: "sad-path-unknown-role",
+			userRepo: &repotest.MockUserRepo{
+				FindUser: user,
+			},
+			principal: roleAdmin,
+			req: models.ChangeRoleRequest{
+				UserID: user.ID,
+				Roles:  []string{"SUPER_ADMIN"},
+			},
+			wantErr: http.StatusBadRequest,
+		},
+		{
+			name: "sad-path-no-roles",
+			userRepo: &repotest.MockUserRepo{
+				FindUser: user,
+			},
+			principal: roleAdmin,
+			req: models.ChangeRoleRequest{
+				UserID: user.ID,
+			},
+			wantErr: http.StatusBadRequest,
+		},
+		{
+			name: "sad-path-no-such-user",
+			userRepo: &repotest.MockUserRepo{
+				FindErr: repository.ErrNoSuchUser,
+			},
+			principal: roleAdmin,
+			req: models.ChangeRoleRequest{
+				UserID: user.ID,
+				Roles:  []string{models.AdminRole},
+			},
+			wantErr: http.StatusNotFound,
+		},
+		{
+			name: "sad-path-missing-permission",
+			userRepo: &repotest.MockUserRepo{
+				FindUser: user,
+			},
+			principal: adminPrincipal(),
+			req: models.ChangeRoleRequest{
+				UserID: user.ID,
+				Roles:  []string{models.AdminRole},
+			},
+			wantErr: http.StatusForbidden,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sessionRepo := &repotest.MockSessionRepo{}
+			svc := NewUserService(hasher, issuer, tt.userRepo, rolesRepo, WithSessions(sessionRepo))
+			got, err := svc.ChangeRole(context.Background(), tt.principal, tt.req)
+			if tt.wantErr != 0 {
+				assertStatus(t, tt.wantErr, err)
+				assert.Equal(t, 0, tt.userRepo.UpdateRolesInvocations)
+				assert.Equal(t, 0, sessionRepo.RevokeByUserIDInvocations)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.req.Roles[0], got.Role)
+			assert.Equal(t, tt.req.Roles, got.Roles)
+			assert.Equal(t, user.Version+1, got.Version)
+			assert.True(t, got.UpdatedAt.After(user.UpdatedAt))
+			assert.Equal(t, 1, tt.userRepo.UpdateRolesInvocations)
+			assert.Equal(t, got, tt.userRepo.UpdateRolesArg)
+			assert.Equal(t, user.ID, sessionRepo.RevokeByUserIDArg)
+		})
+	}
+}
+
+func Test_userSvc_DisableEnableAndDelete(t *testing.T) {
+	assert := assert.New(t)
+	user := testUser()
+	userID := user.ID
+	userRepo := &repotest.MockUserRepo{FindUser: user}
+	loginRepo := &repotest.MockLoginHistoryRepo{}
+	sessionRepo := &repotest.MockSessionRepo{}
+	keyRepo := &repotest.MockAPIKeyRepo{}
+	changeRepo := &repotest.MockEmailChangeRepo{}
+	tokenRepo := &repotest.MockRefreshTokenRepo{}
+	svc := NewUserService(
+		hasher, issuer, userRepo, roleRepo,
+		WithLoginHistory(loginRepo), WithSessions(sessionRepo), WithAPIKeys(keyRepo),
+		WithEmailChanges(changeRepo), WithRefreshTokens(tokenRepo),
+	)
+
+	err := svc.DisableUser(context.Background(), adminPrincipal(), userID)
+	assert.NoError(err)
+	assert.Equal(userID, userRepo.SetDisabledIDArg)
+	assert.True(userRepo.SetDisabledArg)
+	assert.Equal(userID, sessionRepo.RevokeByUserIDArg)
+	assert.Equal(userID, keyRepo.RevokeByUserIDArg)
+	assert.Equal(userID, tokenRepo.RevokeByUserIDArg)
+
+	err = svc.EnableUser(context.Background(), adminPrincipal(), userID)
+	assert.NoError(err)
+	assert.Equal(userID, userRepo.SetDisabledIDArg)
+	assert.False(userRepo.SetDisabledArg)
+	assert.Equal(2, userRepo.SetDisabledInvocations)
+	assert.Equal(1, sessionRepo.RevokeByUserIDInvocations)
+
+	err = svc.DeleteUser(context.Background(), adminPrincipal(), userID)
+	assert.NoError(err)
+	assert.Equal(userID, userRepo.DeleteArg)
+	assert.Equal(1, userRepo.DeleteInvocations)
+	assert.Equal(userID, loginRepo.DeleteByUserIDArg)
+	assert.Equal(userID, sessionRepo.DeleteByUserIDArg)
+	assert.Equal(userID, keyRepo.DeleteByUserIDArg)
+	assert.Equal(userID, changeRepo.DeleteByUserIDArg)
+	assert.Equal(userID, tokenRepo.DeleteByUserIDArg)
+
+	userRepo.UnsetArgs()
+	keyRepo.RevokeByUserIDErr = errors.New("db failure")
+	keyRepo.DeleteByUserIDErr = errors.New("db failure")
+	assertStatus(t, http.StatusInternalServerError, svc.DisableUser(context.Background(), adminPrincipal(), userID))
+	assertStatus(t, http.StatusInternalServerError, svc.DeleteUser(context.Background(), adminPrincipal(), userID))
+	assert.Equal(0, userRepo.DeleteInvocations)
+
+	userRepo.UnsetArgs()
+	userPrincipal := auth.Token{Subject: userID, Role: models.UserRole}
+	assertStatus(t, http.StatusForbidden, svc.DisableUser(context.Background(), userPrincipal, userID))
+	assertStatus(t, http.StatusForbidden, svc.EnableUser(context.Background(), userPrincipal, userID))
+	assertStatus(t, http.StatusForbidden, svc.DeleteUser(context.Background(), userPrincipal, userID))
+	assert.Equal(0, userRepo.SetDisabledInvocations)
+	assert.Equal(0, userRepo.DeleteInvocations)
+
+	userRepo.SetDisabledErr = repository.ErrNoSuchUser
+	userRepo.FindErr = repository.ErrNoSuchUser
+	assertStatus(t, http.StatusNotFound, svc.DisableUser(context.Background(), adminPrincipal(), userID))
+	assertStatus(t, http.StatusNotFound, svc.DeleteUser(context.Background(), adminPrincipal(), userID))
+}
+
+func assertStatus(t *testing.T, status int, err error) {
+	httpErr, ok := err.(*httputil.Error)
+	if !ok {
+		t.Errorf("expected *httputil.Error with status %d, got: %v", status, err)
+		return
+	}
+	assert.Equal(t, status, httpErr.StatusCode)
+}
+
+func assertCode(t *testing.T, code string, err error) {
+	httpErr, ok := err.(*httputil.Error)
+	if !ok {
+		t.Errorf("expected *httputil.Error with code %s, got: %v", code, err)
+		return
+	}
+	assert.Equal(t, code, httpErr.Code)
+}
@@ -0,0 +1,235 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"time"
+
+	"github.com/CzarSimon/user-service/pkg/auth"
+	"github.com/CzarSimon/user-service/pkg/httputil"
+	"github.com/CzarSimon/user-service/pkg/models"
+	"github.com/CzarSimon/user-service/pkg/repository"
+	"go.uber.org/zap"
+)
+
+// Error codes returned by the api key service.
+const (
+	CodeAPIKeyNotFound = "API_KEY_NOT_FOUND"
+)
+
+// lastUsedInterval how often the last used timestamp of an api key is updated.
+const lastUsedInterval = time.Minute
+
+// maxAPIKeyAttempts how many keys are generated before giving up when their prefixes are already taken.
+const maxAPIKeyAttempts = 3
+
+// APIKeyService service responsible for users managing their API keys.
+type APIKeyService interface {
+	// Create creates an API key for the principal, returning the key along with its metadata.
+	// Users may only create keys for themselves, using a token from a direct login rather than one
+	// issued to an OAuth client or resolved from another key, as the key would otherwise escape the
+	// scopes granted to the client or outlive the key it was created with.
+	Create(ctx context.Context, principal auth.Token, userID string, req models.CreateAPIKeyRequest) (models.CreatedAPIKey, error)
+	List(ctx context.Context, principal auth.Token, userID string) ([]models.APIKey, error)
+	Revoke(ctx context.Context, principal auth.Token, userID, keyID string) error
+}
+
+// APIKeyServiceOption configures optional dependencies of an APIKeyService.
+type APIKeyServiceOption func(*apiKeySvc)
+
+// WithAPIKeyLogger sets the logger used when API keys are managed outside of a request.
+func WithAPIKeyLogger(log *zap.Logger) APIKeyServiceOption {
+	return func(svc *apiKeySvc) {
+		svc.log = log
+	}
+}
+
+// NewAPIKeyService creates a new APIKeyService.
+func NewAPIKeyService(keyRepo repository.APIKeyRepository, opts ...APIKeyServiceOption) APIKeyService {
+	svc := &apiKeySvc{
+		keyRepo: traceAPIKeyRepo(keyRepo),
+	}
+
+	for _, opt := range opts {
+		opt(svc)
+	}
+	return svc
+}
+
+type apiKeySvc struct {
+	logging
+	keyRepo repository.APIKeyRepository
+}
+
+func (svc *apiKeySvc) Create(ctx context.Context, principal auth.Token, userID string, req models.CreateAPIKeyRequest) (models.CreatedAPIKey, error) {
+	ctx = svc.withLogger(ctx)
+	if principal.Subject == "" || principal.Subject != userID || !principal.IsDirectLogin() {
+		return models.CreatedAPIKey{}, httputil.ErrForbidden()
+	}
+
+	err := validate(req)
+	if err != nil {
+		return models.CreatedAPIKey{}, err
+	}
+
+	for attempt := 1; ; attempt++ {
+		key, prefix, err := auth.GenerateAPIKey()
+		if err != nil {
+			logger(ctx).Errorw("Failed to generate api key", "userId", userID, "err", err)
+			return models.CreatedAPIKey{}, httputil.NewInternalServerError("Failed to generate api key")
+		}
+
+		apiKey := models.NewAPIKey(userID, prefix, auth.HashToken(key), req)
+		err = svc.keyRepo.Save(ctx, apiKey)
+		if err == repository.ErrAPIKeyExists && attempt < maxAPIKeyAttempts {
+			continue
+		} else if err != nil {
+			logger(ctx).Errorw("Failed to save api key", "userId", userID, "attempt", attempt, "err", err)
+			return models.CreatedAPIKey{}, httputil.NewInternalServerError("Failed to save api key")
+		}
+
+		return models.CreatedAPIKey{
+			APIKey: apiKey,
+			Key:    key,
+		}, nil
+	}
+}
+
+func (svc *apiKeySvc) List(ctx context.Context, principal auth.Token, userID string) ([]models.APIKey, error) {
+	ctx = svc.withLogger(ctx)
+	err := assertUserAccess(principal, userID, models.ReadUsersPermission)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := svc.keyRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		logger(ctx).Errorw("Failed to find api keys", "userId", userID, "err", err)
+		return nil, httputil.NewInternalServerError("Failed to find api keys")
+	}
+
+	return keys, nil
+}
+
+func (svc *apiKeySvc) Revoke(ctx context.Context, principal auth.Token, userID, keyID string) error {
+	ctx = svc.withLogger(ctx)
+	err := assertUserAccess(principal, userID, models.WriteUsersPermission)
+	if err != nil {
+		return err
+	}
+
+	key, err := svc.keyRepo.Find(ctx, keyID)
+	if err == repository.ErrNoSuchAPIKey || (err == nil && key.UserID != userID) {
+		return errAPIKeyNotFound()
+	} else if err != nil {
+		logger(ctx).Errorw("Failed to find api key", "keyId", keyID, "err", err)
+		return httputil.NewInternalServerError("Failed to find api key")
+	}
+
+	if key.IsRevoked() {
+		return nil
+	}
+
+	err = svc.keyRepo.Revoke(ctx, key.ID, time.Now().UTC())
+	if err == repository.ErrNoSuchAPIKey {
+		return errAPIKeyNotFound()
+	} else if err != nil {
+		logger(ctx).Errorw("Failed to revoke api key", "keyId", keyID, "err", err)
+		return httputil.NewInternalServerError("Failed to revoke api key")
+	}
+
+	return nil
+}
+
+// NewAPIKeyVerifier wraps an auth.Verifier so that it also verifies API keys, which makes
+// httputil.Authenticate accept them with the ApiKey scheme. An API key resolves to the same
+// principal as a token issued to its user at login, with the id of the key as the token id.
+// Also keeps track of when keys were last used. Failures are logged with the given logger.
+func NewAPIKeyVerifier(verifier auth.Verifier, keyRepo repository.APIKeyRepository, userRepo repository.UserRepository, roleRepo repository.RoleRepository, log *zap.Logger) auth.Verifier {
+	return &apiKeyVerifier{
+		verifier: verifier,
+		keyRepo:  traceAPIKeyRepo(keyRepo),
+		userRepo: traceUserRepo(userRepo),
+		roleRepo: traceRoleRepo(roleRepo),
+		logging:  logging{log: log},
+	}
+}
+
+type apiKeyVerifier struct {
+	logging
+	verifier auth.Verifier
+	keyRepo  repository.APIKeyRepository
+	userRepo repository.UserRepository
+	roleRepo repository.RoleRepository
+}
+
+func (v *apiKeyVerifier) Verify(rawToken string) (auth.Token, error) {
+	return v.verifier.Verify(rawToken)
+}
+
+func (v *apiKeyVerifier) VerifyAPIKey(rawKey string) (auth.Token, error) {
+	prefix, err := auth.ParseAPIKeyPrefix(rawKey)
+	if err != nil {
+		return auth.Token{}, err
+	}
+
+	ctx := v.withLogger(context.Background())
+	key, err := v.keyRepo.FindByPrefix(ctx, prefix)
+	if err == repository.ErrNoSuchAPIKey {
+		return auth.Token{}, auth.ErrInvalidAPIKey
+	} else if err != nil {
+		logger(ctx).Errorw("Failed to find api key", "prefix", prefix, "err", err)
+		return auth.Token{}, httputil.NewInternalServerError("Failed to verify api key")
+	}
+
+	if subtle.ConstantTimeCompare([]byte(auth.HashToken(rawKey)), []byte(key.KeyHash)) != 1 || key.IsRevoked() {
+		return auth.Token{}, auth.ErrInvalidAPIKey
+	}
+
+	if key.IsExpired() {
+		return auth.Token{}, auth.ErrExpiredToken
+	}
+
+	user, err := v.userRepo.Find(ctx, key.UserID)
+	if err == repository.ErrNoSuchUser {
+		return auth.Token{}, auth.ErrInvalidAPIKey
+	} else if err != nil {
+		logger(ctx).Errorw("Failed find user by id", "userId", key.UserID, "err", err)
+		return auth.Token{}, httputil.NewInternalServerError("Failed to verify api key")
+	}
+
+	if user.Disabled || user.IsDeleted() {
+		return auth.Token{}, auth.ErrInvalidAPIKey
+	}
+
+	permissions, err := findPermissions(ctx, v.roleRepo, user)
+	if err != nil {
+		return auth.Token{}, err
+	}
+
+	now := time.Now().UTC()
+	if now.Sub(key.LastUsedAt) > lastUsedInterval {
+		err = v.keyRepo.UpdateLastUsed(ctx, key.ID, now)
+		if err != nil {
+			logger(ctx).Errorw("Failed to update api key last used", "keyId", key.ID, "err", err)
+		}
+	}
+
+	return auth.Token{
+		ID:            key.ID,
+		Subject:       user.ID,
+		Role:          user.Role,
+		Permissions:   permissions,
+		PrincipalType: auth.UserPrincipal,
+		APIKey:        true,
+		CreatedAt:     key.CreatedAt,
+		ExpiresAt:     key.ExpiresAt,
+	}, nil
+}
+
+func errAPIKeyNotFound() error {
+	return httputil.NewError("No such api key", http.StatusNotFound).
+		WithCode(CodeAPIKeyNotFound).
+		WithMessageKey("apiKey.notFound")
+}
@@ -0,0 +1,334 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/CzarSimon/user-service/pkg/auth"
+	"github.com/CzarSimon/user-service/pkg/httputil"
+	"github.com/CzarSimon/user-service/pkg/id"
+	"github.com/CzarSimon/user-service/pkg/models"
+	"github.com/CzarSimon/user-service/pkg/repository"
+	"github.com/CzarSimon/user-service/pkg/repository/repotest"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func Test_apiKeySvc_Create(t *testing.T) {
+	assert := assert.New(t)
+	userID := id.New()
+	principal := auth.Token{ID: id.New(), Subject: userID, Role: models.UserRole}
+	keyRepo := &repotest.MockAPIKeyRepo{}
+	svc := NewAPIKeyService(keyRepo)
+	req := models.CreateAPIKeyRequest{Name: "Deploy script", ExpiresAt: time.Now().Add(24 * time.Hour)}
+
+	created, err := svc.Create(context.Background(), principal, userID, req)
+	assert.NoError(err)
+	assert.Equal(keyRepo.SaveArg, created.APIKey)
+	assert.Equal(userID, created.APIKey.UserID)
+	assert.Equal("Deploy script", created.APIKey.Name)
+	assert.Equal(req.ExpiresAt.UTC(), created.APIKey.ExpiresAt)
+	prefix, err := auth.ParseAPIKeyPrefix(created.Key)
+	assert.NoError(err)
+	assert.Equal(prefix, created.APIKey.Prefix)
+	assert.Equal(auth.HashToken(created.Key), created.APIKey.KeyHash)
+
+	keyRepo.UnsetArgs()
+	_, err = svc.Create(context.Background(), adminPrincipal(), userID, req)
+	assertStatus(t, http.StatusForbidden, err)
+	assert.Equal(0, keyRepo.SaveInvocations)
+
+	serviceAccount := auth.Token{ID: id.New(), Subject: userID, Role: models.ServiceRole, PrincipalType: auth.ServicePrincipal}
+	_, err = svc.Create(context.Background(), serviceAccount, userID, req)
+	assertStatus(t, http.StatusForbidden, err)
+
+	delegated := auth.Token{ID: id.New(), Subject: userID, Role: models.UserRole, ClientID: id.New(), Scopes: []string{models.OpenIDScope}}
+	_, err = svc.Create(context.Background(), delegated, userID, req)
+	assertStatus(t, http.StatusForbidden, err)
+
+	fromKey := auth.Token{ID: id.New(), Subject: userID, Role: models.UserRole, PrincipalType: auth.UserPrincipal, APIKey: true}
+	_, err = svc.Create(context.Background(), fromKey, userID, req)
+	assertStatus(t, http.StatusForbidden, err)
+	assert.Equal(0, keyRepo.SaveInvocations)
+
+	_, err = svc.Create(context.Background(), principal, userID, models.CreateAPIKeyRequest{Name: "Expired", ExpiresAt: time.Now().Add(-time.Hour)})
+	assertStatus(t, http.StatusBadRequest, err)
+	assertCode(t, httputil.CodeValidationFailed, err)
+
+	keyRepo.SaveErr = repository.ErrAPIKeyExists
+	_, err = svc.Create(context.Background(), principal, userID, req)
+	assertStatus(t, http.StatusInternalServerError, err)
+	assert.Equal(maxAPIKeyAttempts, keyRepo.SaveInvocations)
+
+	keyRepo.UnsetArgs()
+	keyRepo.SaveErr = errors.New("db failure")
+	_, err = svc.Create(context.Background(), principal, userID, req)
+	assertStatus(t, http.StatusInternalServerError, err)
+	assert.Equal(1, keyRepo.SaveInvocations)
+}
+
+func Test_apiKeySvc_List(t *testing.T) {
+	assert := assert.New(t)
+	userID := id.New()
+	principal := auth.Token{ID: id.New(), Subject: userID, Role: models.UserRole}
+	key := models.NewAPIKey(userID, "prefix", "hash", models.CreateAPIKeyRequest{Name: "Deploy script"})
+	keyRepo := &repotest.MockAPIKeyRepo{FindByUserIDKeys: []models.APIKey{key}}
+	svc := NewAPIKeyService(keyRepo)
+
+	keys, err := svc.List(context.Background(), principal, userID)
+	assert.NoError(err)
+	assert.Equal([]models.APIKey{key}, keys)
+	assert.Equal(userID, keyRepo.FindByUserIDArg)
+
+	_, err = svc.List(context.Background(), adminPrincipal(), userID)
+	assert.NoError(err)
+
+	_, err = svc.List(context.Background(), principal, id.New())
+	assertStatus(t, http.StatusForbidden, err)
+
+	keyRepo.FindByUserIDErr = errors.New("db failure")
+	_, err = svc.List(context.Background(), principal, userID)
+	assertStatus(t, http.StatusInternalServerError, err)
+}
+
+func Test_apiKeySvc_Revoke(t *testing.T) {
+	userID := id.New()
+	principal := auth.Token{ID: id.New(), Subject: userID, Role: models.UserRole}
+	key := models.NewAPIKey(userID, "prefix", "hash", models.CreateAPIKeyRequest{Name: "Deploy script"})
+	revokedKey := key
+	revokedKey.RevokedAt = time.Now().UTC()
+	otherKey := models.NewAPIKey(id.New(), "other", "hash", models.CreateAPIKeyRequest{Name: "Other"})
+
+	tests := []struct {
+		name        string
+		principal   auth.Token
+		findKey     models.APIKey
+		findErr     error
+		revokeErr   error
+		wantRevoked bool
+		wantStatus  int
+		wantCode    string
+	}{
+		{
+			name:        "happy-path",
+			principal:   principal,
+			findKey:     key,
+			wantRevoked: true,
+		},
+		{
+			name:        "happy-path-admin",
+			principal:   adminPrincipal(),
+			findKey:     key,
+			wantRevoked: true,
+		},
+		{
+			name:      "happy-path-already-revoked",
+			principal: principal,
+			findKey:   revokedKey,
+		},
+		{
+			name:       "sad-path-key-of-other-user",
+			principal:  principal,
+			findKey:    otherKey,
+			wantStatus: http.StatusNotFound,
+			wantCode:   CodeAPIKeyNotFound,
+		},
+		{
+			name:       "sad-path-no-such-key",
+			principal:  principal,
+			findErr:    repository.ErrNoSuchAPIKey,
+			wantStatus: http.StatusNotFound,
+			wantCode:   CodeAPIKeyNotFound,
+		},
+		{
+			name:       "sad-path-forbidden",
+			principal:  auth.Token{ID: id.New(), Subject: id.New(), Role: models.UserRole},
+			findKey:    key,
+			wantStatus: http.StatusForbidden,
+			wantCode:   "FORBIDDEN",
+		},
+		{
+			name:        "sad-path-revoke-fails",
+			principal:   principal,
+			findKey:     key,
+			revokeErr:   errors.New("db failure"),
+			wantRevoked: true,
+			wantStatus:  http.StatusInternalServerError,
+			wantCode:    "INTERNAL_SERVER_ERROR",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keyRepo := &repotest.MockAPIKeyRepo{FindKey: tt.findKey, FindErr: tt.findErr, RevokeErr: tt.revokeErr}
+			svc := NewAPIKeyService(keyRepo)
+
+			err := svc.Revoke(context.Background(), tt.principal, userID, key.ID)
+			if tt.wantRevoked {
+				assert.Equal(t, key.ID, keyRepo.RevokeArg)
+			} else {
+				assert.Equal(t, 0, keyRepo.RevokeInvocations)
+			}
+
+			if tt.wantStatus != 0 {
+				assertStatus(t, tt.wantStatus, err)
+				assertCode(t, tt.wantCode, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestAPIKeyVerifier(t *testing.T) {
+	user := testUser()
+	rawKey, prefix, err := auth.GenerateAPIKey()
+	assert.NoError(t, err)
+	key := models.NewAPIKey(user.ID, prefix, auth.HashToken(rawKey), models.CreateAPIKeyRequest{Name: "Deploy script"})
+	otherKey, _, err := auth.GenerateAPIKey()
+	assert.NoError(t, err)
+
+	recentlyUsed := key
+	recentlyUsed.LastUsedAt = time.Now().UTC()
+	revoked := key
+	revoked.RevokedAt = time.Now().UTC()
+	expired := key
+	expired.ExpiresAt = time.Now().UTC().Add(-time.Minute)
+	disabledUser := user
+	disabledUser.Disabled = true
+
+	tests := []struct {
+		name         string
+		rawKey       string
+		key          models.APIKey
+		findErr      error
+		user         models.User
+		wantErr      error
+		wantLastUsed bool
+	}{
+		{
+			name:         "happy-path",
+			rawKey:       rawKey,
+			key:          key,
+			user:         user,
+			wantLastUsed: true,
+		},
+		{
+			name:   "happy-path-recently-used",
+			rawKey: rawKey,
+			key:    recentlyUsed,
+			user:   user,
+		},
+		{
+			name:    "sad-path-malformed-key",
+			rawKey:  "not-an-api-key",
+			key:     key,
+			user:    user,
+			wantErr: auth.ErrInvalidAPIKey,
+		},
+		{
+			name:    "sad-path-wrong-secret",
+			rawKey:  "usk_" + prefix + otherKey[len("usk_")+len(prefix):],
+			key:     key,
+			user:    user,
+			wantErr: auth.ErrInvalidAPIKey,
+		},
+		{
+			name:    "sad-path-unknown-prefix",
+			rawKey:  otherKey,
+			findErr: repository.ErrNoSuchAPIKey,
+			user:    user,
+			wantErr: auth.ErrInvalidAPIKey,
+		},
+		{
+			name:    "sad-path-revoked",
+			rawKey:  rawKey,
+			key:     revoked,
+			user:    user,
+			wantErr: auth.ErrInvalidAPIKey,
+		},
+		{
+			name:    "sad-path-expired",
+			rawKey:  rawKey,
+			key:     expired,
+			user:    user,
+			wantErr: auth.ErrExpiredToken,
+		},
+		{
+			name:    "sad-path-disabled-user",
+			rawKey:  rawKey,
+			key:     key,
+			user:    disabledUser,
+			wantErr: auth.ErrInvalidAPIKey,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+			keyRepo := &repotest.MockAPIKeyRepo{FindByPrefixKey: tt.key, FindByPrefixErr: tt.findErr}
+			userRepo := &repotest.MockUserRepo{FindUser: tt.user}
+			roleRepo := &repotest.MockRoleRepo{FindByNamesRoles: []models.Role{{Name: models.UserRole, Permissions: []string{models.ReadUsersPermission}}}}
+			keyVerifier := NewAPIKeyVerifier(verifier, keyRepo, userRepo, roleRepo, zap.NewNop()).(auth.APIKeyVerifier)
+
+			token, err := keyVerifier.VerifyAPIKey(tt.rawKey)
+			if tt.wantErr != nil {
+				assert.Equal(tt.wantErr, err)
+				assert.Equal(0, keyRepo.UpdateLastUsedInvocations)
+				return
+			}
+
+			assert.NoError(err)
+			assert.Equal(prefix, keyRepo.FindByPrefixArg)
+			assert.Equal(key.ID, token.ID)
+			assert.Equal(user.ID, token.Subject)
+			assert.Equal(user.Role, token.Role)
+			assert.Equal([]string{models.ReadUsersPermission}, token.Permissions)
+			assert.False(token.IsServiceAccount())
+			assert.True(token.APIKey)
+			if tt.wantLastUsed {
+				assert.Equal(key.ID, keyRepo.UpdateLastUsedArg)
+			} else {
+				assert.Equal(0, keyRepo.UpdateLastUsedInvocations)
+			}
+		})
+	}
+}
+
+func TestAPIKeyVerifier_VerifiesTokens(t *testing.T) {
+	assert := assert.New(t)
+	keyVerifier := NewAPIKeyVerifier(verifier, &repotest.MockAPIKeyRepo{}, &repotest.MockUserRepo{}, roleRepo, zap.NewNop())
+
+	rawToken, err := issuer.Issue("user-id", models.UserRole)
+	assert.NoError(err)
+	token, err := keyVerifier.Verify(rawToken)
+	assert.NoError(err)
+	assert.Equal("user-id", token.Subject)
+
+	_, err = keyVerifier.Verify("not-a-token")
+	assert.Equal(auth.ErrInvalidToken, err)
+}
+
+func TestAPIKeyVerifier_LogsFailures(t *testing.T) {
+	assert := assert.New(t)
+	user := testUser()
+	rawKey, prefix, err := auth.GenerateAPIKey()
+	assert.NoError(err)
+	key := models.NewAPIKey(user.ID, prefix, auth.HashToken(rawKey), models.CreateAPIKeyRequest{Name: "Deploy script"})
+	keyRepo := &repotest.MockAPIKeyRepo{FindByPrefixKey: key, UpdateLastUsedErr: errors.New("db failure")}
+	core, logs := observer.New(zapcore.ErrorLevel)
+	keyVerifier := NewAPIKeyVerifier(verifier, keyRepo, &repotest.MockUserRepo{FindUser: user}, roleRepo, zap.New(core)).(auth.APIKeyVerifier)
+
+	_, err = keyVerifier.VerifyAPIKey(rawKey)
+	assert.NoError(err)
+	assert.Len(logs.FilterMessage("Failed to update api key last used").All(), 1)
+
+	keyRepo.FindByPrefixErr = errors.New("pq: connection refused")
+	_, err = keyVerifier.VerifyAPIKey(rawKey)
+	assertStatus(t, http.StatusInternalServerError, err)
+	assert.NotContains(err.Error(), "connection refused")
+	assert.Len(logs.FilterMessage("Failed to find api key").All(), 1)
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+
+	"github.com/CzarSimon/user-service/pkg/auth"
+	"github.com/CzarSimon/user-service/pkg/httputil"
+	"github.com/CzarSimon/user-service/pkg/models"
+)
+
+// AuditSink append-only destination of audit events.
+type AuditSink interface {
+	Write(ctx context.Context, event models.AuditEvent) error
+}
+
+// AuditLog AuditSink which can be queried for previously written events.
+type AuditLog interface {
+	AuditSink
+	// Query returns the events matching the query, newest first.
+	Query(ctx context.Context, query models.AuditQuery) ([]models.AuditEvent, error)
+}
+
+// WithAuditSink writes an audit trail of signups, logins, password changes and role changes to an AuditSink.
+func WithAuditSink(sink AuditSink) UserServiceOption {
+	return func(svc *userSvc) {
+		svc.auditSink = sink
+	}
+}
+
+// audit writes an audit event annotated with the request metadata of the context.
+// The event is a failure if err is not nil. Failing to write the event is logged
+// rather than returned, so that an unavailable sink does not stop users from logging in.
+func audit(ctx context.Context, sink AuditSink, eventType, actorID, subjectID string, err error) {
+	if sink == nil {
+		return
+	}
+
+	reason := ""
+	if err != nil {
+		reason = errorCode(err)
+	}
+
+	event := models.NewAuditEvent(eventType, actorID, subjectID, reason)
+	md := httputil.GetRequestMetadata(ctx)
+	event.IP = md.IP
+	event.UserAgent = md.UserAgent
+	event.RequestID = md.RequestID
+
+	writeErr := sink.Write(ctx, event)
+	if writeErr != nil {
+		logger(ctx).Errorw("Failed to write audit event", "type", eventType, "subjectId", subjectID, "err", writeErr)
+	}
+}
+
+// errorCode returns the code of an error returned by the service.
+func errorCode(err error) string {
+	if httpErr, ok := err.(*httputil.Error); ok {
+		return httpErr.Code
+	}
+	return "ERROR"
+}
+
+// AuditService service responsible for letting admins query the audit log.
+type AuditService interface {
+	QueryAuditLog(ctx context.Context, principal auth.Token, query models.AuditQuery) ([]models.AuditEvent, error)
+}
+
+// NewAuditService creates a new AuditService.
+func NewAuditService(log AuditLog) AuditService {
+	return &auditSvc{
+		log: log,
+	}
+}
+
+type auditSvc struct {
+	log AuditLog
+}
+
+func (svc *auditSvc) QueryAuditLog(ctx context.Context, principal auth.Token, query models.AuditQuery) ([]models.AuditEvent, error) {
+	err := assertPermission(principal, models.ReadAuditLogPermission)
+	if err != nil {
+		return nil, err
+	}
+
+	query.Limit = pageSize(query.Limit)
+	events, err := svc.log.Query(ctx, query)
+	if err != nil {
+		logger(ctx).Errorw("Failed to query audit log", "err", err)
+		return nil, httputil.NewInternalServerError("Failed to query audit log")
+	}
+
+	return events, nil
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/CzarSimon/user-service/pkg/models"
+)
+
+// JSONLinesAuditSink AuditSink which writes events to a writer as JSON, one event per line.
+type JSONLinesAuditSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewJSONLinesAuditSink creates an AuditSink writing JSON lines to a writer.
+func NewJSONLinesAuditSink(w io.Writer) *JSONLinesAuditSink {
+	return &JSONLinesAuditSink{
+		w: w,
+	}
+}
+
+// NewStdoutAuditSink creates an AuditSink writing JSON lines to stdout.
+// Closing the sink leaves stdout open.
+func NewStdoutAuditSink() *JSONLinesAuditSink {
+	return NewJSONLinesAuditSink(struct{ io.Writer }{os.Stdout})
+}
+
+// OpenFileAuditSink opens a file in append only mode, creating it if needed, and returns
+// an AuditSink writing JSON lines to it. The sink must be closed to release the file.
+func OpenFileAuditSink(path string) (*JSONLinesAuditSink, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewJSONLinesAuditSink(f), nil
+}
+
+// Write writes an event as a single line of JSON.
+func (s *JSONLinesAuditSink) Write(ctx context.Context, event models.AuditEvent) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.w.Write(append(line, '\n'))
+	return err
+}
+
+// Close closes the underlying writer if it is an io.Closer.
+func (s *JSONLinesAuditSink) Close() error {
+	if closer, ok := s.w.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
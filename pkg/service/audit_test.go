@@ -0,0 +1,179 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/CzarSimon/user-service/pkg/auth"
+	"github.com/CzarSimon/user-service/pkg/httputil"
+	"github.com/CzarSimon/user-service/pkg/models"
+	"github.com/CzarSimon/user-service/pkg/repository"
+	"github.com/CzarSimon/user-service/pkg/repository/repotest"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockAuditLog struct {
+	events   []models.AuditEvent
+	writeErr error
+	queryArg models.AuditQuery
+	queryErr error
+}
+
+func (l *mockAuditLog) Write(ctx context.Context, event models.AuditEvent) error {
+	l.events = append(l.events, event)
+	return l.writeErr
+}
+
+func (l *mockAuditLog) Query(ctx context.Context, query models.AuditQuery) ([]models.AuditEvent, error) {
+	l.queryArg = query
+	return l.events, l.queryErr
+}
+
+func TestAudit(t *testing.T) {
+	assert := assert.New(t)
+	user := testUser()
+	ctx := httputil.WithRequestMetadata(context.Background(), httputil.RequestMetadata{
+		RequestID: "request-1",
+		IP:        "10.0.0.1",
+		UserAgent: "test-agent/1.0",
+	})
+
+	sink := &mockAuditLog{}
+	signupRepo := &repotest.MockUserRepo{FindByEmailErr: repository.ErrNoSuchUser}
+	svc := NewUserService(hasher, issuer, signupRepo, roleRepo, WithAuditSink(sink))
+	res, err := svc.SignUp(ctx, models.SignupRequest{
+		Email:             "new@mail.com",
+		Password:          "secret-drowssap",
+		RepeatPassword:    "secret-drowssap",
+		Surname:           "Tester",
+		MiddleAndLastName: "McTest",
+	})
+	assert.NoError(err)
+	assert.Len(sink.events, 1)
+	event := sink.events[0]
+	assert.NotEmpty(event.ID)
+	assert.Equal(models.AuditSignup, event.Type)
+	assert.Equal(res.User.ID, event.ActorID)
+	assert.Equal(res.User.ID, event.SubjectID)
+	assert.Equal("10.0.0.1", event.IP)
+	assert.Equal("test-agent/1.0", event.UserAgent)
+	assert.Equal("request-1", event.RequestID)
+	assert.Equal(models.AuditSuccess, event.Outcome)
+	assert.Empty(event.Reason)
+
+	sink = &mockAuditLog{writeErr: errors.New("sink unavailable")}
+	loginRepo := &repotest.MockUserRepo{FindByEmailUser: user, FindUser: user}
+	svc = NewUserService(hasher, issuer, loginRepo, roleRepo, WithAuditSink(sink))
+	_, err = svc.Login(ctx, models.LoginRequest{Email: user.Email, Password: "secret-drowssap"})
+	assert.NoError(err)
+	_, err = svc.Login(ctx, models.LoginRequest{Email: user.Email, Password: "wrong-password"})
+	assert.Error(err)
+	assert.Len(sink.events, 2)
+	assert.Equal(models.AuditLogin, sink.events[0].Type)
+	assert.Equal(user.ID, sink.events[0].SubjectID)
+	assert.Equal(models.AuditSuccess, sink.events[0].Outcome)
+	assert.Equal(models.AuditLogin, sink.events[1].Type)
+	assert.Equal(user.ID, sink.events[1].SubjectID)
+	assert.Equal(models.AuditFailure, sink.events[1].Outcome)
+	assert.Equal(CodeInvalidCredentials, sink.events[1].Reason)
+
+	sink.events = nil
+	_, err = svc.ChangePassword(ctx, auth.Token{Subject: user.ID, Role: models.UserRole}, models.ChangePasswordRequest{
+		UserID:         user.ID,
+		OldPassword:    "secret-drowssap",
+		NewPassword:    "new-secret-drowssap",
+		RepeatPassword: "new-secret-drowssap",
+	})
+	assert.NoError(err)
+	assert.Len(sink.events, 1)
+	assert.Equal(models.AuditPasswordChange, sink.events[0].Type)
+	assert.Equal(user.ID, sink.events[0].ActorID)
+	assert.Equal(models.AuditSuccess, sink.events[0].Outcome)
+
+	sink.events = nil
+	admin := adminPrincipal()
+	_, err = svc.ChangeRole(ctx, admin, models.ChangeRoleRequest{UserID: user.ID, Roles: []string{models.AdminRole}})
+	assertStatus(t, http.StatusForbidden, err)
+	assert.Len(sink.events, 1)
+	assert.Equal(models.AuditRoleChange, sink.events[0].Type)
+	assert.Equal(admin.Subject, sink.events[0].ActorID)
+	assert.Equal(user.ID, sink.events[0].SubjectID)
+	assert.Equal(models.AuditFailure, sink.events[0].Outcome)
+	assert.Equal("FORBIDDEN", sink.events[0].Reason)
+}
+
+func Test_auditSvc_QueryAuditLog(t *testing.T) {
+	assert := assert.New(t)
+	auditor := adminPrincipal()
+	auditor.Permissions = append(auditor.Permissions, models.ReadAuditLogPermission)
+
+	log := &mockAuditLog{events: []models.AuditEvent{models.NewAuditEvent(models.AuditLogin, "user-1", "user-1", "")}}
+	svc := NewAuditService(log)
+	events, err := svc.QueryAuditLog(context.Background(), auditor, models.AuditQuery{Type: models.AuditLogin})
+	assert.NoError(err)
+	assert.Equal(log.events, events)
+	assert.Equal(models.AuditLogin, log.queryArg.Type)
+	assert.Equal(models.DefaultPageSize, log.queryArg.Limit)
+
+	_, err = svc.QueryAuditLog(context.Background(), auditor, models.AuditQuery{Limit: 10000})
+	assert.NoError(err)
+	assert.Equal(models.MaxPageSize, log.queryArg.Limit)
+
+	_, err = svc.QueryAuditLog(context.Background(), adminPrincipal(), models.AuditQuery{})
+	assertStatus(t, http.StatusForbidden, err)
+
+	log.queryErr = errors.New("query failed")
+	_, err = svc.QueryAuditLog(context.Background(), auditor, models.AuditQuery{})
+	assertStatus(t, http.StatusInternalServerError, err)
+}
+
+func TestJSONLinesAuditSink(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	sink := NewJSONLinesAuditSink(&buf)
+
+	first := models.NewAuditEvent(models.AuditLogin, "user-1", "user-1", "")
+	second := models.NewAuditEvent(models.AuditLogin, "user-2", "user-2", CodeInvalidCredentials)
+	assert.NoError(sink.Write(context.Background(), first))
+	assert.NoError(sink.Write(context.Background(), second))
+	assert.NoError(sink.Close())
+
+	scanner := bufio.NewScanner(&buf)
+	events := make([]models.AuditEvent, 0)
+	for scanner.Scan() {
+		var event models.AuditEvent
+		assert.NoError(json.Unmarshal(scanner.Bytes(), &event))
+		events = append(events, event)
+	}
+	assert.Len(events, 2)
+	assert.Equal(first.ID, events[0].ID)
+	assert.Equal(models.AuditFailure, events[1].Outcome)
+	assert.Equal(CodeInvalidCredentials, events[1].Reason)
+}
+
+func TestOpenFileAuditSink(t *testing.T) {
+	assert := assert.New(t)
+	dir, err := ioutil.TempDir("", "audit")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "audit.log")
+
+	for i := 0; i < 2; i++ {
+		sink, err := OpenFileAuditSink(path)
+		assert.NoError(err)
+		assert.NoError(sink.Write(context.Background(), models.NewAuditEvent(models.AuditSignup, "user-1", "user-1", "")))
+		assert.NoError(sink.Close())
+	}
+
+	content, err := ioutil.ReadFile(path)
+	assert.NoError(err)
+	assert.Len(bytes.Split(bytes.TrimSpace(content), []byte("\n")), 2)
+}
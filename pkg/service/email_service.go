@@ -0,0 +1,241 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/CzarSimon/user-service/pkg/auth"
+	"github.com/CzarSimon/user-service/pkg/httputil"
+	"github.com/CzarSimon/user-service/pkg/models"
+	"github.com/CzarSimon/user-service/pkg/repository"
+	"go.uber.org/zap"
+)
+
+// Email change token lifetimes.
+const (
+	emailConfirmationPeriod = 24 * time.Hour
+	emailRevertPeriod       = 7 * 24 * time.Hour
+	emailTokenLength        = 32
+)
+
+// EmailSender interface for sending transactional emails to users.
+// Implementations are responsible for turning tokens into links.
+type EmailSender interface {
+	SendEmailChangeConfirmation(to, token string) error
+	SendEmailChangeNotice(to, newEmail, revertToken string) error
+}
+
+// EmailChangeService service responsible for changing the email address of users.
+type EmailChangeService interface {
+	RequestEmailChange(ctx context.Context, principal auth.Token, req models.ChangeEmailRequest) error
+	ConfirmEmailChange(ctx context.Context, token string) (models.User, error)
+	RevertEmailChange(ctx context.Context, token string) (models.User, error)
+}
+
+// EmailChangeServiceOption configures optional settings of an EmailChangeService.
+type EmailChangeServiceOption func(*emailChangeSvc)
+
+// WithNewEmailNormalizer sets how new email addresses are normalized, should match
+// the normalizer used by the UserService. Defaults to models.DefaultEmailNormalizer.
+func WithNewEmailNormalizer(normalizer models.EmailNormalizer) EmailChangeServiceOption {
+	return func(svc *emailChangeSvc) {
+		svc.emailNormalizer = normalizer
+	}
+}
+
+// WithEmailChangeLogger sets the logger used when email changes are made outside of a request.
+func WithEmailChangeLogger(log *zap.Logger) EmailChangeServiceOption {
+	return func(svc *emailChangeSvc) {
+		svc.log = log
+	}
+}
+
+// NewEmailChangeService creates a new EmailChangeService.
+func NewEmailChangeService(hasher auth.Hasher, userRepo repository.UserRepository, changeRepo repository.EmailChangeRepository, sender EmailSender, opts ...EmailChangeServiceOption) EmailChangeService {
+	svc := &emailChangeSvc{
+		hasher:          hasher,
+		userRepo:        traceUserRepo(userRepo),
+		changeRepo:      traceEmailChangeRepo(changeRepo),
+		sender:          sender,
+		emailNormalizer: models.DefaultEmailNormalizer,
+	}
+
+	for _, opt := range opts {
+		opt(svc)
+	}
+	return svc
+}
+
+type emailChangeSvc struct {
+	logging
+	hasher          auth.Hasher
+	userRepo        repository.UserRepository
+	changeRepo      repository.EmailChangeRepository
+	sender          EmailSender
+	emailNormalizer models.EmailNormalizer
+}
+
+func (svc *emailChangeSvc) RequestEmailChange(ctx context.Context, principal auth.Token, req models.ChangeEmailRequest) error {
+	ctx = svc.withLogger(ctx)
+	err := assertUserAccess(principal, req.UserID, models.WriteUsersPermission)
+	if err != nil {
+		return err
+	}
+
+	user, err := svc.findUser(ctx, req.UserID)
+	if err != nil {
+		return err
+	}
+
+	err = verifyPassword(ctx, svc.hasher, req.Password, user.Credentials.Salt, user.Credentials.PasswordHash)
+	if err != nil {
+		return errInvalidCredentials()
+	}
+
+	newEmail, err := normalizeEmail(svc.emailNormalizer, req.NewEmail)
+	if err != nil {
+		return err
+	}
+
+	if newEmail == user.Email {
+		return httputil.NewError("New email must differ from the current email", http.StatusBadRequest).
+			WithCode(CodeEmailUnchanged).
+			WithMessageKey("email.unchanged")
+	}
+
+	err = svc.assertEmailAvailable(ctx, newEmail)
+	if err != nil {
+		return err
+	}
+
+	token, err := auth.GenSalt(emailTokenLength)
+	if err != nil {
+		logger(ctx).Errorw("Failed generate email token", "err", err)
+		return httputil.NewInternalServerError("Failed to generate token")
+	}
+
+	change := models.NewEmailChange(user, newEmail, auth.HashToken(token), emailConfirmationPeriod)
+	err = svc.changeRepo.Save(ctx, change)
+	if err != nil {
+		logger(ctx).Errorw("Failed to save email change", "userId", user.ID, "err", err)
+		return httputil.NewInternalServerError("Failed to save email change")
+	}
+
+	err = svc.sender.SendEmailChangeConfirmation(newEmail, token)
+	if err != nil {
+		logger(ctx).Errorw("Failed to send email change confirmation", "userId", user.ID, "err", err)
+		return httputil.NewInternalServerError("Failed to send confirmation email")
+	}
+
+	return nil
+}
+
+func (svc *emailChangeSvc) ConfirmEmailChange(ctx context.Context, token string) (models.User, error) {
+	ctx = svc.withLogger(ctx)
+	change, err := svc.changeRepo.FindByConfirmToken(ctx, auth.HashToken(token))
+	if err == repository.ErrNoSuchEmailChange {
+		return models.User{}, errInvalidEmailToken()
+	} else if err != nil {
+		logger(ctx).Errorw("Failed to find email change", "err", err)
+		return models.User{}, httputil.NewInternalServerError("Failed to find email change")
+	}
+
+	if !change.Confirmable() {
+		return models.User{}, errInvalidEmailToken()
+	}
+
+	err = svc.swapEmail(ctx, change.UserID, change.OldEmail, change.NewEmail)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	revertToken, err := auth.GenSalt(emailTokenLength)
+	if err != nil {
+		logger(ctx).Errorw("Failed generate email token", "err", err)
+		return models.User{}, httputil.NewInternalServerError("Failed to generate token")
+	}
+
+	change.ConfirmedAt = time.Now().UTC()
+	change.RevertTokenHash = auth.HashToken(revertToken)
+	change.RevertableUntil = change.ConfirmedAt.Add(emailRevertPeriod)
+	err = svc.changeRepo.Update(ctx, change)
+	if err != nil {
+		logger(ctx).Errorw("Failed to update email change", "changeId", change.ID, "err", err)
+		return models.User{}, httputil.NewInternalServerError("Failed to update email change")
+	}
+
+	err = svc.sender.SendEmailChangeNotice(change.OldEmail, change.NewEmail, revertToken)
+	if err != nil {
+		logger(ctx).Errorw("Failed to send email change notice", "changeId", change.ID, "err", err)
+	}
+
+	return svc.findUser(ctx, change.UserID)
+}
+
+func (svc *emailChangeSvc) RevertEmailChange(ctx context.Context, token string) (models.User, error) {
+	ctx = svc.withLogger(ctx)
+	change, err := svc.changeRepo.FindByRevertToken(ctx, auth.HashToken(token))
+	if err == repository.ErrNoSuchEmailChange {
+		return models.User{}, errInvalidEmailToken()
+	} else if err != nil {
+		logger(ctx).Errorw("Failed to find email change", "err", err)
+		return models.User{}, httputil.NewInternalServerError("Failed to find email change")
+	}
+
+	if !change.Revertable() {
+		return models.User{}, errInvalidEmailToken()
+	}
+
+	err = svc.swapEmail(ctx, change.UserID, change.NewEmail, change.OldEmail)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	change.RevertedAt = time.Now().UTC()
+	err = svc.changeRepo.Update(ctx, change)
+	if err != nil {
+		logger(ctx).Errorw("Failed to update email change", "changeId", change.ID, "err", err)
+		return models.User{}, httputil.NewInternalServerError("Failed to update email change")
+	}
+
+	return svc.findUser(ctx, change.UserID)
+}
+
+func (svc *emailChangeSvc) swapEmail(ctx context.Context, userID, from, to string) error {
+	err := svc.userRepo.ChangeEmail(ctx, userID, from, to)
+	if err == repository.ErrUserExists {
+		return errUserAlreadyExists()
+	} else if err == repository.ErrVersionConflict {
+		return errVersionConflict()
+	} else if err == repository.ErrNoSuchUser {
+		return errUserNotFound()
+	} else if err != nil {
+		logger(ctx).Errorw("Failed to change email", "userId", userID, "err", err)
+		return httputil.NewInternalServerError("Failed to change email")
+	}
+
+	return nil
+}
+
+func (svc *emailChangeSvc) assertEmailAvailable(ctx context.Context, email string) error {
+	_, err := svc.userRepo.FindByEmail(ctx, email)
+	if err == nil {
+		return errUserAlreadyExists()
+	} else if err != repository.ErrNoSuchUser {
+		logger(ctx).Errorw("Failed find user by email", "err", err)
+		return httputil.NewInternalServerError("Failed to get user")
+	}
+
+	return nil
+}
+
+func (svc *emailChangeSvc) findUser(ctx context.Context, id string) (models.User, error) {
+	return findUser(ctx, svc.userRepo, id)
+}
+
+func errInvalidEmailToken() error {
+	return httputil.NewError("Invalid or expired token", http.StatusBadRequest).
+		WithCode(CodeInvalidEmailToken).
+		WithMessageKey("email.invalidToken")
+}
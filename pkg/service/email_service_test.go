@@ -0,0 +1,284 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/CzarSimon/user-service/pkg/auth"
+	"github.com/CzarSimon/user-service/pkg/id"
+	"github.com/CzarSimon/user-service/pkg/models"
+	"github.com/CzarSimon/user-service/pkg/repository"
+	"github.com/CzarSimon/user-service/pkg/repository/repotest"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockEmailSender struct {
+	confirmationTo    string
+	confirmationToken string
+	noticeTo          string
+	noticeNewEmail    string
+	noticeToken       string
+}
+
+func (s *mockEmailSender) SendEmailChangeConfirmation(to, token string) error {
+	s.confirmationTo = to
+	s.confirmationToken = token
+	return nil
+}
+
+func (s *mockEmailSender) SendEmailChangeNotice(to, newEmail, revertToken string) error {
+	s.noticeTo = to
+	s.noticeNewEmail = newEmail
+	s.noticeToken = revertToken
+	return nil
+}
+
+func Test_emailChangeSvc_RequestEmailChange(t *testing.T) {
+	user := testUser()
+
+	tests := []struct {
+		name      string
+		userRepo  *repotest.MockUserRepo
+		principal auth.Token
+		req       models.ChangeEmailRequest
+		wantErr   int
+	}{
+		{
+			name: "happy-path",
+			userRepo: &repotest.MockUserRepo{
+				FindUser:       user,
+				FindByEmailErr: repository.ErrNoSuchUser,
+			},
+			principal: auth.Token{Subject: user.ID, Role: models.UserRole},
+			req: models.ChangeEmailRequest{
+				UserID:   user.ID,
+				NewEmail: "new@mail.com",
+				Password: "secret-drowssap",
+			},
+		},
+		{
+			name: "sad-path-invalid-email",
+			userRepo: &repotest.MockUserRepo{
+				FindUser:       user,
+				FindByEmailErr: repository.ErrNoSuchUser,
+			},
+			principal: auth.Token{Subject: user.ID, Role: models.UserRole},
+			req: models.ChangeEmailRequest{
+				UserID:   user.ID,
+				NewEmail: "new-at-mail.com",
+				Password: "secret-drowssap",
+			},
+			wantErr: http.StatusBadRequest,
+		},
+		{
+			name: "sad-path-same-email-different-case",
+			userRepo: &repotest.MockUserRepo{
+				FindUser:       user,
+				FindByEmailErr: repository.ErrNoSuchUser,
+			},
+			principal: auth.Token{Subject: user.ID, Role: models.UserRole},
+			req: models.ChangeEmailRequest{
+				UserID:   user.ID,
+				NewEmail: " MAIL@mail.com",
+				Password: "secret-drowssap",
+			},
+			wantErr: http.StatusBadRequest,
+		},
+		{
+			name: "sad-path-wrong-password",
+			userRepo: &repotest.MockUserRepo{
+				FindUser:       user,
+				FindByEmailErr: repository.ErrNoSuchUser,
+			},
+			principal: auth.Token{Subject: user.ID, Role: models.UserRole},
+			req: models.ChangeEmailRequest{
+				UserID:   user.ID,
+				NewEmail: "new@mail.com",
+				Password: "wrong-password",
+			},
+			wantErr: http.StatusUnauthorized,
+		},
+		{
+			name: "sad-path-email-taken",
+			userRepo: &repotest.MockUserRepo{
+				FindUser: user,
+			},
+			principal: auth.Token{Subject: user.ID, Role: models.UserRole},
+			req: models.ChangeEmailRequest{
+				UserID:   user.ID,
+				NewEmail: "new@mail.com",
+				Password: "secret-drowssap",
+			},
+			wantErr: http.StatusConflict,
+		},
+		{
+			name: "sad-path-same-email",
+			userRepo: &repotest.MockUserRepo{
+				FindUser:       user,
+				FindByEmailErr: repository.ErrNoSuchUser,
+			},
+			principal: auth.Token{Subject: user.ID, Role: models.UserRole},
+			req: models.ChangeEmailRequest{
+				UserID:   user.ID,
+				NewEmail: user.Email,
+				Password: "secret-drowssap",
+			},
+			wantErr: http.StatusBadRequest,
+		},
+		{
+			name: "sad-path-other-user",
+			userRepo: &repotest.MockUserRepo{
+				FindUser:       user,
+				FindByEmailErr: repository.ErrNoSuchUser,
+			},
+			principal: auth.Token{Subject: id.New(), Role: models.UserRole},
+			req: models.ChangeEmailRequest{
+				UserID:   user.ID,
+				NewEmail: "new@mail.com",
+				Password: "secret-drowssap",
+			},
+			wantErr: http.StatusForbidden,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changeRepo := &repotest.MockEmailChangeRepo{}
+			sender := &mockEmailSender{}
+			svc := NewEmailChangeService(hasher, tt.userRepo, changeRepo, sender)
+
+			err := svc.RequestEmailChange(context.Background(), tt.principal, tt.req)
+			if tt.wantErr != 0 {
+				assertStatus(t, tt.wantErr, err)
+				assert.Equal(t, 0, changeRepo.SaveInvocations)
+				assert.Equal(t, "", sender.confirmationToken)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.req.NewEmail, sender.confirmationTo)
+			assert.NotEqual(t, "", sender.confirmationToken)
+
+			saved := changeRepo.SaveArg
+			assert.Equal(t, user.ID, saved.UserID)
+			assert.Equal(t, user.Email, saved.OldEmail)
+			assert.Equal(t, tt.req.NewEmail, saved.NewEmail)
+			assert.Equal(t, auth.HashToken(sender.confirmationToken), saved.ConfirmTokenHash)
+			assert.True(t, saved.Confirmable())
+		})
+	}
+}
+
+func Test_emailChangeSvc_ConfirmAndRevert(t *testing.T) {
+	assert := assert.New(t)
+	user := testUser()
+	change := models.NewEmailChange(user, "new@mail.com", auth.HashToken("confirm-token"), time.Hour)
+
+	userRepo := &repotest.MockUserRepo{FindUser: user}
+	changeRepo := &repotest.MockEmailChangeRepo{FindByConfirmTokenChange: change}
+	sender := &mockEmailSender{}
+	svc := NewEmailChangeService(hasher, userRepo, changeRepo, sender)
+
+	_, err := svc.ConfirmEmailChange(context.Background(), "confirm-token")
+	assert.NoError(err)
+	assert.Equal(auth.HashToken("confirm-token"), changeRepo.FindByConfirmTokenArg)
+	assert.Equal(user.ID, userRepo.ChangeEmailIDArg)
+	assert.Equal(user.Email, userRepo.ChangeEmailOldArg)
+	assert.Equal("new@mail.com", userRepo.ChangeEmailNewArg)
+	assert.Equal(user.Email, sender.noticeTo)
+	assert.Equal("new@mail.com", sender.noticeNewEmail)
+	assert.NotEqual("", sender.noticeToken)
+
+	confirmed := changeRepo.UpdateArg
+	assert.False(confirmed.Confirmable())
+	assert.True(confirmed.Revertable())
+	assert.Equal(auth.HashToken(sender.noticeToken), confirmed.RevertTokenHash)
+
+	// Confirmed changes should not be confirmable again.
+	changeRepo.FindByConfirmTokenChange = confirmed
+	_, err = svc.ConfirmEmailChange(context.Background(), "confirm-token")
+	assertStatus(t, http.StatusBadRequest, err)
+	assertCode(t, CodeInvalidEmailToken, err)
+
+	userRepo.UnsetArgs()
+	changeRepo.FindByRevertTokenChange = confirmed
+	_, err = svc.RevertEmailChange(context.Background(), sender.noticeToken)
+	assert.NoError(err)
+	assert.Equal(user.ID, userRepo.ChangeEmailIDArg)
+	assert.Equal("new@mail.com", userRepo.ChangeEmailOldArg)
+	assert.Equal(user.Email, userRepo.ChangeEmailNewArg)
+	assert.False(changeRepo.UpdateArg.Revertable())
+
+	// Reverted changes should not be revertable again.
+	changeRepo.FindByRevertTokenChange = changeRepo.UpdateArg
+	_, err = svc.RevertEmailChange(context.Background(), sender.noticeToken)
+	assertStatus(t, http.StatusBadRequest, err)
+}
+
+func Test_emailChangeSvc_ConfirmEmailChange_Errors(t *testing.T) {
+	user := testUser()
+	change := models.NewEmailChange(user, "new@mail.com", auth.HashToken("confirm-token"), time.Hour)
+	expired := models.NewEmailChange(user, "new@mail.com", auth.HashToken("confirm-token"), -time.Hour)
+
+	tests := []struct {
+		name       string
+		userRepo   *repotest.MockUserRepo
+		changeRepo *repotest.MockEmailChangeRepo
+		wantErr    int
+	}{
+		{
+			name:     "sad-path-unknown-token",
+			userRepo: &repotest.MockUserRepo{FindUser: user},
+			changeRepo: &repotest.MockEmailChangeRepo{
+				FindByConfirmTokenErr: repository.ErrNoSuchEmailChange,
+			},
+			wantErr: http.StatusBadRequest,
+		},
+		{
+			name:     "sad-path-expired-token",
+			userRepo: &repotest.MockUserRepo{FindUser: user},
+			changeRepo: &repotest.MockEmailChangeRepo{
+				FindByConfirmTokenChange: expired,
+			},
+			wantErr: http.StatusBadRequest,
+		},
+		{
+			name: "sad-path-email-taken",
+			userRepo: &repotest.MockUserRepo{
+				FindUser:       user,
+				ChangeEmailErr: repository.ErrUserExists,
+			},
+			changeRepo: &repotest.MockEmailChangeRepo{
+				FindByConfirmTokenChange: change,
+			},
+			wantErr: http.StatusConflict,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sender := &mockEmailSender{}
+			svc := NewEmailChangeService(hasher, tt.userRepo, tt.changeRepo, sender)
+
+			_, err := svc.ConfirmEmailChange(context.Background(), "confirm-token")
+			assertStatus(t, tt.wantErr, err)
+			assert.Equal(t, 0, tt.changeRepo.UpdateInvocations)
+			assert.Equal(t, "", sender.noticeToken)
+		})
+	}
+}
+
+func testUser() models.User {
+	return models.User{
+		ID:                id.New(),
+		Email:             "mail@mail.com",
+		Surname:           "Tester",
+		MiddleAndLastName: "McTest",
+		Role:              models.UserRole,
+		CreatedAt:         time.Now().UTC(),
+		Credentials: models.Credentials{
+			PasswordHash: "SCRYPT$32768$1$8$64$e741da717da8b684c6b512704e1dbcb999f38bf3b3ccf4729166b37da305e9770927c90ec6c6b1537d61a2a10d6a8295c23c46276e4d0e0019ed4c95fc238270",
+			Salt:         "94f61dca8108138e98580d174a6eec493b4be51ca748109862",
+		},
+	}
+}
@@ -11,6 +11,19 @@ require (
 	github.com/CzarSimon/user-service/pkg/repository/repotest v0.0.0-20190423194243-bb8b9a2c67f2
 	github.com/mimir-news/pkg v0.0.0-20190121200947-8a9f96ba3037
 	github.com/pkg/errors v0.8.1 // indirect
-	github.com/stretchr/testify v1.3.0
+	github.com/prometheus/client_golang v0.9.2
+	github.com/stretchr/testify v1.7.0
+	go.opentelemetry.io/otel v1.0.0
+	go.opentelemetry.io/otel/sdk v1.0.0
+	go.opentelemetry.io/otel/trace v1.0.0
 	go.uber.org/zap v1.9.1
 )
+
+replace (
+	github.com/CzarSimon/user-service/pkg/auth => ../auth
+	github.com/CzarSimon/user-service/pkg/httputil => ../httputil
+	github.com/CzarSimon/user-service/pkg/id => ../id
+	github.com/CzarSimon/user-service/pkg/models => ../models
+	github.com/CzarSimon/user-service/pkg/repository => ../repository
+	github.com/CzarSimon/user-service/pkg/repository/repotest => ../repository/repotest
+)
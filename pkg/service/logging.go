@@ -0,0 +1,28 @@
+package service
+
+import (
+	"context"
+
+	"github.com/CzarSimon/user-service/pkg/httputil"
+	"go.uber.org/zap"
+)
+
+// logger returns the request scoped logger carried by a context, see httputil.LoggerFromContext.
+func logger(ctx context.Context) *zap.SugaredLogger {
+	return httputil.LoggerFromContext(ctx).Sugar().With("package", "pkg/service")
+}
+
+// logging is embedded in the services to hold the logger injected into them, which is used
+// when they are called outside of a request, so that the context carries no request scoped logger.
+type logging struct {
+	log *zap.Logger
+}
+
+// withLogger returns a copy of a context which carries the injected logger, unless the context
+// already carries a request scoped logger.
+func (l logging) withLogger(ctx context.Context) context.Context {
+	if l.log == nil {
+		return ctx
+	}
+	return httputil.ContextWithLogger(ctx, httputil.LoggerFromContextOr(ctx, l.log))
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"strings"
+	"time"
+
+	"github.com/CzarSimon/user-service/pkg/auth"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Login results.
+const (
+	loginSuccess = "success"
+	loginFailure = "failure"
+)
+
+// Metrics prometheus metrics of signups, logins, password changes, issued tokens and password hashing.
+// A nil *Metrics records nothing.
+type Metrics struct {
+	signups         prometheus.Counter
+	logins          *prometheus.CounterVec
+	passwordChanges prometheus.Counter
+	tokensIssued    *prometheus.CounterVec
+	hashDuration    *prometheus.HistogramVec
+}
+
+// NewMetrics creates Metrics and registers them in a prometheus.Registerer.
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	m := &Metrics{
+		signups: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "user_service_signups_total",
+			Help: "Number of successful signups.",
+		}),
+		logins: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "user_service_logins_total",
+			Help: "Number of login attempts by result and failure reason.",
+		}, []string{"result", "reason"}),
+		passwordChanges: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "user_service_password_changes_total",
+			Help: "Number of successful password changes.",
+		}),
+		tokensIssued: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "user_service_tokens_issued_total",
+			Help: "Number of issued tokens by role.",
+		}, []string{"role"}),
+		hashDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "user_service_hash_duration_seconds",
+			Help:    "Duration of hashing and verifying passwords by algorithm.",
+			Buckets: []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"algorithm", "operation"}),
+	}
+
+	reg.MustRegister(m.signups, m.logins, m.passwordChanges, m.tokensIssued, m.hashDuration)
+	return m
+}
+
+// WithMetrics records metrics of the UserService and instruments its hasher and issuer.
+func WithMetrics(m *Metrics) UserServiceOption {
+	return func(svc *userSvc) {
+		svc.metrics = m
+		svc.hasher = m.InstrumentHasher(svc.hasher)
+		svc.issuer = m.InstrumentIssuer(svc.issuer)
+	}
+}
+
+// InstrumentHasher wraps an auth.Hasher so that the duration of hashing is recorded.
+func (m *Metrics) InstrumentHasher(hasher auth.Hasher) auth.Hasher {
+	if m == nil {
+		return hasher
+	}
+
+	return &instrumentedHasher{
+		hasher:   hasher,
+		duration: m.hashDuration,
+	}
+}
+
+// InstrumentIssuer wraps an auth.Issuer so that issued tokens are counted.
+func (m *Metrics) InstrumentIssuer(issuer auth.Issuer) auth.Issuer {
+	if m == nil {
+		return issuer
+	}
+
+	return &instrumentedIssuer{
+		issuer:       issuer,
+		tokensIssued: m.tokensIssued,
+	}
+}
+
+func (m *Metrics) recordSignup(err error) {
+	if m == nil || err != nil {
+		return
+	}
+	m.signups.Inc()
+}
+
+// recordLogin records the result of a login attempt, using the error code as the reason for failures.
+func (m *Metrics) recordLogin(err error) {
+	if m == nil {
+		return
+	}
+
+	if err == nil {
+		m.logins.WithLabelValues(loginSuccess, "").Inc()
+		return
+	}
+
+	m.logins.WithLabelValues(loginFailure, strings.ToLower(errorCode(err))).Inc()
+}
+
+func (m *Metrics) recordPasswordChange(err error) {
+	if m == nil || err != nil {
+		return
+	}
+	m.passwordChanges.Inc()
+}
+
+type instrumentedHasher struct {
+	hasher   auth.Hasher
+	duration *prometheus.HistogramVec
+}
+
+func (h *instrumentedHasher) Hash(plaintext, salt string) (string, error) {
+	start := time.Now()
+	hash, err := h.hasher.Hash(plaintext, salt)
+	if err == nil {
+		h.duration.WithLabelValues(hashAlgorithm(hash), "hash").Observe(time.Since(start).Seconds())
+	}
+	return hash, err
+}
+
+func (h *instrumentedHasher) Verify(plaintext, salt, hash string) error {
+	start := time.Now()
+	err := h.hasher.Verify(plaintext, salt, hash)
+	h.duration.WithLabelValues(hashAlgorithm(hash), "verify").Observe(time.Since(start).Seconds())
+	return err
+}
+
+// hashAlgorithm returns the algorithm of an encoded hash, e.g. scrypt for SCRYPT$32768$1$8$64$...
+func hashAlgorithm(hash string) string {
+	parts := strings.SplitN(hash, "$", 2)
+	if len(parts) < 2 {
+		return "unknown"
+	}
+	return strings.ToLower(parts[0])
+}
+
+type instrumentedIssuer struct {
+	issuer       auth.Issuer
+	tokensIssued *prometheus.CounterVec
+}
+
+func (i *instrumentedIssuer) Issue(sub, role string, permissions ...string) (string, error) {
+	token, err := i.issuer.Issue(sub, role, permissions...)
+	if err == nil {
+		i.tokensIssued.WithLabelValues(role).Inc()
+	}
+	return token, err
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/CzarSimon/user-service/pkg/auth"
+	"github.com/CzarSimon/user-service/pkg/models"
+	"github.com/CzarSimon/user-service/pkg/repository"
+	"github.com/CzarSimon/user-service/pkg/repository/repotest"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	assert := assert.New(t)
+	reg := prometheus.NewRegistry()
+	metrics := NewMetrics(reg)
+	user := testUser()
+
+	signupRepo := &repotest.MockUserRepo{FindByEmailErr: repository.ErrNoSuchUser}
+	svc := NewUserService(hasher, issuer, signupRepo, roleRepo, WithMetrics(metrics))
+	_, err := svc.SignUp(context.Background(), models.SignupRequest{
+		Email:             "new@mail.com",
+		Password:          "secret-drowssap",
+		RepeatPassword:    "secret-drowssap",
+		Surname:           "Tester",
+		MiddleAndLastName: "McTest",
+	})
+	assert.NoError(err)
+	assert.Equal(float64(1), testutil.ToFloat64(metrics.signups))
+
+	loginRepo := &repotest.MockUserRepo{FindByEmailUser: user, FindUser: user}
+	svc = NewUserService(hasher, issuer, loginRepo, roleRepo, WithMetrics(metrics))
+	_, err = svc.Login(context.Background(), models.LoginRequest{Email: user.Email, Password: "secret-drowssap"})
+	assert.NoError(err)
+	_, err = svc.Login(context.Background(), models.LoginRequest{Email: user.Email, Password: "wrong-password"})
+	assert.Error(err)
+	_, err = svc.Login(context.Background(), models.LoginRequest{Email: user.Email, Password: "wrong-password"})
+	assert.Error(err)
+	_, err = svc.Login(context.Background(), models.LoginRequest{Email: user.Email})
+	assert.Error(err)
+
+	assert.Equal(float64(1), testutil.ToFloat64(metrics.logins.WithLabelValues(loginSuccess, "")))
+	assert.Equal(float64(2), testutil.ToFloat64(metrics.logins.WithLabelValues(loginFailure, "invalid_credentials")))
+	assert.Equal(float64(1), testutil.ToFloat64(metrics.logins.WithLabelValues(loginFailure, "validation_failed")))
+
+	_, err = svc.ChangePassword(context.Background(), auth.Token{Subject: user.ID, Role: models.UserRole}, models.ChangePasswordRequest{
+		UserID:         user.ID,
+		OldPassword:    "secret-drowssap",
+		NewPassword:    "new-secret-drowssap",
+		RepeatPassword: "new-secret-drowssap",
+	})
+	assert.NoError(err)
+	assert.Equal(float64(1), testutil.ToFloat64(metrics.passwordChanges))
+
+	// Signup, successful login and password change.
+	assert.Equal(float64(3), testutil.ToFloat64(metrics.tokensIssued.WithLabelValues(models.UserRole)))
+
+	families, err := reg.Gather()
+	assert.NoError(err)
+	hashCounts := make(map[string]uint64)
+	for _, family := range families {
+		if family.GetName() != "user_service_hash_duration_seconds" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			operation := metric.GetLabel()[1].GetValue()
+			assert.Equal("scrypt", metric.GetLabel()[0].GetValue())
+			hashCounts[operation] = metric.GetHistogram().GetSampleCount()
+		}
+	}
+	// Signup and password change hashes, three logins and one password change verifications.
+	assert.Equal(uint64(2), hashCounts["hash"])
+	assert.Equal(uint64(4), hashCounts["verify"])
+}
+
+func Test_hashAlgorithm(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal("scrypt", hashAlgorithm(testUser().Credentials.PasswordHash))
+	assert.Equal("pbkdf2", hashAlgorithm("PBKDF2$10000$64$abcdef"))
+	assert.Equal("unknown", hashAlgorithm("abcdef"))
+}
@@ -0,0 +1,783 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/CzarSimon/user-service/pkg/auth"
+	"github.com/CzarSimon/user-service/pkg/httputil"
+	"github.com/CzarSimon/user-service/pkg/models"
+	"github.com/CzarSimon/user-service/pkg/repository"
+	"go.uber.org/zap"
+)
+
+// Error codes returned by the OAuth service. They are the error codes of
+// RFC 6749 in upper case, so that the api can return them in standard form.
+const (
+	CodeInvalidRequest          = "INVALID_REQUEST"
+	CodeInvalidClient           = "INVALID_CLIENT"
+	CodeInvalidGrant            = "INVALID_GRANT"
+	CodeInvalidScope            = "INVALID_SCOPE"
+	CodeUnsupportedGrantType    = "UNSUPPORTED_GRANT_TYPE"
+	CodeUnsupportedResponseType = "UNSUPPORTED_RESPONSE_TYPE"
+)
+
+// Lifetimes and lengths of OAuth credentials.
+const (
+	authorizationCodeLifetime = time.Minute
+	refreshTokenLifetime      = 30 * 24 * time.Hour
+	oauthTokenLength          = 32
+	clientSecretLength        = 32
+	clientSaltLength          = 25
+)
+
+// OAuthService OAuth2 authorization server issuing tokens to registered clients on behalf of users,
+// with the authorization code grant protected by PKCE and rotating refresh tokens.
+//
+// The permissions of tokens issued to clients are the permissions of the user limited to the granted scopes.
+type OAuthService interface {
+	RegisterClient(ctx context.Context, principal auth.Token, req models.RegisterClientRequest) (models.ClientRegistration, error)
+	// Authorize validates an authorization request and returns what the user is asked to consent to.
+	Authorize(ctx context.Context, principal auth.Token, req models.AuthorizeRequest) (models.ConsentPrompt, error)
+	// Consent handles the answer of a user to a consent prompt. Returns the uri to redirect the user to,
+	// which carries either an authorization code or the access_denied error.
+	Consent(ctx context.Context, principal auth.Token, req models.AuthorizeRequest) (string, error)
+	// Token exchanges an authorization code or a refresh token for new tokens,
+	// or issues a token to a service account presenting its client credentials.
+	Token(ctx context.Context, req models.TokenRequest) (models.TokenResponse, error)
+	// EndSession logs a user out on request of a client, revoking the session identified by the ID token hint.
+	// Returns the uri to redirect the user to after logout, if any.
+	EndSession(ctx context.Context, req models.EndSessionRequest) (string, error)
+	// UserInfo returns the claims about the user a client has been granted the openid scope for.
+	UserInfo(ctx context.Context, principal auth.Token) (models.UserInfo, error)
+}
+
+// OAuthServiceOption configures optional dependencies of an OAuthService.
+type OAuthServiceOption func(*oauthSvc)
+
+// WithOAuthSessions records a session in a SessionRepository for every access token issued to a client,
+// which is required for them to be accepted by a verifier created by NewSessionVerifier.
+func WithOAuthSessions(sessionRepo repository.SessionRepository) OAuthServiceOption {
+	return func(svc *oauthSvc) {
+		svc.sessionRepo = traceSessionRepo(sessionRepo)
+	}
+}
+
+// WithServiceAccounts enables the client credentials grant, which issues tokens to service accounts
+// acting on their own behalf. Their tokens hold the permissions of the account limited to the requested scopes.
+func WithServiceAccounts(accountRepo repository.ServiceAccountRepository) OAuthServiceOption {
+	return func(svc *oauthSvc) {
+		svc.accountRepo = traceServiceAccountRepo(accountRepo)
+	}
+}
+
+// WithOpenID makes the service an OpenID Connect provider, issuing ID tokens
+// to clients which have been granted the openid scope.
+func WithOpenID(idIssuer auth.IDTokenIssuer) OAuthServiceOption {
+	return func(svc *oauthSvc) {
+		svc.idIssuer = idIssuer
+	}
+}
+
+// WithOAuthLogger sets the logger used when the OAuth service is called outside of a request.
+func WithOAuthLogger(log *zap.Logger) OAuthServiceOption {
+	return func(svc *oauthSvc) {
+		svc.log = log
+	}
+}
+
+// NewOAuthService creates a new OAuthService. Client secrets are hashed with the given hasher.
+func NewOAuthService(
+	hasher auth.Hasher,
+	issuer auth.GrantIssuer,
+	userRepo repository.UserRepository,
+	roleRepo repository.RoleRepository,
+	clientRepo repository.OAuthClientRepository,
+	codeRepo repository.AuthorizationCodeRepository,
+	tokenRepo repository.RefreshTokenRepository,
+	opts ...OAuthServiceOption,
+) OAuthService {
+	svc := &oauthSvc{
+		hasher:     hasher,
+		issuer:     issuer,
+		userRepo:   traceUserRepo(userRepo),
+		roleRepo:   traceRoleRepo(roleRepo),
+		clientRepo: traceOAuthClientRepo(clientRepo),
+		codeRepo:   traceAuthorizationCodeRepo(codeRepo),
+		tokenRepo:  traceRefreshTokenRepo(tokenRepo),
+	}
+
+	for _, opt := range opts {
+		opt(svc)
+	}
+	return svc
+}
+
+type oauthSvc struct {
+	logging
+	hasher      auth.Hasher
+	issuer      auth.GrantIssuer
+	userRepo    repository.UserRepository
+	roleRepo    repository.RoleRepository
+	clientRepo  repository.OAuthClientRepository
+	codeRepo    repository.AuthorizationCodeRepository
+	tokenRepo   repository.RefreshTokenRepository
+	sessionRepo repository.SessionRepository
+	idIssuer    auth.IDTokenIssuer
+	accountRepo repository.ServiceAccountRepository
+}
+
+// authorization what a user has authorized a client to do, which is carried
+// over from authorization codes to the refresh tokens issued for them.
+type authorization struct {
+	userID    string
+	scopes    []string
+	nonce     string
+	sessionID string
+	authTime  time.Time
+}
+
+func (svc *oauthSvc) RegisterClient(ctx context.Context, principal auth.Token, req models.RegisterClientRequest) (models.ClientRegistration, error) {
+	ctx = svc.withLogger(ctx)
+	err := assertPermission(principal, models.WriteClientsPermission)
+	if err != nil {
+		return models.ClientRegistration{}, err
+	}
+
+	err = validate(req)
+	if err != nil {
+		return models.ClientRegistration{}, err
+	}
+
+	var secret, salt, secretHash string
+	if req.Type == models.ConfidentialClient {
+		secret, salt, secretHash, err = createClientSecret(ctx, svc.hasher)
+		if err != nil {
+			return models.ClientRegistration{}, err
+		}
+	}
+
+	client := req.Client(secretHash, salt)
+	err = svc.clientRepo.Save(ctx, client)
+	if err != nil {
+		logger(ctx).Errorw("Failed to save client", "err", err)
+		return models.ClientRegistration{}, httputil.NewInternalServerError("Failed to save client")
+	}
+
+	return models.ClientRegistration{
+		Client:       client,
+		ClientSecret: secret,
+	}, nil
+}
+
+// createClientSecret generates a client secret along with its salt and hash.
+func createClientSecret(ctx context.Context, hasher auth.Hasher) (string, string, string, error) {
+	secret, err := auth.GenSalt(clientSecretLength)
+	if err != nil {
+		logger(ctx).Errorw("Failed to generate client secret", "err", err)
+		return "", "", "", httputil.NewInternalServerError("Failed to generate client secret")
+	}
+
+	salt, err := auth.GenSalt(clientSaltLength)
+	if err != nil {
+		logger(ctx).Errorw("Failed to generate salt", "err", err)
+		return "", "", "", httputil.NewInternalServerError("Failed to generate salt")
+	}
+
+	hash, err := hashPassword(ctx, hasher, secret, salt)
+	if err != nil {
+		logger(ctx).Errorw("Failed to hash client secret", "err", err)
+		return "", "", "", httputil.NewInternalServerError("Failed to hash client secret")
+	}
+
+	return secret, salt, hash, nil
+}
+
+func (svc *oauthSvc) Authorize(ctx context.Context, principal auth.Token, req models.AuthorizeRequest) (models.ConsentPrompt, error) {
+	ctx = svc.withLogger(ctx)
+	client, scopes, err := svc.authorize(ctx, principal, req)
+	if err != nil {
+		return models.ConsentPrompt{}, err
+	}
+
+	return models.ConsentPrompt{
+		ClientID:   client.ID,
+		ClientName: client.Name,
+		Scopes:     scopes,
+	}, nil
+}
+
+func (svc *oauthSvc) Consent(ctx context.Context, principal auth.Token, req models.AuthorizeRequest) (string, error) {
+	ctx = svc.withLogger(ctx)
+	_, scopes, err := svc.authorize(ctx, principal, req)
+	if err != nil {
+		return "", err
+	}
+
+	if !req.Approved {
+		return redirectURI(req.RedirectURI, req.State, url.Values{"error": {"access_denied"}})
+	}
+
+	user, err := findUser(ctx, svc.userRepo, principal.Subject)
+	if err != nil {
+		return "", err
+	}
+
+	if user.Disabled {
+		return "", errAccountDisabled()
+	}
+
+	if user.IsDeleted() {
+		return "", errAccountDeleted()
+	}
+
+	code, err := auth.GenSalt(oauthTokenLength)
+	if err != nil {
+		logger(ctx).Errorw("Failed to generate authorization code", "err", err)
+		return "", httputil.NewInternalServerError("Failed to generate authorization code")
+	}
+
+	authCode := models.NewAuthorizationCode(auth.HashToken(code), user.ID, req, scopes, authorizationCodeLifetime)
+	authCode.SessionID = principal.ID
+	authCode.AuthTime = principal.CreatedAt
+	err = svc.codeRepo.Save(ctx, authCode)
+	if err != nil {
+		logger(ctx).Errorw("Failed to save authorization code", "clientId", req.ClientID, "err", err)
+		return "", httputil.NewInternalServerError("Failed to save authorization code")
+	}
+
+	return redirectURI(req.RedirectURI, req.State, url.Values{"code": {code}})
+}
+
+// authorize validates an authorization request made by a user, returning the requesting client and the requested scopes.
+// Only users who have logged in directly, rather than through another client or with an api key, may authorize clients.
+func (svc *oauthSvc) authorize(ctx context.Context, principal auth.Token, req models.AuthorizeRequest) (models.OAuthClient, []string, error) {
+	if principal.Subject == "" || !principal.IsDirectLogin() {
+		return models.OAuthClient{}, nil, httputil.ErrForbidden()
+	}
+
+	err := validate(req)
+	if err != nil {
+		return models.OAuthClient{}, nil, err
+	}
+
+	client, err := svc.findClient(ctx, req.ClientID)
+	if err != nil {
+		return models.OAuthClient{}, nil, err
+	}
+
+	if !client.HasRedirectURI(req.RedirectURI) {
+		return models.OAuthClient{}, nil, errInvalidRequest("Redirect uri has not been registered for the client")
+	}
+
+	if req.ResponseType != models.CodeResponseType {
+		return models.OAuthClient{}, nil, httputil.NewError("Unsupported response type", http.StatusBadRequest).
+			WithCode(CodeUnsupportedResponseType).
+			WithMessageKey("oauth.unsupportedResponseType")
+	}
+
+	if req.CodeChallengeMethod != auth.PKCEMethodS256 || !auth.ValidPKCEChallenge(req.CodeChallenge) {
+		return models.OAuthClient{}, nil, errInvalidRequest("A PKCE code challenge with method S256 is required")
+	}
+
+	scopes, err := requestedScopes(client, req.Scope)
+	if err != nil {
+		return models.OAuthClient{}, nil, err
+	}
+
+	return client, scopes, nil
+}
+
+func (svc *oauthSvc) findClient(ctx context.Context, clientID string) (models.OAuthClient, error) {
+	client, err := svc.clientRepo.Find(ctx, clientID)
+	if err == repository.ErrNoSuchClient {
+		return models.OAuthClient{}, errInvalidRequest("Unknown client")
+	} else if err != nil {
+		logger(ctx).Errorw("Failed to find client", "clientId", clientID, "err", err)
+		return models.OAuthClient{}, httputil.NewInternalServerError("Failed to find client")
+	}
+
+	return client, nil
+}
+
+func (svc *oauthSvc) Token(ctx context.Context, req models.TokenRequest) (models.TokenResponse, error) {
+	ctx = svc.withLogger(ctx)
+	if req.GrantType == models.ClientCredentialsGrant && svc.accountRepo != nil {
+		return svc.clientCredentials(ctx, req)
+	}
+
+	client, err := svc.authenticateClient(ctx, req.ClientID, req.ClientSecret)
+	if err != nil {
+		return models.TokenResponse{}, err
+	}
+
+	switch req.GrantType {
+	case models.AuthorizationCodeGrant:
+		return svc.exchangeCode(ctx, client, req)
+	case models.RefreshTokenGrant:
+		return svc.refresh(ctx, client, req)
+	default:
+		return models.TokenResponse{}, httputil.NewError("Unsupported grant type", http.StatusBadRequest).
+			WithCode(CodeUnsupportedGrantType).
+			WithMessageKey("oauth.unsupportedGrantType")
+	}
+}
+
+// authenticateClient finds the client making a token request. Confidential clients must present their secret.
+func (svc *oauthSvc) authenticateClient(ctx context.Context, clientID, secret string) (models.OAuthClient, error) {
+	if clientID == "" {
+		return models.OAuthClient{}, errInvalidClient()
+	}
+
+	client, err := svc.clientRepo.Find(ctx, clientID)
+	if err == repository.ErrNoSuchClient {
+		return models.OAuthClient{}, errInvalidClient()
+	} else if err != nil {
+		logger(ctx).Errorw("Failed to find client", "clientId", clientID, "err", err)
+		return models.OAuthClient{}, httputil.NewInternalServerError("Failed to find client")
+	}
+
+	if !client.IsConfidential() {
+		return client, nil
+	}
+
+	err = verifyPassword(ctx, svc.hasher, secret, client.Salt, client.SecretHash)
+	if err != nil {
+		return models.OAuthClient{}, errInvalidClient()
+	}
+
+	return client, nil
+}
+
+// clientCredentials issues an access token to a service account as given in RFC 6749 section 4.4.
+// Scopes default to every permission of the account. No refresh token is issued, as the account can
+// request a new token with its credentials at any time.
+func (svc *oauthSvc) clientCredentials(ctx context.Context, req models.TokenRequest) (models.TokenResponse, error) {
+	account, err := svc.authenticateServiceAccount(ctx, req.ClientID, req.ClientSecret)
+	if err != nil {
+		return models.TokenResponse{}, err
+	}
+
+	scopes := models.ParseScope(req.Scope)
+	if len(scopes) == 0 {
+		scopes = account.Permissions
+	} else if !account.AllowsScopes(scopes) {
+		return models.TokenResponse{}, errInvalidScope()
+	}
+
+	grant := auth.Grant{ClientID: account.ID, Scopes: scopes, PrincipalType: auth.ServicePrincipal}
+	accessToken, err := issueGrant(ctx, svc.issuer, account.ID, models.ServiceRole, grant, scopes...)
+	if err != nil {
+		logger(ctx).Errorw("Failed issue token", "clientId", account.ID, "err", err)
+		return models.TokenResponse{}, httputil.NewInternalServerError("Failed to generate token")
+	}
+
+	err = createSession(ctx, svc.sessionRepo, account.ID, accessToken)
+	if err != nil {
+		return models.TokenResponse{}, err
+	}
+
+	return models.TokenResponse{
+		AccessToken: accessToken,
+		TokenType:   models.BearerTokenType,
+		ExpiresIn:   int(svc.issuer.TokenAge().Seconds()),
+		Scope:       models.FormatScope(scopes),
+	}, nil
+}
+
+// authenticateServiceAccount finds a service account and verifies its secret.
+func (svc *oauthSvc) authenticateServiceAccount(ctx context.Context, clientID, secret string) (models.ServiceAccount, error) {
+	if clientID == "" || secret == "" {
+		return models.ServiceAccount{}, errInvalidClient()
+	}
+
+	account, err := svc.accountRepo.Find(ctx, clientID)
+	if err == repository.ErrNoSuchServiceAccount {
+		return models.ServiceAccount{}, errInvalidClient()
+	} else if err != nil {
+		logger(ctx).Errorw("Failed to find service account", "clientId", clientID, "err", err)
+		return models.ServiceAccount{}, httputil.NewInternalServerError("Failed to find service account")
+	}
+
+	err = verifyPassword(ctx, svc.hasher, secret, account.Salt, account.SecretHash)
+	if err != nil {
+		return models.ServiceAccount{}, errInvalidClient()
+	}
+
+	return account, nil
+}
+
+// exchangeCode exchanges an authorization code for tokens. Codes can only be used once,
+// by the client they were issued to, with the same redirect uri and the matching PKCE code verifier.
+func (svc *oauthSvc) exchangeCode(ctx context.Context, client models.OAuthClient, req models.TokenRequest) (models.TokenResponse, error) {
+	if req.Code == "" || req.CodeVerifier == "" {
+		return models.TokenResponse{}, errInvalidRequest("Code and code verifier are required")
+	}
+
+	code, err := svc.codeRepo.Consume(ctx, auth.HashToken(req.Code))
+	if err == repository.ErrNoSuchAuthorizationCode {
+		return models.TokenResponse{}, errInvalidGrant()
+	} else if err != nil {
+		logger(ctx).Errorw("Failed to consume authorization code", "clientId", client.ID, "err", err)
+		return models.TokenResponse{}, httputil.NewInternalServerError("Failed to find authorization code")
+	}
+
+	if code.ClientID != client.ID || code.RedirectURI != req.RedirectURI || code.IsExpired() {
+		return models.TokenResponse{}, errInvalidGrant()
+	}
+
+	if !auth.VerifyPKCE(req.CodeVerifier, code.CodeChallenge) {
+		return models.TokenResponse{}, errInvalidGrant()
+	}
+
+	return svc.issueTokens(ctx, client, authorization{
+		userID:    code.UserID,
+		scopes:    code.Scopes,
+		nonce:     code.Nonce,
+		sessionID: code.SessionID,
+		authTime:  code.AuthTime,
+	})
+}
+
+// refresh exchanges a refresh token for new tokens, revoking the used refresh token.
+// The scopes of the new tokens may be narrowed but never widened.
+func (svc *oauthSvc) refresh(ctx context.Context, client models.OAuthClient, req models.TokenRequest) (models.TokenResponse, error) {
+	if req.RefreshToken == "" {
+		return models.TokenResponse{}, errInvalidRequest("Refresh token is required")
+	}
+
+	tokenHash := auth.HashToken(req.RefreshToken)
+	token, err := svc.tokenRepo.Find(ctx, tokenHash)
+	if err == repository.ErrNoSuchRefreshToken {
+		return models.TokenResponse{}, errInvalidGrant()
+	} else if err != nil {
+		logger(ctx).Errorw("Failed to find refresh token", "clientId", client.ID, "err", err)
+		return models.TokenResponse{}, httputil.NewInternalServerError("Failed to find refresh token")
+	}
+
+	if token.ClientID != client.ID || !token.IsValid() {
+		return models.TokenResponse{}, errInvalidGrant()
+	}
+
+	scopes := token.Scopes
+	if req.Scope != "" {
+		scopes = models.ParseScope(req.Scope)
+		if !token.AllowsScopes(scopes) {
+			return models.TokenResponse{}, errInvalidScope()
+		}
+	}
+
+	err = svc.assertSessionActive(ctx, token.SessionID)
+	if err != nil {
+		return models.TokenResponse{}, err
+	}
+
+	err = svc.tokenRepo.Consume(ctx, tokenHash, time.Now().UTC())
+	if err == repository.ErrNoSuchRefreshToken {
+		return models.TokenResponse{}, errInvalidGrant()
+	} else if err != nil {
+		logger(ctx).Errorw("Failed to revoke refresh token", "clientId", client.ID, "err", err)
+		return models.TokenResponse{}, httputil.NewInternalServerError("Failed to revoke refresh token")
+	}
+
+	return svc.issueTokens(ctx, client, authorization{
+		userID:    token.UserID,
+		scopes:    scopes,
+		sessionID: token.SessionID,
+		authTime:  token.AuthTime,
+	})
+}
+
+// issueTokens issues an access and refresh token to a client on behalf of a user, as long as the user is still active.
+// An ID token is issued as well if the client has been granted the openid scope.
+func (svc *oauthSvc) issueTokens(ctx context.Context, client models.OAuthClient, authz authorization) (models.TokenResponse, error) {
+	user, err := svc.userRepo.Find(ctx, authz.userID)
+	if err == repository.ErrNoSuchUser {
+		return models.TokenResponse{}, errInvalidGrant()
+	} else if err != nil {
+		logger(ctx).Errorw("Failed find user by id", "userId", authz.userID, "err", err)
+		return models.TokenResponse{}, httputil.NewInternalServerError("Failed to find user")
+	}
+
+	if user.Disabled || user.IsDeleted() {
+		return models.TokenResponse{}, errInvalidGrant()
+	}
+
+	permissions, err := findPermissions(ctx, svc.roleRepo, user)
+	if err != nil {
+		return models.TokenResponse{}, err
+	}
+
+	grant := auth.Grant{ClientID: client.ID, Scopes: authz.scopes}
+	accessToken, err := issueGrant(ctx, svc.issuer, user.ID, models.ClientRole, grant, scopedPermissions(permissions, authz.scopes)...)
+	if err != nil {
+		logger(ctx).Errorw("Failed issue token", "clientId", client.ID, "err", err)
+		return models.TokenResponse{}, httputil.NewInternalServerError("Failed to generate token")
+	}
+
+	err = createSession(ctx, svc.sessionRepo, user.ID, accessToken)
+	if err != nil {
+		return models.TokenResponse{}, err
+	}
+
+	refreshToken, err := svc.createRefreshToken(ctx, client, authz)
+	if err != nil {
+		return models.TokenResponse{}, err
+	}
+
+	idToken, err := svc.issueIDToken(ctx, client, user, authz)
+	if err != nil {
+		return models.TokenResponse{}, err
+	}
+
+	return models.TokenResponse{
+		AccessToken:  accessToken,
+		TokenType:    models.BearerTokenType,
+		ExpiresIn:    int(svc.issuer.TokenAge().Seconds()),
+		RefreshToken: refreshToken,
+		Scope:        models.FormatScope(authz.scopes),
+		IDToken:      idToken,
+	}, nil
+}
+
+func (svc *oauthSvc) createRefreshToken(ctx context.Context, client models.OAuthClient, authz authorization) (string, error) {
+	refreshToken, err := auth.GenSalt(oauthTokenLength)
+	if err != nil {
+		logger(ctx).Errorw("Failed to generate refresh token", "err", err)
+		return "", httputil.NewInternalServerError("Failed to generate refresh token")
+	}
+
+	token := models.NewRefreshToken(auth.HashToken(refreshToken), client.ID, authz.userID, authz.scopes, refreshTokenLifetime)
+	token.SessionID = authz.sessionID
+	token.AuthTime = authz.authTime
+	err = svc.tokenRepo.Save(ctx, token)
+	if err != nil {
+		logger(ctx).Errorw("Failed to save refresh token", "clientId", client.ID, "err", err)
+		return "", httputil.NewInternalServerError("Failed to save refresh token")
+	}
+
+	return refreshToken, nil
+}
+
+// issueIDToken issues an ID token if the service is an OpenID Connect provider and
+// the client has been granted the openid scope. Returns an empty string otherwise.
+func (svc *oauthSvc) issueIDToken(ctx context.Context, client models.OAuthClient, user models.User, authz authorization) (string, error) {
+	if svc.idIssuer == nil || !containsScope(authz.scopes, models.OpenIDScope) {
+		return "", nil
+	}
+
+	info := models.NewUserInfo(user, authz.scopes)
+	idToken, err := issueIDToken(ctx, svc.idIssuer, auth.IDToken{
+		Subject:   user.ID,
+		Audience:  client.ID,
+		Nonce:     authz.nonce,
+		AuthTime:  authz.authTime,
+		SessionID: authz.sessionID,
+		Email:     info.Email,
+		Name:      info.Name,
+	})
+	if err != nil {
+		logger(ctx).Errorw("Failed to issue id token", "clientId", client.ID, "err", err)
+		return "", httputil.NewInternalServerError("Failed to generate id token")
+	}
+
+	return idToken, nil
+}
+
+func (svc *oauthSvc) UserInfo(ctx context.Context, principal auth.Token) (models.UserInfo, error) {
+	ctx = svc.withLogger(ctx)
+	if !principal.IsDelegated() || !principal.HasScope(models.OpenIDScope) {
+		return models.UserInfo{}, httputil.ErrForbidden()
+	}
+
+	user, err := findUser(ctx, svc.userRepo, principal.Subject)
+	if err != nil {
+		return models.UserInfo{}, err
+	}
+
+	return models.NewUserInfo(user, principal.Scopes), nil
+}
+
+func (svc *oauthSvc) EndSession(ctx context.Context, req models.EndSessionRequest) (string, error) {
+	ctx = svc.withLogger(ctx)
+	var hint auth.IDToken
+	if req.IDTokenHint != "" {
+		var err error
+		hint, err = svc.parseIDTokenHint(req.IDTokenHint)
+		if err != nil {
+			return "", err
+		}
+
+		if req.ClientID != "" && req.ClientID != hint.Audience {
+			return "", errInvalidRequest("Client id does not match the id token hint")
+		}
+		req.ClientID = hint.Audience
+	}
+
+	uri, err := svc.postLogoutRedirectURI(ctx, req)
+	if err != nil {
+		return "", err
+	}
+
+	err = svc.revokeSession(ctx, hint.SessionID)
+	if err != nil {
+		return "", err
+	}
+
+	return uri, nil
+}
+
+// postLogoutRedirectURI checks that the uri a client asks users to be redirected to after logout has been registered.
+func (svc *oauthSvc) postLogoutRedirectURI(ctx context.Context, req models.EndSessionRequest) (string, error) {
+	if req.PostLogoutRedirectURI == "" {
+		return "", nil
+	}
+
+	if req.ClientID == "" {
+		return "", errInvalidRequest("A client id or id token hint is required to redirect after logout")
+	}
+
+	client, err := svc.findClient(ctx, req.ClientID)
+	if err != nil {
+		return "", err
+	}
+
+	if !client.HasPostLogoutRedirectURI(req.PostLogoutRedirectURI) {
+		return "", errInvalidRequest("Post logout redirect uri has not been registered for the client")
+	}
+
+	return redirectURI(req.PostLogoutRedirectURI, req.State, nil)
+}
+
+func (svc *oauthSvc) parseIDTokenHint(rawToken string) (auth.IDToken, error) {
+	if svc.idIssuer == nil {
+		return auth.IDToken{}, errInvalidRequest("ID token hints are not supported")
+	}
+
+	hint, err := svc.idIssuer.ParseIDTokenHint(rawToken)
+	if err != nil {
+		return auth.IDToken{}, errInvalidRequest("Invalid id token hint")
+	}
+
+	return hint, nil
+}
+
+// assertSessionActive checks that the session a user authorized a client from has not ended, if sessions
+// are recorded, so that ending the session also ends the refresh tokens issued to the clients it authorized.
+func (svc *oauthSvc) assertSessionActive(ctx context.Context, sessionID string) error {
+	if svc.sessionRepo == nil || sessionID == "" {
+		return nil
+	}
+
+	session, err := svc.sessionRepo.Find(ctx, sessionID)
+	if err == repository.ErrNoSuchSession {
+		return errInvalidGrant()
+	} else if err != nil {
+		logger(ctx).Errorw("Failed to find session", "sessionId", sessionID, "err", err)
+		return httputil.NewInternalServerError("Failed to find session")
+	}
+
+	if session.IsRevoked() {
+		return errInvalidGrant()
+	}
+	return nil
+}
+
+// revokeSession revokes the session a user authorized a client from, if sessions are recorded.
+func (svc *oauthSvc) revokeSession(ctx context.Context, sessionID string) error {
+	if svc.sessionRepo == nil || sessionID == "" {
+		return nil
+	}
+
+	err := svc.sessionRepo.Revoke(ctx, sessionID, time.Now().UTC())
+	if err != nil && err != repository.ErrNoSuchSession {
+		logger(ctx).Errorw("Failed to revoke session", "sessionId", sessionID, "err", err)
+		return httputil.NewInternalServerError("Failed to end session")
+	}
+
+	return nil
+}
+
+// requestedScopes parses the scopes requested by a client, which default to every scope it is allowed to request.
+func requestedScopes(client models.OAuthClient, scope string) ([]string, error) {
+	scopes := models.ParseScope(scope)
+	if len(scopes) == 0 {
+		return client.Scopes, nil
+	}
+
+	if !client.AllowsScopes(scopes) {
+		return nil, errInvalidScope()
+	}
+
+	return scopes, nil
+}
+
+func containsScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// scopedPermissions returns the permissions which have also been granted as scopes.
+func scopedPermissions(permissions, scopes []string) []string {
+	granted := make(map[string]bool)
+	for _, scope := range scopes {
+		granted[scope] = true
+	}
+
+	scoped := make([]string, 0)
+	for _, permission := range permissions {
+		if granted[permission] {
+			scoped = append(scoped, permission)
+		}
+	}
+	return scoped
+}
+
+// redirectURI adds parameters and the state of a request to a redirect uri.
+func redirectURI(uri, state string, params url.Values) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", errInvalidRequest("Invalid redirect uri")
+	}
+
+	query := u.Query()
+	for key, values := range params {
+		query[key] = values
+	}
+	if state != "" {
+		query.Set("state", state)
+	}
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
+
+func errInvalidRequest(message string) error {
+	return httputil.NewError(message, http.StatusBadRequest).
+		WithCode(CodeInvalidRequest).
+		WithMessageKey("oauth.invalidRequest")
+}
+
+func errInvalidClient() error {
+	return httputil.NewError("Client authentication failed", http.StatusUnauthorized).
+		WithCode(CodeInvalidClient).
+		WithMessageKey("oauth.invalidClient")
+}
+
+func errInvalidGrant() error {
+	return httputil.NewError("Invalid or expired grant", http.StatusBadRequest).
+		WithCode(CodeInvalidGrant).
+		WithMessageKey("oauth.invalidGrant")
+}
+
+func errInvalidScope() error {
+	return httputil.NewError("Invalid scope", http.StatusBadRequest).
+		WithCode(CodeInvalidScope).
+		WithMessageKey("oauth.invalidScope")
+}
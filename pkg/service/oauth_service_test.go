@@ -0,0 +1,658 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/CzarSimon/user-service/pkg/auth"
+	"github.com/CzarSimon/user-service/pkg/id"
+	"github.com/CzarSimon/user-service/pkg/models"
+	"github.com/CzarSimon/user-service/pkg/repository"
+	"github.com/CzarSimon/user-service/pkg/repository/repotest"
+	"github.com/stretchr/testify/assert"
+)
+
+const testCodeVerifier = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+
+func Test_oauthSvc_RegisterClient(t *testing.T) {
+	assert := assert.New(t)
+	clientRepo := &repotest.MockOAuthClientRepo{}
+	svc := newTestOAuthService(&repotest.MockUserRepo{}, clientRepo, &repotest.MockAuthorizationCodeRepo{}, &repotest.MockRefreshTokenRepo{})
+	principal := auth.Token{Subject: id.New(), Role: models.AdminRole, Permissions: []string{models.WriteClientsPermission}}
+	req := models.RegisterClientRequest{
+		Name:         "Web app",
+		Type:         models.ConfidentialClient,
+		RedirectURIs: []string{"https://app.example.com/callback"},
+		Scopes:       []string{models.ReadUsersPermission},
+	}
+
+	reg, err := svc.RegisterClient(context.Background(), principal, req)
+	assert.NoError(err)
+	assert.NotEmpty(reg.ClientSecret)
+	assert.Equal(reg.Client, clientRepo.SaveArg)
+	assert.NotEqual(reg.ClientSecret, reg.Client.SecretHash)
+	assert.NoError(hasher.Verify(reg.ClientSecret, reg.Client.Salt, reg.Client.SecretHash))
+
+	req.Type = models.PublicClient
+	reg, err = svc.RegisterClient(context.Background(), principal, req)
+	assert.NoError(err)
+	assert.Empty(reg.ClientSecret)
+	assert.Empty(reg.Client.SecretHash)
+
+	_, err = svc.RegisterClient(context.Background(), adminPrincipal(), req)
+	assertStatus(t, http.StatusForbidden, err)
+
+	_, err = svc.RegisterClient(context.Background(), principal, models.RegisterClientRequest{Name: "Web app"})
+	assertStatus(t, http.StatusBadRequest, err)
+
+	clientRepo.SaveErr = errors.New("db failure")
+	_, err = svc.RegisterClient(context.Background(), principal, req)
+	assertStatus(t, http.StatusInternalServerError, err)
+}
+
+func Test_oauthSvc_AuthorizationCodeFlow(t *testing.T) {
+	assert := assert.New(t)
+	user := testUser()
+	user.Role = models.AdminRole
+	userRepo := &repotest.MockUserRepo{FindUser: user}
+	client := testOAuthClient(models.PublicClient)
+	clientRepo := &repotest.MockOAuthClientRepo{FindClient: client}
+	codeRepo := &repotest.MockAuthorizationCodeRepo{}
+	tokenRepo := &repotest.MockRefreshTokenRepo{}
+	sessionRepo := &repotest.MockSessionRepo{}
+	svc := newTestOAuthService(userRepo, clientRepo, codeRepo, tokenRepo, WithOAuthSessions(sessionRepo))
+	principal := auth.Token{ID: id.New(), Subject: user.ID, Role: models.AdminRole}
+
+	req := testAuthorizeRequest(client)
+	prompt, err := svc.Authorize(context.Background(), principal, req)
+	assert.NoError(err)
+	assert.Equal(client.ID, prompt.ClientID)
+	assert.Equal(client.Name, prompt.ClientName)
+	assert.Equal([]string{"openid", models.ReadUsersPermission}, prompt.Scopes)
+
+	req.Approved = true
+	redirect, err := svc.Consent(context.Background(), principal, req)
+	assert.NoError(err)
+	u, err := url.Parse(redirect)
+	assert.NoError(err)
+	assert.Equal("app.example.com", u.Host)
+	assert.Equal("/callback", u.Path)
+	assert.Equal("state-value", u.Query().Get("state"))
+	code := u.Query().Get("code")
+	assert.NotEmpty(code)
+
+	savedCode := codeRepo.SaveArg
+	assert.Equal(auth.HashToken(code), savedCode.CodeHash)
+	assert.Equal(user.ID, savedCode.UserID)
+	assert.Equal(client.ID, savedCode.ClientID)
+	assert.Equal(prompt.Scopes, savedCode.Scopes)
+
+	codeRepo.ConsumeCode = savedCode
+	res, err := svc.Token(context.Background(), models.TokenRequest{
+		GrantType:    models.AuthorizationCodeGrant,
+		Code:         code,
+		RedirectURI:  req.RedirectURI,
+		CodeVerifier: testCodeVerifier,
+		ClientID:     client.ID,
+	})
+	assert.NoError(err)
+	assert.Equal(auth.HashToken(code), codeRepo.ConsumeArg)
+	assert.Equal(models.BearerTokenType, res.TokenType)
+	assert.Equal(86400, res.ExpiresIn)
+	assert.Equal("openid users:read", res.Scope)
+
+	token, err := verifier.Verify(res.AccessToken)
+	assert.NoError(err)
+	assert.Equal(user.ID, token.Subject)
+	assert.Equal(models.ClientRole, token.Role)
+	assert.Equal(client.ID, token.ClientID)
+	assert.Equal(prompt.Scopes, token.Scopes)
+	assert.Equal([]string{models.ReadUsersPermission}, token.Permissions)
+	assert.Equal(token.ID, sessionRepo.SaveArg.ID)
+
+	info, err := svc.UserInfo(context.Background(), token)
+	assert.NoError(err)
+	assert.Equal(user.ID, info.Subject)
+
+	_, err = svc.UserInfo(context.Background(), principal)
+	assertStatus(t, http.StatusForbidden, err)
+
+	refreshToken := tokenRepo.SaveArg
+	assert.NotEmpty(res.RefreshToken)
+	assert.Equal(auth.HashToken(res.RefreshToken), refreshToken.TokenHash)
+	assert.Equal(client.ID, refreshToken.ClientID)
+	assert.Equal(user.ID, refreshToken.UserID)
+	assert.True(refreshToken.IsValid())
+}
+
+func Test_oauthSvc_Authorize(t *testing.T) {
+	client := testOAuthClient(models.PublicClient)
+	userID := id.New()
+	principal := auth.Token{ID: id.New(), Subject: userID, Role: models.UserRole}
+
+	tests := []struct {
+		name       string
+		principal  auth.Token
+		req        func(models.AuthorizeRequest) models.AuthorizeRequest
+		findErr    error
+		wantStatus int
+		wantCode   string
+	}{
+		{
+			name:      "happy-path",
+			principal: principal,
+			req:       func(r models.AuthorizeRequest) models.AuthorizeRequest { return r },
+		},
+		{
+			name:       "sad-path-client-token",
+			principal:  auth.Token{Subject: userID, ClientID: id.New()},
+			req:        func(r models.AuthorizeRequest) models.AuthorizeRequest { return r },
+			wantStatus: http.StatusForbidden,
+			wantCode:   "FORBIDDEN",
+		},
+		{
+			name:       "sad-path-api-key",
+			principal:  auth.Token{ID: id.New(), Subject: userID, Role: models.UserRole, PrincipalType: auth.UserPrincipal, APIKey: true},
+			req:        func(r models.AuthorizeRequest) models.AuthorizeRequest { return r },
+			wantStatus: http.StatusForbidden,
+			wantCode:   "FORBIDDEN",
+		},
+		{
+			name:       "sad-path-unknown-client",
+			principal:  principal,
+			req:        func(r models.AuthorizeRequest) models.AuthorizeRequest { return r },
+			findErr:    repository.ErrNoSuchClient,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   CodeInvalidRequest,
+		},
+		{
+			name:       "sad-path-client-lookup-fails",
+			principal:  principal,
+			req:        func(r models.AuthorizeRequest) models.AuthorizeRequest { return r },
+			findErr:    errors.New("db failure"),
+			wantStatus: http.StatusInternalServerError,
+			wantCode:   "INTERNAL_SERVER_ERROR",
+		},
+		{
+			name:      "sad-path-missing-client-id",
+			principal: principal,
+			req: func(r models.AuthorizeRequest) models.AuthorizeRequest {
+				r.ClientID = ""
+				return r
+			},
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "VALIDATION_FAILED",
+		},
+		{
+			name:      "sad-path-unregistered-redirect-uri",
+			principal: principal,
+			req: func(r models.AuthorizeRequest) models.AuthorizeRequest {
+				r.RedirectURI = "https://evil.example.com/callback"
+				return r
+			},
+			wantStatus: http.StatusBadRequest,
+			wantCode:   CodeInvalidRequest,
+		},
+		{
+			name:      "sad-path-token-response-type",
+			principal: principal,
+			req: func(r models.AuthorizeRequest) models.AuthorizeRequest {
+				r.ResponseType = "token"
+				return r
+			},
+			wantStatus: http.StatusBadRequest,
+			wantCode:   CodeUnsupportedResponseType,
+		},
+		{
+			name:      "sad-path-missing-code-challenge",
+			principal: principal,
+			req: func(r models.AuthorizeRequest) models.AuthorizeRequest {
+				r.CodeChallenge = ""
+				return r
+			},
+			wantStatus: http.StatusBadRequest,
+			wantCode:   CodeInvalidRequest,
+		},
+		{
+			name:      "sad-path-plain-code-challenge",
+			principal: principal,
+			req: func(r models.AuthorizeRequest) models.AuthorizeRequest {
+				r.CodeChallenge = testCodeVerifier
+				r.CodeChallengeMethod = "plain"
+				return r
+			},
+			wantStatus: http.StatusBadRequest,
+			wantCode:   CodeInvalidRequest,
+		},
+		{
+			name:      "sad-path-scope-not-allowed",
+			principal: principal,
+			req: func(r models.AuthorizeRequest) models.AuthorizeRequest {
+				r.Scope = "openid users:write"
+				return r
+			},
+			wantStatus: http.StatusBadRequest,
+			wantCode:   CodeInvalidScope,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientRepo := &repotest.MockOAuthClientRepo{FindClient: client, FindErr: tt.findErr}
+			svc := newTestOAuthService(&repotest.MockUserRepo{}, clientRepo, &repotest.MockAuthorizationCodeRepo{}, &repotest.MockRefreshTokenRepo{})
+
+			_, err := svc.Authorize(context.Background(), tt.principal, tt.req(testAuthorizeRequest(client)))
+			if tt.wantStatus == 0 {
+				assert.NoError(t, err)
+				return
+			}
+
+			assertStatus(t, tt.wantStatus, err)
+			assertCode(t, tt.wantCode, err)
+		})
+	}
+}
+
+func Test_oauthSvc_Consent(t *testing.T) {
+	assert := assert.New(t)
+	user := testUser()
+	userRepo := &repotest.MockUserRepo{FindUser: user}
+	client := testOAuthClient(models.PublicClient)
+	codeRepo := &repotest.MockAuthorizationCodeRepo{}
+	svc := newTestOAuthService(userRepo, &repotest.MockOAuthClientRepo{FindClient: client}, codeRepo, &repotest.MockRefreshTokenRepo{})
+	principal := auth.Token{ID: id.New(), Subject: user.ID, Role: models.UserRole}
+
+	req := testAuthorizeRequest(client)
+	redirect, err := svc.Consent(context.Background(), principal, req)
+	assert.NoError(err)
+	assert.Equal("https://app.example.com/callback?error=access_denied&state=state-value", redirect)
+	assert.Equal(0, codeRepo.SaveInvocations)
+
+	req.Approved = true
+	req.Scope = models.ReadUsersPermission
+	redirect, err = svc.Consent(context.Background(), principal, req)
+	assert.NoError(err)
+	assert.Contains(redirect, "code=")
+	assert.Equal([]string{models.ReadUsersPermission}, codeRepo.SaveArg.Scopes)
+	assert.True(codeRepo.SaveArg.ExpiresAt.Before(time.Now().Add(2 * time.Minute)))
+
+	userRepo.FindUser.Disabled = true
+	_, err = svc.Consent(context.Background(), principal, req)
+	assertCode(t, CodeAccountDisabled, err)
+
+	userRepo.FindUser.Disabled = false
+	codeRepo.SaveErr = errors.New("db failure")
+	_, err = svc.Consent(context.Background(), principal, req)
+	assertStatus(t, http.StatusInternalServerError, err)
+}
+
+func Test_oauthSvc_Token_AuthorizationCode(t *testing.T) {
+	user := testUser()
+	client := testOAuthClient(models.PublicClient)
+	validCode := models.NewAuthorizationCode(auth.HashToken("code"), user.ID, testAuthorizeRequest(client), []string{"openid"}, time.Minute)
+
+	tests := []struct {
+		name       string
+		req        func(models.TokenRequest) models.TokenRequest
+		code       func(models.AuthorizationCode) models.AuthorizationCode
+		consumeErr error
+		findClient func(models.OAuthClient) models.OAuthClient
+		disabled   bool
+		wantStatus int
+		wantCode   string
+	}{
+		{
+			name: "happy-path",
+		},
+		{
+			name: "sad-path-wrong-code-verifier",
+			req: func(r models.TokenRequest) models.TokenRequest {
+				r.CodeVerifier = "wrong-verifier-wrong-verifier-wrong-verifier"
+				return r
+			},
+			wantStatus: http.StatusBadRequest,
+			wantCode:   CodeInvalidGrant,
+		},
+		{
+			name: "sad-path-missing-code-verifier",
+			req: func(r models.TokenRequest) models.TokenRequest {
+				r.CodeVerifier = ""
+				return r
+			},
+			wantStatus: http.StatusBadRequest,
+			wantCode:   CodeInvalidRequest,
+		},
+		{
+			name: "sad-path-wrong-redirect-uri",
+			req: func(r models.TokenRequest) models.TokenRequest {
+				r.RedirectURI = "https://app.example.com/other"
+				return r
+			},
+			wantStatus: http.StatusBadRequest,
+			wantCode:   CodeInvalidGrant,
+		},
+		{
+			name: "sad-path-code-issued-to-other-client",
+			code: func(c models.AuthorizationCode) models.AuthorizationCode {
+				c.ClientID = id.New()
+				return c
+			},
+			wantStatus: http.StatusBadRequest,
+			wantCode:   CodeInvalidGrant,
+		},
+		{
+			name: "sad-path-expired-code",
+			code: func(c models.AuthorizationCode) models.AuthorizationCode {
+				c.ExpiresAt = time.Now().UTC().Add(-time.Second)
+				return c
+			},
+			wantStatus: http.StatusBadRequest,
+			wantCode:   CodeInvalidGrant,
+		},
+		{
+			name:       "sad-path-unknown-or-used-code",
+			consumeErr: repository.ErrNoSuchAuthorizationCode,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   CodeInvalidGrant,
+		},
+		{
+			name:       "sad-path-disabled-user",
+			disabled:   true,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   CodeInvalidGrant,
+		},
+		{
+			name: "sad-path-unsupported-grant-type",
+			req: func(r models.TokenRequest) models.TokenRequest {
+				r.GrantType = "password"
+				return r
+			},
+			wantStatus: http.StatusBadRequest,
+			wantCode:   CodeUnsupportedGrantType,
+		},
+		{
+			name: "sad-path-missing-client-id",
+			req: func(r models.TokenRequest) models.TokenRequest {
+				r.ClientID = ""
+				return r
+			},
+			wantStatus: http.StatusUnauthorized,
+			wantCode:   CodeInvalidClient,
+		},
+		{
+			name: "sad-path-confidential-client-without-secret",
+			findClient: func(c models.OAuthClient) models.OAuthClient {
+				return testOAuthClient(models.ConfidentialClient)
+			},
+			wantStatus: http.StatusUnauthorized,
+			wantCode:   CodeInvalidClient,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			foundUser := user
+			foundUser.Disabled = tt.disabled
+			foundClient := client
+			if tt.findClient != nil {
+				foundClient = tt.findClient(client)
+			}
+			code := validCode
+			if tt.code != nil {
+				code = tt.code(validCode)
+			}
+			req := models.TokenRequest{
+				GrantType:    models.AuthorizationCodeGrant,
+				Code:         "code",
+				RedirectURI:  "https://app.example.com/callback",
+				CodeVerifier: testCodeVerifier,
+				ClientID:     client.ID,
+			}
+			if tt.req != nil {
+				req = tt.req(req)
+			}
+
+			codeRepo := &repotest.MockAuthorizationCodeRepo{ConsumeCode: code, ConsumeErr: tt.consumeErr}
+			svc := newTestOAuthService(
+				&repotest.MockUserRepo{FindUser: foundUser},
+				&repotest.MockOAuthClientRepo{FindClient: foundClient},
+				codeRepo,
+				&repotest.MockRefreshTokenRepo{},
+			)
+
+			res, err := svc.Token(context.Background(), req)
+			if tt.wantStatus == 0 {
+				assert.NoError(t, err)
+				assert.NotEmpty(t, res.AccessToken)
+				return
+			}
+
+			assertStatus(t, tt.wantStatus, err)
+			assertCode(t, tt.wantCode, err)
+		})
+	}
+}
+
+func Test_oauthSvc_Token_ConfidentialClient(t *testing.T) {
+	assert := assert.New(t)
+	clientRepo := &repotest.MockOAuthClientRepo{}
+	svc := newTestOAuthService(&repotest.MockUserRepo{FindUser: testUser()}, clientRepo, &repotest.MockAuthorizationCodeRepo{}, &repotest.MockRefreshTokenRepo{})
+	admin := auth.Token{Subject: id.New(), Role: models.AdminRole, Permissions: []string{models.WriteClientsPermission}}
+	reg, err := svc.RegisterClient(context.Background(), admin, models.RegisterClientRequest{
+		Name:         "Web app",
+		Type:         models.ConfidentialClient,
+		RedirectURIs: []string{"https://app.example.com/callback"},
+	})
+	assert.NoError(err)
+	clientRepo.FindClient = reg.Client
+
+	_, err = svc.Token(context.Background(), models.TokenRequest{
+		GrantType:    models.RefreshTokenGrant,
+		ClientID:     reg.Client.ID,
+		ClientSecret: "wrong-secret",
+	})
+	assertCode(t, CodeInvalidClient, err)
+
+	_, err = svc.Token(context.Background(), models.TokenRequest{
+		GrantType:    models.RefreshTokenGrant,
+		ClientID:     reg.Client.ID,
+		ClientSecret: reg.ClientSecret,
+	})
+	assertCode(t, CodeInvalidRequest, err)
+}
+
+func Test_oauthSvc_Token_RefreshToken(t *testing.T) {
+	assert := assert.New(t)
+	user := testUser()
+	client := testOAuthClient(models.PublicClient)
+	scopes := []string{"openid", models.ReadUsersPermission}
+	stored := models.NewRefreshToken(auth.HashToken("refresh-token"), client.ID, user.ID, scopes, time.Hour)
+	tokenRepo := &repotest.MockRefreshTokenRepo{FindToken: stored}
+	svc := newTestOAuthService(&repotest.MockUserRepo{FindUser: user}, &repotest.MockOAuthClientRepo{FindClient: client}, &repotest.MockAuthorizationCodeRepo{}, tokenRepo)
+	req := models.TokenRequest{
+		GrantType:    models.RefreshTokenGrant,
+		RefreshToken: "refresh-token",
+		ClientID:     client.ID,
+	}
+
+	res, err := svc.Token(context.Background(), req)
+	assert.NoError(err)
+	assert.Equal(auth.HashToken("refresh-token"), tokenRepo.FindArg)
+	assert.Equal(auth.HashToken("refresh-token"), tokenRepo.ConsumeArg)
+	assert.NotEqual("refresh-token", res.RefreshToken)
+	assert.Equal(auth.HashToken(res.RefreshToken), tokenRepo.SaveArg.TokenHash)
+	assert.Equal(scopes, tokenRepo.SaveArg.Scopes)
+	assert.Equal("openid users:read", res.Scope)
+
+	req.Scope = "openid"
+	res, err = svc.Token(context.Background(), req)
+	assert.NoError(err)
+	assert.Equal("openid", res.Scope)
+	assert.Equal([]string{"openid"}, tokenRepo.SaveArg.Scopes)
+
+	req.Scope = "openid users:write"
+	_, err = svc.Token(context.Background(), req)
+	assertCode(t, CodeInvalidScope, err)
+
+	req.Scope = ""
+	tokenRepo.FindToken.ClientID = id.New()
+	_, err = svc.Token(context.Background(), req)
+	assertCode(t, CodeInvalidGrant, err)
+
+	tokenRepo.FindToken = stored
+	tokenRepo.FindToken.RevokedAt = time.Now().UTC()
+	_, err = svc.Token(context.Background(), req)
+	assertCode(t, CodeInvalidGrant, err)
+
+	tokenRepo.FindErr = repository.ErrNoSuchRefreshToken
+	_, err = svc.Token(context.Background(), req)
+	assertCode(t, CodeInvalidGrant, err)
+
+	tokenRepo.FindErr = nil
+	tokenRepo.FindToken = stored
+	tokenRepo.ConsumeErr = repository.ErrNoSuchRefreshToken
+	_, err = svc.Token(context.Background(), req)
+	assertCode(t, CodeInvalidGrant, err)
+
+	tokenRepo.ConsumeErr = errors.New("db failure")
+	_, err = svc.Token(context.Background(), req)
+	assertStatus(t, http.StatusInternalServerError, err)
+}
+
+// refreshTokenStore in memory RefreshTokenRepository where Find waits until
+// every expected caller has found the token, to make refreshes race.
+type refreshTokenStore struct {
+	mu     sync.Mutex
+	tokens map[string]models.RefreshToken
+	found  sync.WaitGroup
+}
+
+func (s *refreshTokenStore) Save(ctx context.Context, token models.RefreshToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens[token.TokenHash] = token
+	return nil
+}
+
+func (s *refreshTokenStore) Find(ctx context.Context, tokenHash string) (models.RefreshToken, error) {
+	s.mu.Lock()
+	token, ok := s.tokens[tokenHash]
+	s.mu.Unlock()
+	s.found.Done()
+	s.found.Wait()
+	if !ok {
+		return models.RefreshToken{}, repository.ErrNoSuchRefreshToken
+	}
+	return token, nil
+}
+
+func (s *refreshTokenStore) Consume(ctx context.Context, tokenHash string, revokedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	token, ok := s.tokens[tokenHash]
+	if !ok || !token.RevokedAt.IsZero() {
+		return repository.ErrNoSuchRefreshToken
+	}
+	token.RevokedAt = revokedAt
+	s.tokens[tokenHash] = token
+	return nil
+}
+
+func (s *refreshTokenStore) RevokeByUserID(ctx context.Context, userID string, revokedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for hash, token := range s.tokens {
+		if token.UserID == userID && token.RevokedAt.IsZero() {
+			token.RevokedAt = revokedAt
+			s.tokens[hash] = token
+		}
+	}
+	return nil
+}
+
+func (s *refreshTokenStore) DeleteByUserID(ctx context.Context, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for hash, token := range s.tokens {
+		if token.UserID == userID {
+			delete(s.tokens, hash)
+		}
+	}
+	return nil
+}
+
+// staticClientRepo OAuthClientRepository which always finds the same client, safe for concurrent use.
+type staticClientRepo struct {
+	client models.OAuthClient
+}
+
+func (r staticClientRepo) Save(ctx context.Context, client models.OAuthClient) error {
+	return nil
+}
+
+func (r staticClientRepo) Find(ctx context.Context, id string) (models.OAuthClient, error) {
+	return r.client, nil
+}
+
+func Test_oauthSvc_Token_ConcurrentRefreshes(t *testing.T) {
+	assert := assert.New(t)
+	user := testUser()
+	client := testOAuthClient(models.PublicClient)
+	stored := models.NewRefreshToken(auth.HashToken("refresh-token"), client.ID, user.ID, []string{"openid"}, time.Hour)
+	tokenRepo := &refreshTokenStore{tokens: map[string]models.RefreshToken{stored.TokenHash: stored}}
+	svc := newTestOAuthService(&repotest.MockUserRepo{FindUser: user}, staticClientRepo{client: client}, &repotest.MockAuthorizationCodeRepo{}, tokenRepo)
+	req := models.TokenRequest{
+		GrantType:    models.RefreshTokenGrant,
+		RefreshToken: "refresh-token",
+		ClientID:     client.ID,
+	}
+
+	const refreshes = 2
+	tokenRepo.found.Add(refreshes)
+	errs := make(chan error, refreshes)
+	for i := 0; i < refreshes; i++ {
+		go func() {
+			_, err := svc.Token(context.Background(), req)
+			errs <- err
+		}()
+	}
+
+	failed := 0
+	for i := 0; i < refreshes; i++ {
+		err := <-errs
+		if err != nil {
+			assertCode(t, CodeInvalidGrant, err)
+			failed++
+		}
+	}
+	assert.Equal(refreshes-1, failed)
+}
+
+func newTestOAuthService(userRepo repository.UserRepository, clientRepo repository.OAuthClientRepository, codeRepo repository.AuthorizationCodeRepository, tokenRepo repository.RefreshTokenRepository, opts ...OAuthServiceOption) OAuthService {
+	return NewOAuthService(hasher, issuer, userRepo, roleRepo, clientRepo, codeRepo, tokenRepo, opts...)
+}
+
+func testOAuthClient(clientType string) models.OAuthClient {
+	return models.OAuthClient{
+		ID:           id.New(),
+		Name:         "Test app",
+		Type:         clientType,
+		RedirectURIs: []string{"https://app.example.com/callback"},
+		Scopes:       []string{"openid", models.ReadUsersPermission},
+		CreatedAt:    time.Now().UTC(),
+	}
+}
+
+func testAuthorizeRequest(client models.OAuthClient) models.AuthorizeRequest {
+	return models.AuthorizeRequest{
+		ResponseType:        models.CodeResponseType,
+		ClientID:            client.ID,
+		RedirectURI:         "https://app.example.com/callback",
+		State:               "state-value",
+		CodeChallenge:       auth.PKCEChallenge(testCodeVerifier),
+		CodeChallengeMethod: auth.PKCEMethodS256,
+	}
+}
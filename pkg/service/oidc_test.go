@@ -0,0 +1,243 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/CzarSimon/user-service/pkg/auth"
+	"github.com/CzarSimon/user-service/pkg/id"
+	"github.com/CzarSimon/user-service/pkg/models"
+	"github.com/CzarSimon/user-service/pkg/repository"
+	"github.com/CzarSimon/user-service/pkg/repository/repotest"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_oauthSvc_IssuesIDTokens(t *testing.T) {
+	assert := assert.New(t)
+	user := testUser()
+	client := testOAuthClient(models.PublicClient)
+	client.Scopes = []string{models.OpenIDScope, models.EmailScope, models.ProfileScope}
+	codeRepo := &repotest.MockAuthorizationCodeRepo{}
+	tokenRepo := &repotest.MockRefreshTokenRepo{}
+	idIssuer := testIDTokenIssuer(t)
+	svc := newTestOAuthService(&repotest.MockUserRepo{FindUser: user}, &repotest.MockOAuthClientRepo{FindClient: client}, codeRepo, tokenRepo, WithOpenID(idIssuer))
+	authTime := time.Now().UTC().Add(-time.Hour).Truncate(time.Second)
+	principal := auth.Token{ID: id.New(), Subject: user.ID, Role: models.UserRole, CreatedAt: authTime}
+
+	req := testAuthorizeRequest(client)
+	req.Nonce = "nonce-value"
+	req.Approved = true
+	redirect, err := svc.Consent(context.Background(), principal, req)
+	assert.NoError(err)
+	u, err := url.Parse(redirect)
+	assert.NoError(err)
+	assert.Equal(principal.ID, codeRepo.SaveArg.SessionID)
+	assert.Equal(authTime, codeRepo.SaveArg.AuthTime)
+
+	codeRepo.ConsumeCode = codeRepo.SaveArg
+	res, err := svc.Token(context.Background(), models.TokenRequest{
+		GrantType:    models.AuthorizationCodeGrant,
+		Code:         u.Query().Get("code"),
+		RedirectURI:  req.RedirectURI,
+		CodeVerifier: testCodeVerifier,
+		ClientID:     client.ID,
+	})
+	assert.NoError(err)
+	assert.NotEmpty(res.IDToken)
+
+	idToken, err := idIssuer.ParseIDTokenHint(res.IDToken)
+	assert.NoError(err)
+	assert.Equal(user.ID, idToken.Subject)
+	assert.Equal(client.ID, idToken.Audience)
+	assert.Equal("nonce-value", idToken.Nonce)
+	assert.Equal(authTime, idToken.AuthTime)
+	assert.Equal(principal.ID, idToken.SessionID)
+	assert.Equal(user.Email, idToken.Email)
+	assert.Equal("Tester McTest", idToken.Name)
+	assert.Equal(principal.ID, tokenRepo.SaveArg.SessionID)
+	assert.Equal(authTime, tokenRepo.SaveArg.AuthTime)
+
+	tokenRepo.FindToken = tokenRepo.SaveArg
+	res, err = svc.Token(context.Background(), models.TokenRequest{
+		GrantType:    models.RefreshTokenGrant,
+		RefreshToken: res.RefreshToken,
+		Scope:        "openid email",
+		ClientID:     client.ID,
+	})
+	assert.NoError(err)
+	idToken, err = idIssuer.ParseIDTokenHint(res.IDToken)
+	assert.NoError(err)
+	assert.Empty(idToken.Nonce)
+	assert.Equal(authTime, idToken.AuthTime)
+	assert.Equal(principal.ID, idToken.SessionID)
+	assert.Equal(user.Email, idToken.Email)
+	assert.Empty(idToken.Name)
+
+	tokenRepo.FindToken = tokenRepo.SaveArg
+	res, err = svc.Token(context.Background(), models.TokenRequest{
+		GrantType:    models.RefreshTokenGrant,
+		RefreshToken: res.RefreshToken,
+		Scope:        "email",
+		ClientID:     client.ID,
+	})
+	assert.NoError(err)
+	assert.Empty(res.IDToken)
+}
+
+func Test_oauthSvc_EndSession(t *testing.T) {
+	idIssuer := testIDTokenIssuer(t)
+	client := testOAuthClient(models.PublicClient)
+	client.PostLogoutRedirectURIs = []string{"https://app.example.com/logged-out"}
+	hint, err := idIssuer.IssueIDToken(auth.IDToken{Subject: id.New(), Audience: client.ID, SessionID: "session-id"})
+	assert.NoError(t, err)
+	otherHint, err := testIDTokenIssuer(t).IssueIDToken(auth.IDToken{Subject: id.New(), Audience: client.ID, SessionID: "session-id"})
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		req         models.EndSessionRequest
+		revokeErr   error
+		want        string
+		wantRevoked bool
+		wantStatus  int
+		wantCode    string
+		withoutOIDC bool
+	}{
+		{
+			name:        "happy-path",
+			req:         models.EndSessionRequest{IDTokenHint: hint, PostLogoutRedirectURI: "https://app.example.com/logged-out", State: "xyz"},
+			want:        "https://app.example.com/logged-out?state=xyz",
+			wantRevoked: true,
+		},
+		{
+			name:        "happy-path-without-redirect",
+			req:         models.EndSessionRequest{IDTokenHint: hint},
+			want:        "",
+			wantRevoked: true,
+		},
+		{
+			name:        "happy-path-session-already-gone",
+			req:         models.EndSessionRequest{IDTokenHint: hint},
+			revokeErr:   repository.ErrNoSuchSession,
+			wantRevoked: true,
+		},
+		{
+			name: "happy-path-client-id-without-hint",
+			req:  models.EndSessionRequest{ClientID: client.ID, PostLogoutRedirectURI: "https://app.example.com/logged-out"},
+			want: "https://app.example.com/logged-out",
+		},
+		{
+			name:       "sad-path-unregistered-redirect",
+			req:        models.EndSessionRequest{IDTokenHint: hint, PostLogoutRedirectURI: "https://evil.example.com"},
+			wantStatus: http.StatusBadRequest,
+			wantCode:   CodeInvalidRequest,
+		},
+		{
+			name:       "sad-path-redirect-without-client",
+			req:        models.EndSessionRequest{PostLogoutRedirectURI: "https://app.example.com/logged-out"},
+			wantStatus: http.StatusBadRequest,
+			wantCode:   CodeInvalidRequest,
+		},
+		{
+			name:       "sad-path-client-id-mismatch",
+			req:        models.EndSessionRequest{IDTokenHint: hint, ClientID: id.New()},
+			wantStatus: http.StatusBadRequest,
+			wantCode:   CodeInvalidRequest,
+		},
+		{
+			name:       "sad-path-hint-signed-by-other-key",
+			req:        models.EndSessionRequest{IDTokenHint: otherHint},
+			wantStatus: http.StatusBadRequest,
+			wantCode:   CodeInvalidRequest,
+		},
+		{
+			name:        "sad-path-hint-without-openid",
+			req:         models.EndSessionRequest{IDTokenHint: hint},
+			withoutOIDC: true,
+			wantStatus:  http.StatusBadRequest,
+			wantCode:    CodeInvalidRequest,
+		},
+		{
+			name:        "sad-path-revoke-fails",
+			req:         models.EndSessionRequest{IDTokenHint: hint},
+			revokeErr:   errors.New("db failure"),
+			wantRevoked: true,
+			wantStatus:  http.StatusInternalServerError,
+			wantCode:    "INTERNAL_SERVER_ERROR",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sessionRepo := &repotest.MockSessionRepo{RevokeErr: tt.revokeErr}
+			opts := []OAuthServiceOption{WithOAuthSessions(sessionRepo)}
+			if !tt.withoutOIDC {
+				opts = append(opts, WithOpenID(idIssuer))
+			}
+			svc := newTestOAuthService(&repotest.MockUserRepo{}, &repotest.MockOAuthClientRepo{FindClient: client}, &repotest.MockAuthorizationCodeRepo{}, &repotest.MockRefreshTokenRepo{}, opts...)
+
+			uri, err := svc.EndSession(context.Background(), tt.req)
+			if tt.wantRevoked {
+				assert.Equal(t, []string{"session-id"}, sessionRepo.RevokeArgs)
+			} else {
+				assert.Empty(t, sessionRepo.RevokeArgs)
+			}
+
+			if tt.wantStatus != 0 {
+				assertStatus(t, tt.wantStatus, err)
+				assertCode(t, tt.wantCode, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, uri)
+		})
+	}
+}
+
+func testIDTokenIssuer(t *testing.T) auth.IDTokenIssuer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	idIssuer, err := auth.NewRSAIDTokenIssuer("https://id.example.com", key)
+	assert.NoError(t, err)
+	return idIssuer
+}
+
+func Test_oauthSvc_Token_RefreshTokenOfEndedSession(t *testing.T) {
+	assert := assert.New(t)
+	user := testUser()
+	client := testOAuthClient(models.PublicClient)
+	stored := models.NewRefreshToken(auth.HashToken("refresh-token"), client.ID, user.ID, []string{"openid"}, time.Hour)
+	stored.SessionID = "session-id"
+	tokenRepo := &repotest.MockRefreshTokenRepo{FindToken: stored}
+	sessionRepo := &repotest.MockSessionRepo{FindSession: models.NewSession("session-id", user.ID, models.ClientInfo{})}
+	svc := newTestOAuthService(&repotest.MockUserRepo{FindUser: user}, &repotest.MockOAuthClientRepo{FindClient: client}, &repotest.MockAuthorizationCodeRepo{}, tokenRepo, WithOAuthSessions(sessionRepo))
+	req := models.TokenRequest{
+		GrantType:    models.RefreshTokenGrant,
+		RefreshToken: "refresh-token",
+		ClientID:     client.ID,
+	}
+
+	_, err := svc.Token(context.Background(), req)
+	assert.NoError(err)
+	assert.Equal("session-id", sessionRepo.FindArg)
+
+	tokenRepo.UnsetArgs()
+	sessionRepo.FindSession.RevokedAt = time.Now().UTC()
+	_, err = svc.Token(context.Background(), req)
+	assertCode(t, CodeInvalidGrant, err)
+	assert.Equal(0, tokenRepo.ConsumeInvocations)
+
+	sessionRepo.FindErr = repository.ErrNoSuchSession
+	_, err = svc.Token(context.Background(), req)
+	assertCode(t, CodeInvalidGrant, err)
+
+	sessionRepo.FindErr = errors.New("db failure")
+	_, err = svc.Token(context.Background(), req)
+	assertStatus(t, http.StatusInternalServerError, err)
+}
@@ -16,11 +16,15 @@ type defaultChecker struct {
 
 func (c *defaultChecker) check(password, repeatPassword string) error {
 	if len(password) < c.minLength {
-		return httputil.NewError("password is to short", http.StatusBadRequest)
+		return httputil.NewError("password is to short", http.StatusBadRequest).
+			WithCode(CodePasswordTooShort).
+			WithMessageKey("password.tooShort", c.minLength)
 	}
 
 	if password != repeatPassword {
-		return httputil.NewError("passwords do not match", http.StatusBadRequest)
+		return httputil.NewError("passwords do not match", http.StatusBadRequest).
+			WithCode(CodePasswordMismatch).
+			WithMessageKey("password.mismatch")
 	}
 
 	return nil
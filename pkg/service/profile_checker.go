@@ -0,0 +1,46 @@
+package service
+
+import (
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/CzarSimon/user-service/pkg/httputil"
+	"github.com/CzarSimon/user-service/pkg/models"
+)
+
+func checkProfileUpdate(req models.UpdateProfileRequest) error {
+	if req.Version < 1 {
+		return httputil.NewError("version is required", http.StatusBadRequest).
+			WithCode(CodeVersionRequired).
+			WithMessageKey("profile.versionRequired")
+	}
+
+	err := checkName("surname", req.Surname)
+	if err != nil {
+		return err
+	}
+
+	return checkName("middleAndLastName", req.MiddleAndLastName)
+}
+
+// checkName checks that an optional name is not blank and not too long.
+func checkName(field string, name *string) error {
+	if name == nil {
+		return nil
+	}
+
+	if strings.TrimSpace(*name) == "" {
+		return httputil.NewError(field+" must not be empty", http.StatusBadRequest).
+			WithCode(CodeInvalidName).
+			WithMessageKey("profile.nameEmpty", field)
+	}
+
+	if utf8.RuneCountInString(*name) > models.MaxNameLength {
+		return httputil.NewError(field+" is too long", http.StatusBadRequest).
+			WithCode(CodeInvalidName).
+			WithMessageKey("profile.nameTooLong", field, models.MaxNameLength)
+	}
+
+	return nil
+}
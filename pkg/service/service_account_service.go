@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/CzarSimon/user-service/pkg/auth"
+	"github.com/CzarSimon/user-service/pkg/httputil"
+	"github.com/CzarSimon/user-service/pkg/models"
+	"github.com/CzarSimon/user-service/pkg/repository"
+	"go.uber.org/zap"
+)
+
+// Error codes returned by the service account service.
+const (
+	CodeServiceAccountNotFound = "SERVICE_ACCOUNT_NOT_FOUND"
+)
+
+// ServiceAccountService service responsible for admins managing service accounts.
+// Service accounts get their tokens from an OAuthService created with WithServiceAccounts.
+type ServiceAccountService interface {
+	// Create creates a service account and returns it along with its client secret, which is not stored.
+	// Admins may only grant service accounts permissions they hold themselves.
+	Create(ctx context.Context, principal auth.Token, req models.CreateServiceAccountRequest) (models.ServiceAccountCredentials, error)
+	List(ctx context.Context, principal auth.Token) ([]models.ServiceAccount, error)
+	Delete(ctx context.Context, principal auth.Token, id string) error
+}
+
+// ServiceAccountServiceOption configures optional dependencies of a ServiceAccountService.
+type ServiceAccountServiceOption func(*serviceAccountSvc)
+
+// WithServiceAccountSessions deletes the sessions of a service account when it is deleted, so that
+// the tokens issued to it are no longer accepted by a verifier created by NewSessionVerifier.
+func WithServiceAccountSessions(sessionRepo repository.SessionRepository) ServiceAccountServiceOption {
+	return func(svc *serviceAccountSvc) {
+		svc.sessionRepo = traceSessionRepo(sessionRepo)
+	}
+}
+
+// WithServiceAccountLogger sets the logger used when service accounts are managed outside of a request.
+func WithServiceAccountLogger(log *zap.Logger) ServiceAccountServiceOption {
+	return func(svc *serviceAccountSvc) {
+		svc.log = log
+	}
+}
+
+// NewServiceAccountService creates a new ServiceAccountService. Client secrets are hashed with the given hasher.
+func NewServiceAccountService(hasher auth.Hasher, accountRepo repository.ServiceAccountRepository, opts ...ServiceAccountServiceOption) ServiceAccountService {
+	svc := &serviceAccountSvc{
+		hasher:      hasher,
+		accountRepo: traceServiceAccountRepo(accountRepo),
+	}
+
+	for _, opt := range opts {
+		opt(svc)
+	}
+	return svc
+}
+
+type serviceAccountSvc struct {
+	logging
+	hasher      auth.Hasher
+	accountRepo repository.ServiceAccountRepository
+	sessionRepo repository.SessionRepository
+}
+
+func (svc *serviceAccountSvc) Create(ctx context.Context, principal auth.Token, req models.CreateServiceAccountRequest) (models.ServiceAccountCredentials, error) {
+	ctx = svc.withLogger(ctx)
+	err := assertPermission(principal, models.WriteServiceAccountsPermission)
+	if err != nil {
+		return models.ServiceAccountCredentials{}, err
+	}
+
+	err = validate(req)
+	if err != nil {
+		return models.ServiceAccountCredentials{}, err
+	}
+
+	for _, permission := range req.Permissions {
+		err = assertPermission(principal, permission)
+		if err != nil {
+			return models.ServiceAccountCredentials{}, err
+		}
+	}
+
+	secret, salt, secretHash, err := createClientSecret(ctx, svc.hasher)
+	if err != nil {
+		return models.ServiceAccountCredentials{}, err
+	}
+
+	account := req.ServiceAccount(principal.Subject, secretHash, salt)
+	err = svc.accountRepo.Save(ctx, account)
+	if err != nil {
+		logger(ctx).Errorw("Failed to save service account", "err", err)
+		return models.ServiceAccountCredentials{}, httputil.NewInternalServerError("Failed to save service account")
+	}
+
+	return models.ServiceAccountCredentials{
+		Account:      account,
+		ClientSecret: secret,
+	}, nil
+}
+
+func (svc *serviceAccountSvc) List(ctx context.Context, principal auth.Token) ([]models.ServiceAccount, error) {
+	ctx = svc.withLogger(ctx)
+	err := assertPermission(principal, models.WriteServiceAccountsPermission)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts, err := svc.accountRepo.FindAll(ctx)
+	if err != nil {
+		logger(ctx).Errorw("Failed to find service accounts", "err", err)
+		return nil, httputil.NewInternalServerError("Failed to find service accounts")
+	}
+
+	return accounts, nil
+}
+
+func (svc *serviceAccountSvc) Delete(ctx context.Context, principal auth.Token, id string) error {
+	ctx = svc.withLogger(ctx)
+	err := assertPermission(principal, models.WriteServiceAccountsPermission)
+	if err != nil {
+		return err
+	}
+
+	err = svc.accountRepo.Delete(ctx, id)
+	if err == repository.ErrNoSuchServiceAccount {
+		return errServiceAccountNotFound()
+	} else if err != nil {
+		logger(ctx).Errorw("Failed to delete service account", "clientId", id, "err", err)
+		return httputil.NewInternalServerError("Failed to delete service account")
+	}
+
+	if svc.sessionRepo == nil {
+		return nil
+	}
+
+	err = svc.sessionRepo.DeleteByUserID(ctx, id)
+	if err != nil {
+		logger(ctx).Errorw("Failed to delete service account sessions", "clientId", id, "err", err)
+		return httputil.NewInternalServerError("Failed to delete service account sessions")
+	}
+
+	return nil
+}
+
+func errServiceAccountNotFound() error {
+	return httputil.NewError("No such service account", http.StatusNotFound).
+		WithCode(CodeServiceAccountNotFound).
+		WithMessageKey("serviceAccount.notFound")
+}
@@ -0,0 +1,214 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/CzarSimon/user-service/pkg/auth"
+	"github.com/CzarSimon/user-service/pkg/httputil"
+	"github.com/CzarSimon/user-service/pkg/id"
+	"github.com/CzarSimon/user-service/pkg/models"
+	"github.com/CzarSimon/user-service/pkg/repository"
+	"github.com/CzarSimon/user-service/pkg/repository/repotest"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_serviceAccountSvc_Create(t *testing.T) {
+	assert := assert.New(t)
+	accountRepo := &repotest.MockServiceAccountRepo{}
+	svc := NewServiceAccountService(hasher, accountRepo)
+	principal := auth.Token{
+		Subject:     id.New(),
+		Role:        models.AdminRole,
+		Permissions: []string{models.WriteServiceAccountsPermission, models.ReadUsersPermission},
+	}
+	req := models.CreateServiceAccountRequest{
+		Name:        "Nightly export",
+		Permissions: []string{models.ReadUsersPermission},
+	}
+
+	creds, err := svc.Create(context.Background(), principal, req)
+	assert.NoError(err)
+	assert.NotEmpty(creds.ClientSecret)
+	assert.Equal(accountRepo.SaveArg, creds.Account)
+	assert.Equal(principal.Subject, creds.Account.CreatedBy)
+	assert.Equal(req.Permissions, creds.Account.Permissions)
+	assert.NotEqual(creds.ClientSecret, creds.Account.SecretHash)
+	assert.NoError(hasher.Verify(creds.ClientSecret, creds.Account.Salt, creds.Account.SecretHash))
+
+	accountRepo.UnsetArgs()
+	req.Permissions = []string{models.WriteUsersPermission}
+	_, err = svc.Create(context.Background(), principal, req)
+	assertStatus(t, http.StatusForbidden, err)
+	assert.Equal(0, accountRepo.SaveInvocations)
+
+	_, err = svc.Create(context.Background(), auth.Token{Subject: id.New(), Role: models.UserRole}, req)
+	assertStatus(t, http.StatusForbidden, err)
+
+	_, err = svc.Create(context.Background(), principal, models.CreateServiceAccountRequest{Name: "No permissions"})
+	assertStatus(t, http.StatusBadRequest, err)
+	assertCode(t, httputil.CodeValidationFailed, err)
+
+	accountRepo.SaveErr = errors.New("db failure")
+	req.Permissions = []string{models.ReadUsersPermission}
+	_, err = svc.Create(context.Background(), principal, req)
+	assertStatus(t, http.StatusInternalServerError, err)
+}
+
+func Test_serviceAccountSvc_ListAndDelete(t *testing.T) {
+	assert := assert.New(t)
+	account := testServiceAccount()
+	accountRepo := &repotest.MockServiceAccountRepo{FindAllAccounts: []models.ServiceAccount{account}}
+	sessionRepo := &repotest.MockSessionRepo{}
+	svc := NewServiceAccountService(hasher, accountRepo, WithServiceAccountSessions(sessionRepo))
+	principal := auth.Token{Subject: id.New(), Role: models.AdminRole, Permissions: []string{models.WriteServiceAccountsPermission}}
+
+	accounts, err := svc.List(context.Background(), principal)
+	assert.NoError(err)
+	assert.Equal([]models.ServiceAccount{account}, accounts)
+
+	_, err = svc.List(context.Background(), adminPrincipal())
+	assertStatus(t, http.StatusForbidden, err)
+
+	err = svc.Delete(context.Background(), adminPrincipal(), account.ID)
+	assertStatus(t, http.StatusForbidden, err)
+	assert.Equal(0, accountRepo.DeleteInvocations)
+
+	err = svc.Delete(context.Background(), principal, account.ID)
+	assert.NoError(err)
+	assert.Equal(account.ID, accountRepo.DeleteArg)
+	assert.Equal(account.ID, sessionRepo.DeleteByUserIDArg)
+
+	accountRepo.DeleteErr = repository.ErrNoSuchServiceAccount
+	err = svc.Delete(context.Background(), principal, account.ID)
+	assertStatus(t, http.StatusNotFound, err)
+	assertCode(t, CodeServiceAccountNotFound, err)
+
+	accountRepo.DeleteErr = nil
+	sessionRepo.DeleteByUserIDErr = errors.New("db failure")
+	err = svc.Delete(context.Background(), principal, account.ID)
+	assertStatus(t, http.StatusInternalServerError, err)
+}
+
+func Test_oauthSvc_Token_ClientCredentials(t *testing.T) {
+	account := testServiceAccount()
+
+	tests := []struct {
+		name       string
+		req        models.TokenRequest
+		findErr    error
+		withoutSA  bool
+		wantScopes []string
+		wantStatus int
+		wantCode   string
+	}{
+		{
+			name:       "happy-path-all-permissions",
+			req:        models.TokenRequest{ClientID: account.ID, ClientSecret: "service-secret"},
+			wantScopes: account.Permissions,
+		},
+		{
+			name:       "happy-path-narrowed-scope",
+			req:        models.TokenRequest{ClientID: account.ID, ClientSecret: "service-secret", Scope: models.ReadAuditLogPermission},
+			wantScopes: []string{models.ReadAuditLogPermission},
+		},
+		{
+			name:       "sad-path-scope-not-granted",
+			req:        models.TokenRequest{ClientID: account.ID, ClientSecret: "service-secret", Scope: models.WriteUsersPermission},
+			wantStatus: http.StatusBadRequest,
+			wantCode:   CodeInvalidScope,
+		},
+		{
+			name:       "sad-path-wrong-secret",
+			req:        models.TokenRequest{ClientID: account.ID, ClientSecret: "wrong-secret"},
+			wantStatus: http.StatusUnauthorized,
+			wantCode:   CodeInvalidClient,
+		},
+		{
+			name:       "sad-path-missing-secret",
+			req:        models.TokenRequest{ClientID: account.ID},
+			wantStatus: http.StatusUnauthorized,
+			wantCode:   CodeInvalidClient,
+		},
+		{
+			name:       "sad-path-unknown-account",
+			req:        models.TokenRequest{ClientID: id.New(), ClientSecret: "service-secret"},
+			findErr:    repository.ErrNoSuchServiceAccount,
+			wantStatus: http.StatusUnauthorized,
+			wantCode:   CodeInvalidClient,
+		},
+		{
+			name:       "sad-path-find-fails",
+			req:        models.TokenRequest{ClientID: account.ID, ClientSecret: "service-secret"},
+			findErr:    errors.New("db failure"),
+			wantStatus: http.StatusInternalServerError,
+			wantCode:   "INTERNAL_SERVER_ERROR",
+		},
+		{
+			name:       "sad-path-service-accounts-not-enabled",
+			req:        models.TokenRequest{ClientID: account.ID, ClientSecret: "service-secret"},
+			withoutSA:  true,
+			wantStatus: http.StatusUnauthorized,
+			wantCode:   CodeInvalidClient,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+			accountRepo := &repotest.MockServiceAccountRepo{FindAccount: account, FindErr: tt.findErr}
+			sessionRepo := &repotest.MockSessionRepo{}
+			opts := []OAuthServiceOption{WithOAuthSessions(sessionRepo)}
+			if !tt.withoutSA {
+				opts = append(opts, WithServiceAccounts(accountRepo))
+			}
+			clientRepo := &repotest.MockOAuthClientRepo{FindErr: repository.ErrNoSuchClient}
+			svc := newTestOAuthService(&repotest.MockUserRepo{}, clientRepo, &repotest.MockAuthorizationCodeRepo{}, &repotest.MockRefreshTokenRepo{}, opts...)
+
+			tt.req.GrantType = models.ClientCredentialsGrant
+			res, err := svc.Token(context.Background(), tt.req)
+			if tt.wantStatus != 0 {
+				assertStatus(t, tt.wantStatus, err)
+				assertCode(t, tt.wantCode, err)
+				assert.Equal(0, sessionRepo.SaveInvocations)
+				return
+			}
+
+			assert.NoError(err)
+			assert.Equal(models.BearerTokenType, res.TokenType)
+			assert.Empty(res.RefreshToken)
+			assert.Empty(res.IDToken)
+			assert.Equal(models.FormatScope(tt.wantScopes), res.Scope)
+
+			token, err := verifier.Verify(res.AccessToken)
+			assert.NoError(err)
+			assert.True(token.IsServiceAccount())
+			assert.Equal(account.ID, token.Subject)
+			assert.Equal(account.ID, token.ClientID)
+			assert.Equal(models.ServiceRole, token.Role)
+			assert.Equal(tt.wantScopes, token.Permissions)
+			assert.Equal(tt.wantScopes, token.Scopes)
+			assert.Equal(token.ID, sessionRepo.SaveArg.ID)
+			assert.Equal(account.ID, sessionRepo.SaveArg.UserID)
+		})
+	}
+}
+
+func testServiceAccount() models.ServiceAccount {
+	salt, err := auth.GenSalt(clientSaltLength)
+	if err != nil {
+		panic(err)
+	}
+
+	secretHash, err := hasher.Hash("service-secret", salt)
+	if err != nil {
+		panic(err)
+	}
+
+	req := models.CreateServiceAccountRequest{
+		Name:        "Nightly export",
+		Permissions: []string{models.ReadUsersPermission, models.ReadAuditLogPermission},
+	}
+	return req.ServiceAccount(id.New(), secretHash, salt)
+}
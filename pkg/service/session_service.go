@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/CzarSimon/user-service/pkg/auth"
+	"github.com/CzarSimon/user-service/pkg/httputil"
+	"github.com/CzarSimon/user-service/pkg/models"
+	"github.com/CzarSimon/user-service/pkg/repository"
+	"go.uber.org/zap"
+)
+
+// Error codes returned by the session service.
+const (
+	CodeSessionNotFound = "SESSION_NOT_FOUND"
+)
+
+// lastSeenInterval how often the last seen timestamp of a session is updated.
+const lastSeenInterval = time.Minute
+
+// SessionService service responsible for users and admins managing active sessions.
+type SessionService interface {
+	ListSessions(ctx context.Context, principal auth.Token, userID string) ([]models.Session, error)
+	RevokeSession(ctx context.Context, principal auth.Token, userID, sessionID string) error
+	// RevokeOtherSessions revokes all sessions of a user except the one of the principal.
+	// Returns the number of revoked sessions.
+	RevokeOtherSessions(ctx context.Context, principal auth.Token, userID string) (int, error)
+}
+
+// SessionServiceOption configures optional dependencies of a SessionService.
+type SessionServiceOption func(*sessionSvc)
+
+// WithRevocationAudit writes an audit event to an AuditSink for every revoked session.
+func WithRevocationAudit(sink AuditSink) SessionServiceOption {
+	return func(svc *sessionSvc) {
+		svc.auditSink = sink
+	}
+}
+
+// WithSessionLogger sets the logger used when sessions are managed outside of a request.
+func WithSessionLogger(log *zap.Logger) SessionServiceOption {
+	return func(svc *sessionSvc) {
+		svc.log = log
+	}
+}
+
+// NewSessionService creates a new SessionService.
+func NewSessionService(sessionRepo repository.SessionRepository, opts ...SessionServiceOption) SessionService {
+	svc := &sessionSvc{
+		sessionRepo: traceSessionRepo(sessionRepo),
+	}
+
+	for _, opt := range opts {
+		opt(svc)
+	}
+	return svc
+}
+
+type sessionSvc struct {
+	logging
+	sessionRepo repository.SessionRepository
+	auditSink   AuditSink
+}
+
+func (svc *sessionSvc) ListSessions(ctx context.Context, principal auth.Token, userID string) ([]models.Session, error) {
+	ctx = svc.withLogger(ctx)
+	err := assertUserAccess(principal, userID, models.ReadUsersPermission)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := svc.sessionRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		logger(ctx).Errorw("Failed to find sessions", "userId", userID, "err", err)
+		return nil, httputil.NewInternalServerError("Failed to find sessions")
+	}
+
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == principal.ID
+	}
+	return sessions, nil
+}
+
+func (svc *sessionSvc) RevokeSession(ctx context.Context, principal auth.Token, userID, sessionID string) error {
+	ctx = svc.withLogger(ctx)
+	err := assertUserAccess(principal, userID, models.WriteUsersPermission)
+	if err != nil {
+		return err
+	}
+
+	session, err := svc.sessionRepo.Find(ctx, sessionID)
+	if err == repository.ErrNoSuchSession || (err == nil && session.UserID != userID) {
+		return errSessionNotFound()
+	} else if err != nil {
+		logger(ctx).Errorw("Failed to find session", "sessionId", sessionID, "err", err)
+		return httputil.NewInternalServerError("Failed to find session")
+	}
+
+	if session.IsRevoked() {
+		return nil
+	}
+
+	return svc.revoke(ctx, principal, session)
+}
+
+func (svc *sessionSvc) RevokeOtherSessions(ctx context.Context, principal auth.Token, userID string) (int, error) {
+	ctx = svc.withLogger(ctx)
+	err := assertUserAccess(principal, userID, models.WriteUsersPermission)
+	if err != nil {
+		return 0, err
+	}
+
+	sessions, err := svc.sessionRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		logger(ctx).Errorw("Failed to find sessions", "userId", userID, "err", err)
+		return 0, httputil.NewInternalServerError("Failed to find sessions")
+	}
+
+	revoked := 0
+	for _, session := range sessions {
+		if session.ID == principal.ID {
+			continue
+		}
+
+		err = svc.revoke(ctx, principal, session)
+		if err != nil {
+			return revoked, err
+		}
+		revoked++
+	}
+
+	return revoked, nil
+}
+
+func (svc *sessionSvc) revoke(ctx context.Context, principal auth.Token, session models.Session) error {
+	err := svc.sessionRepo.Revoke(ctx, session.ID, time.Now().UTC())
+	if err == repository.ErrNoSuchSession {
+		err = errSessionNotFound()
+	} else if err != nil {
+		logger(ctx).Errorw("Failed to revoke session", "sessionId", session.ID, "err", err)
+		err = httputil.NewInternalServerError("Failed to revoke session")
+	}
+
+	audit(ctx, svc.auditSink, models.AuditTokenRevocation, principal.Subject, session.UserID, err)
+	return err
+}
+
+// NewSessionVerifier wraps an auth.Verifier so that tokens are only accepted while their
+// session exists and has not been revoked. Also keeps track of when sessions were last seen.
+// Failures are logged with the given logger, as verification happens outside of any service call.
+// API keys are not tied to sessions and are passed on to the wrapped verifier if it verifies them,
+// so it may wrap a verifier created by NewAPIKeyVerifier.
+func NewSessionVerifier(verifier auth.Verifier, sessionRepo repository.SessionRepository, log *zap.Logger) auth.Verifier {
+	return &sessionVerifier{
+		verifier:    verifier,
+		sessionRepo: sessionRepo,
+		logging:     logging{log: log},
+	}
+}
+
+type sessionVerifier struct {
+	logging
+	verifier    auth.Verifier
+	sessionRepo repository.SessionRepository
+}
+
+func (v *sessionVerifier) Verify(rawToken string) (auth.Token, error) {
+	token, err := v.verifier.Verify(rawToken)
+	if err != nil {
+		return auth.Token{}, err
+	}
+
+	ctx := v.withLogger(context.Background())
+	session, err := v.sessionRepo.Find(ctx, token.ID)
+	if err == repository.ErrNoSuchSession {
+		return auth.Token{}, auth.ErrInvalidToken
+	} else if err != nil {
+		logger(ctx).Errorw("Failed to find session", "sessionId", token.ID, "err", err)
+		return auth.Token{}, httputil.NewInternalServerError("Failed to verify token")
+	}
+
+	if session.IsRevoked() || session.UserID != token.Subject {
+		return auth.Token{}, auth.ErrInvalidToken
+	}
+
+	now := time.Now().UTC()
+	if now.Sub(session.LastSeenAt) > lastSeenInterval {
+		err = v.sessionRepo.UpdateLastSeen(ctx, session.ID, now)
+		if err != nil {
+			logger(ctx).Errorw("Failed to update session last seen", "sessionId", session.ID, "err", err)
+		}
+	}
+
+	return token, nil
+}
+
+func (v *sessionVerifier) VerifyAPIKey(rawKey string) (auth.Token, error) {
+	keyVerifier, ok := v.verifier.(auth.APIKeyVerifier)
+	if !ok {
+		return auth.Token{}, auth.ErrInvalidAPIKey
+	}
+	return keyVerifier.VerifyAPIKey(rawKey)
+}
+
+func errSessionNotFound() error {
+	return httputil.NewError("No such session", http.StatusNotFound).
+		WithCode(CodeSessionNotFound).
+		WithMessageKey("session.notFound")
+}
@@ -0,0 +1,284 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/CzarSimon/user-service/pkg/auth"
+	"github.com/CzarSimon/user-service/pkg/httputil"
+	"github.com/CzarSimon/user-service/pkg/id"
+	"github.com/CzarSimon/user-service/pkg/models"
+	"github.com/CzarSimon/user-service/pkg/repository"
+	"github.com/CzarSimon/user-service/pkg/repository/repotest"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func Test_userSvc_Login_CreatesSession(t *testing.T) {
+	assert := assert.New(t)
+	user := testUser()
+	userRepo := &repotest.MockUserRepo{FindByEmailUser: user}
+	sessionRepo := &repotest.MockSessionRepo{}
+	svc := NewUserService(hasher, issuer, userRepo, roleRepo, WithSessions(sessionRepo))
+	ctx := httputil.WithRequestMetadata(context.Background(), httputil.RequestMetadata{
+		IP:        "10.0.0.1",
+		UserAgent: "test-agent/1.0",
+	})
+
+	res, err := svc.Login(ctx, models.LoginRequest{Email: user.Email, Password: "secret-drowssap"})
+	assert.NoError(err)
+	token, err := verifier.Verify(res.Token)
+	assert.NoError(err)
+
+	session := sessionRepo.SaveArg
+	assert.Equal(token.ID, session.ID)
+	assert.Equal(user.ID, session.UserID)
+	assert.Equal("10.0.0.1", session.IP)
+	assert.Equal("test-agent/1.0", session.UserAgent)
+	assert.False(session.CreatedAt.IsZero())
+	assert.Equal(session.CreatedAt, session.LastSeenAt)
+
+	sessionRepo.SaveErr = errors.New("db failure")
+	_, err = svc.Login(ctx, models.LoginRequest{Email: user.Email, Password: "secret-drowssap"})
+	assertStatus(t, http.StatusInternalServerError, err)
+}
+
+func Test_sessionSvc_ListSessions(t *testing.T) {
+	assert := assert.New(t)
+	userID := id.New()
+	principal := auth.Token{ID: id.New(), Subject: userID, Role: models.UserRole}
+	sessionRepo := &repotest.MockSessionRepo{
+		FindByUserIDSessions: []models.Session{
+			models.NewSession(principal.ID, userID, models.ClientInfo{}),
+			models.NewSession(id.New(), userID, models.ClientInfo{}),
+		},
+	}
+	svc := NewSessionService(sessionRepo)
+
+	sessions, err := svc.ListSessions(context.Background(), principal, userID)
+	assert.NoError(err)
+	assert.Len(sessions, 2)
+	assert.True(sessions[0].Current)
+	assert.False(sessions[1].Current)
+	assert.Equal(userID, sessionRepo.FindByUserIDArg)
+
+	_, err = svc.ListSessions(context.Background(), principal, id.New())
+	assertStatus(t, http.StatusForbidden, err)
+
+	_, err = svc.ListSessions(context.Background(), adminPrincipal(), userID)
+	assert.NoError(err)
+
+	sessionRepo.FindByUserIDErr = errors.New("db failure")
+	_, err = svc.ListSessions(context.Background(), principal, userID)
+	assertStatus(t, http.StatusInternalServerError, err)
+}
+
+func Test_sessionSvc_RevokeSession(t *testing.T) {
+	userID := id.New()
+	principal := auth.Token{ID: id.New(), Subject: userID, Role: models.UserRole}
+	session := models.NewSession(id.New(), userID, models.ClientInfo{})
+	revoked := session
+	revoked.RevokedAt = time.Now().UTC()
+
+	tests := []struct {
+		name            string
+		sessionRepo     *repotest.MockSessionRepo
+		principal       auth.Token
+		userID          string
+		wantErr         int
+		wantRevocations int
+	}{
+		{
+			name:            "happy-path",
+			sessionRepo:     &repotest.MockSessionRepo{FindSession: session},
+			principal:       principal,
+			userID:          userID,
+			wantRevocations: 1,
+		},
+		{
+			name:            "happy-path-admin",
+			sessionRepo:     &repotest.MockSessionRepo{FindSession: session},
+			principal:       adminPrincipal(),
+			userID:          userID,
+			wantRevocations: 1,
+		},
+		{
+			name:            "happy-path-already-revoked",
+			sessionRepo:     &repotest.MockSessionRepo{FindSession: revoked},
+			principal:       principal,
+			userID:          userID,
+			wantRevocations: 0,
+		},
+		{
+			name:        "sad-path-other-user",
+			sessionRepo: &repotest.MockSessionRepo{FindSession: session},
+			principal:   auth.Token{ID: id.New(), Subject: id.New(), Role: models.UserRole},
+			userID:      userID,
+			wantErr:     http.StatusForbidden,
+		},
+		{
+			name:        "sad-path-session-of-other-user",
+			sessionRepo: &repotest.MockSessionRepo{FindSession: models.NewSession(id.New(), id.New(), models.ClientInfo{})},
+			principal:   principal,
+			userID:      userID,
+			wantErr:     http.StatusNotFound,
+		},
+		{
+			name:        "sad-path-no-such-session",
+			sessionRepo: &repotest.MockSessionRepo{FindErr: repository.ErrNoSuchSession},
+			principal:   principal,
+			userID:      userID,
+			wantErr:     http.StatusNotFound,
+		},
+		{
+			name:            "sad-path-revoke-failure",
+			sessionRepo:     &repotest.MockSessionRepo{FindSession: session, RevokeErr: errors.New("db failure")},
+			principal:       principal,
+			userID:          userID,
+			wantErr:         http.StatusInternalServerError,
+			wantRevocations: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewSessionService(tt.sessionRepo)
+			err := svc.RevokeSession(context.Background(), tt.principal, tt.userID, session.ID)
+			if tt.wantErr != 0 {
+				assertStatus(t, tt.wantErr, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantRevocations, tt.sessionRepo.RevokeInvocations)
+		})
+	}
+}
+
+func Test_sessionSvc_RevokeOtherSessions(t *testing.T) {
+	assert := assert.New(t)
+	userID := id.New()
+	principal := auth.Token{ID: id.New(), Subject: userID, Role: models.UserRole}
+	other := models.NewSession(id.New(), userID, models.ClientInfo{})
+	sessionRepo := &repotest.MockSessionRepo{
+		FindByUserIDSessions: []models.Session{
+			models.NewSession(principal.ID, userID, models.ClientInfo{}),
+			other,
+		},
+	}
+	log := &mockAuditLog{}
+	svc := NewSessionService(sessionRepo, WithRevocationAudit(log))
+
+	revoked, err := svc.RevokeOtherSessions(context.Background(), principal, userID)
+	assert.NoError(err)
+	assert.Equal(1, revoked)
+	assert.Equal([]string{other.ID}, sessionRepo.RevokeArgs)
+	assert.Len(log.events, 1)
+	assert.Equal(models.AuditTokenRevocation, log.events[0].Type)
+	assert.Equal(userID, log.events[0].ActorID)
+	assert.Equal(models.AuditSuccess, log.events[0].Outcome)
+
+	sessionRepo.UnsetArgs()
+	revoked, err = svc.RevokeOtherSessions(context.Background(), adminPrincipal(), userID)
+	assert.NoError(err)
+	assert.Equal(2, revoked)
+
+	_, err = svc.RevokeOtherSessions(context.Background(), principal, id.New())
+	assertStatus(t, http.StatusForbidden, err)
+}
+
+func TestSessionVerifier(t *testing.T) {
+	assert := assert.New(t)
+	user := testUser()
+	rawToken, err := issuer.Issue(user.ID, models.UserRole)
+	assert.NoError(err)
+	tokenID, err := auth.TokenID(rawToken)
+	assert.NoError(err)
+
+	session := models.NewSession(tokenID, user.ID, models.ClientInfo{})
+	sessionRepo := &repotest.MockSessionRepo{FindSession: session}
+	core, logs := observer.New(zapcore.ErrorLevel)
+	sessionVerifier := NewSessionVerifier(verifier, sessionRepo, zap.New(core))
+
+	token, err := sessionVerifier.Verify(rawToken)
+	assert.NoError(err)
+	assert.Equal(user.ID, token.Subject)
+	assert.Equal(tokenID, sessionRepo.FindArg)
+	assert.Equal(0, sessionRepo.UpdateLastSeenInvocations)
+
+	sessionRepo.FindSession.LastSeenAt = time.Now().UTC().Add(-time.Hour)
+	_, err = sessionVerifier.Verify(rawToken)
+	assert.NoError(err)
+	assert.Equal(1, sessionRepo.UpdateLastSeenInvocations)
+
+	sessionRepo.UpdateLastSeenErr = errors.New("db failure")
+	_, err = sessionVerifier.Verify(rawToken)
+	assert.NoError(err)
+	assert.Len(logs.FilterMessage("Failed to update session last seen").All(), 1)
+	sessionRepo.UpdateLastSeenErr = nil
+
+	sessionRepo.FindSession.RevokedAt = time.Now().UTC()
+	_, err = sessionVerifier.Verify(rawToken)
+	assert.Equal(auth.ErrInvalidToken, err)
+
+	sessionRepo.FindErr = repository.ErrNoSuchSession
+	_, err = sessionVerifier.Verify(rawToken)
+	assert.Equal(auth.ErrInvalidToken, err)
+
+	sessionRepo.FindErr = errors.New("pq: connection refused")
+	_, err = sessionVerifier.Verify(rawToken)
+	assertStatus(t, http.StatusInternalServerError, err)
+	assert.NotContains(err.Error(), "connection refused")
+	assert.Len(logs.FilterMessage("Failed to find session").All(), 1)
+
+	_, err = sessionVerifier.Verify("not-a-token")
+	assert.Equal(auth.ErrInvalidToken, err)
+}
+
+func TestSessionVerifier_VerifiesAPIKeys(t *testing.T) {
+	assert := assert.New(t)
+	user := testUser()
+	rawKey, prefix, err := auth.GenerateAPIKey()
+	assert.NoError(err)
+	key := models.NewAPIKey(user.ID, prefix, auth.HashToken(rawKey), models.CreateAPIKeyRequest{Name: "Deploy script"})
+	keyRepo := &repotest.MockAPIKeyRepo{FindByPrefixKey: key}
+	sessionRepo := &repotest.MockSessionRepo{FindErr: repository.ErrNoSuchSession}
+	keyVerifier := NewAPIKeyVerifier(verifier, keyRepo, &repotest.MockUserRepo{FindUser: user}, roleRepo, zap.NewNop())
+
+	sessionVerifier, ok := NewSessionVerifier(keyVerifier, sessionRepo, zap.NewNop()).(auth.APIKeyVerifier)
+	assert.True(ok)
+	token, err := sessionVerifier.VerifyAPIKey(rawKey)
+	assert.NoError(err)
+	assert.Equal(user.ID, token.Subject)
+	assert.Equal(key.ID, token.ID)
+	assert.Equal(0, sessionRepo.FindInvocations)
+
+	sessionVerifier = NewSessionVerifier(verifier, sessionRepo, zap.NewNop()).(auth.APIKeyVerifier)
+	_, err = sessionVerifier.VerifyAPIKey(rawKey)
+	assert.Equal(auth.ErrInvalidAPIKey, err)
+}
+
+func Test_userSvc_Logout(t *testing.T) {
+	assert := assert.New(t)
+	principal := auth.Token{ID: id.New(), Subject: id.New(), Role: models.UserRole}
+	sessionRepo := &repotest.MockSessionRepo{}
+	svc := NewUserService(hasher, issuer, &repotest.MockUserRepo{}, roleRepo, WithSessions(sessionRepo))
+
+	err := svc.Logout(context.Background(), principal)
+	assert.NoError(err)
+	assert.Equal([]string{principal.ID}, sessionRepo.RevokeArgs)
+
+	sessionRepo.RevokeErr = repository.ErrNoSuchSession
+	err = svc.Logout(context.Background(), principal)
+	assert.NoError(err)
+
+	sessionRepo.RevokeErr = errors.New("db failure")
+	err = svc.Logout(context.Background(), principal)
+	assertStatus(t, http.StatusInternalServerError, err)
+
+	err = NewUserService(hasher, issuer, &repotest.MockUserRepo{}, roleRepo).Logout(context.Background(), principal)
+	assert.NoError(err)
+}
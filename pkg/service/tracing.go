@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+
+	"github.com/CzarSimon/user-service/pkg/auth"
+	"github.com/CzarSimon/user-service/pkg/repository"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/CzarSimon/user-service/pkg/service"
+
+// tracer creates spans using the global tracer provider, which can be set after the tracer is created.
+var tracer = otel.Tracer(tracerName)
+
+func startSpan(ctx context.Context, name string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name)
+}
+
+// expectedErrors are returned by repositories as part of normal operation, such as
+// looking up a user that does not exist, and are not recorded as span errors.
+var expectedErrors = map[error]bool{
+	repository.ErrNoSuchUser:              true,
+	repository.ErrUserExists:              true,
+	repository.ErrVersionConflict:         true,
+	repository.ErrNoSuchRole:              true,
+	repository.ErrNoSuchSession:           true,
+	repository.ErrNoSuchAPIKey:            true,
+	repository.ErrAPIKeyExists:            true,
+	repository.ErrNoSuchEmailChange:       true,
+	repository.ErrNoSuchClient:            true,
+	repository.ErrNoSuchAuthorizationCode: true,
+	repository.ErrNoSuchRefreshToken:      true,
+	repository.ErrNoSuchServiceAccount:    true,
+}
+
+// spanError records an unexpected error on a span and returns the error.
+func spanError(span trace.Span, err error) error {
+	if err != nil && !expectedErrors[err] {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
+}
+
+// hashPassword hashes a password in a span.
+func hashPassword(ctx context.Context, hasher auth.Hasher, password, salt string) (string, error) {
+	_, span := startSpan(ctx, "Hasher.Hash")
+	defer span.End()
+
+	hash, err := hasher.Hash(password, salt)
+	span.SetAttributes(attribute.String("hash.algorithm", hashAlgorithm(hash)))
+	return hash, spanError(span, err)
+}
+
+// verifyPassword verifies a password against a hash in a span. Mismatching
+// passwords are expected and not recorded as span errors.
+func verifyPassword(ctx context.Context, hasher auth.Hasher, password, salt, hash string) error {
+	_, span := startSpan(ctx, "Hasher.Verify")
+	defer span.End()
+
+	err := hasher.Verify(password, salt, hash)
+	span.SetAttributes(
+		attribute.String("hash.algorithm", hashAlgorithm(hash)),
+		attribute.Bool("hash.match", err == nil),
+	)
+	if err != nil && err != auth.ErrHashMissmatch {
+		spanError(span, err)
+	}
+	return err
+}
+
+// issueToken issues a token in a span.
+func issueToken(ctx context.Context, issuer auth.Issuer, sub, role string, permissions ...string) (string, error) {
+	_, span := startSpan(ctx, "Issuer.Issue")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("token.role", role))
+	token, err := issuer.Issue(sub, role, permissions...)
+	return token, spanError(span, err)
+}
+
+// issueGrant issues a token to an OAuth client in a span.
+func issueGrant(ctx context.Context, issuer auth.GrantIssuer, sub, role string, grant auth.Grant, permissions ...string) (string, error) {
+	_, span := startSpan(ctx, "Issuer.IssueGrant")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("token.role", role),
+		attribute.String("token.clientId", grant.ClientID),
+	)
+	token, err := issuer.IssueGrant(sub, role, grant, permissions...)
+	return token, spanError(span, err)
+}
+
+// issueIDToken issues an OpenID Connect ID token in a span.
+func issueIDToken(ctx context.Context, issuer auth.IDTokenIssuer, token auth.IDToken) (string, error) {
+	_, span := startSpan(ctx, "IDTokenIssuer.IssueIDToken")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("token.clientId", token.Audience))
+	idToken, err := issuer.IssueIDToken(token)
+	return idToken, spanError(span, err)
+}
@@ -0,0 +1,432 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/CzarSimon/user-service/pkg/models"
+	"github.com/CzarSimon/user-service/pkg/repository"
+)
+
+type tracedUserRepo struct {
+	repo repository.UserRepository
+}
+
+// traceUserRepo wraps a repository so that every call to it is traced.
+func traceUserRepo(repo repository.UserRepository) repository.UserRepository {
+	return &tracedUserRepo{repo: repo}
+}
+
+func (r *tracedUserRepo) Find(ctx context.Context, id string) (models.User, error) {
+	ctx, span := startSpan(ctx, "UserRepository.Find")
+	defer span.End()
+	result, err := r.repo.Find(ctx, id)
+	return result, spanError(span, err)
+}
+
+func (r *tracedUserRepo) FindByEmail(ctx context.Context, email string) (models.User, error) {
+	ctx, span := startSpan(ctx, "UserRepository.FindByEmail")
+	defer span.End()
+	result, err := r.repo.FindByEmail(ctx, email)
+	return result, spanError(span, err)
+}
+
+func (r *tracedUserRepo) Save(ctx context.Context, user models.User) error {
+	ctx, span := startSpan(ctx, "UserRepository.Save")
+	defer span.End()
+	return spanError(span, r.repo.Save(ctx, user))
+}
+
+func (r *tracedUserRepo) UpdateCredentials(ctx context.Context, credentials models.Credentials) error {
+	ctx, span := startSpan(ctx, "UserRepository.UpdateCredentials")
+	defer span.End()
+	return spanError(span, r.repo.UpdateCredentials(ctx, credentials))
+}
+
+func (r *tracedUserRepo) ChangeEmail(ctx context.Context, id, oldEmail, newEmail string) error {
+	ctx, span := startSpan(ctx, "UserRepository.ChangeEmail")
+	defer span.End()
+	return spanError(span, r.repo.ChangeEmail(ctx, id, oldEmail, newEmail))
+}
+
+func (r *tracedUserRepo) UpdateProfile(ctx context.Context, user models.User, expectedVersion int) error {
+	ctx, span := startSpan(ctx, "UserRepository.UpdateProfile")
+	defer span.End()
+	return spanError(span, r.repo.UpdateProfile(ctx, user, expectedVersion))
+}
+
+func (r *tracedUserRepo) List(ctx context.Context, query models.UserQuery) ([]models.User, error) {
+	ctx, span := startSpan(ctx, "UserRepository.List")
+	defer span.End()
+	result, err := r.repo.List(ctx, query)
+	return result, spanError(span, err)
+}
+
+func (r *tracedUserRepo) UpdateRoles(ctx context.Context, user models.User) error {
+	ctx, span := startSpan(ctx, "UserRepository.UpdateRoles")
+	defer span.End()
+	return spanError(span, r.repo.UpdateRoles(ctx, user))
+}
+
+func (r *tracedUserRepo) SetDisabled(ctx context.Context, id string, disabled bool) error {
+	ctx, span := startSpan(ctx, "UserRepository.SetDisabled")
+	defer span.End()
+	return spanError(span, r.repo.SetDisabled(ctx, id, disabled))
+}
+
+func (r *tracedUserRepo) Delete(ctx context.Context, id string) error {
+	ctx, span := startSpan(ctx, "UserRepository.Delete")
+	defer span.End()
+	return spanError(span, r.repo.Delete(ctx, id))
+}
+
+func (r *tracedUserRepo) MarkDeleted(ctx context.Context, id string, deletedAt time.Time) error {
+	ctx, span := startSpan(ctx, "UserRepository.MarkDeleted")
+	defer span.End()
+	return spanError(span, r.repo.MarkDeleted(ctx, id, deletedAt))
+}
+
+func (r *tracedUserRepo) FindDeletedBefore(ctx context.Context, t time.Time) ([]models.User, error) {
+	ctx, span := startSpan(ctx, "UserRepository.FindDeletedBefore")
+	defer span.End()
+	result, err := r.repo.FindDeletedBefore(ctx, t)
+	return result, spanError(span, err)
+}
+
+type tracedRoleRepo struct {
+	repo repository.RoleRepository
+}
+
+// traceRoleRepo wraps a repository so that every call to it is traced.
+func traceRoleRepo(repo repository.RoleRepository) repository.RoleRepository {
+	return &tracedRoleRepo{repo: repo}
+}
+
+func (r *tracedRoleRepo) Find(ctx context.Context, name string) (models.Role, error) {
+	ctx, span := startSpan(ctx, "RoleRepository.Find")
+	defer span.End()
+	result, err := r.repo.Find(ctx, name)
+	return result, spanError(span, err)
+}
+
+func (r *tracedRoleRepo) FindByNames(ctx context.Context, names []string) ([]models.Role, error) {
+	ctx, span := startSpan(ctx, "RoleRepository.FindByNames")
+	defer span.End()
+	result, err := r.repo.FindByNames(ctx, names)
+	return result, spanError(span, err)
+}
+
+func (r *tracedRoleRepo) Save(ctx context.Context, role models.Role) error {
+	ctx, span := startSpan(ctx, "RoleRepository.Save")
+	defer span.End()
+	return spanError(span, r.repo.Save(ctx, role))
+}
+
+type tracedLoginHistoryRepo struct {
+	repo repository.LoginHistoryRepository
+}
+
+// traceLoginHistoryRepo wraps a repository so that every call to it is traced.
+func traceLoginHistoryRepo(repo repository.LoginHistoryRepository) repository.LoginHistoryRepository {
+	if repo == nil {
+		return nil
+	}
+
+	return &tracedLoginHistoryRepo{repo: repo}
+}
+
+func (r *tracedLoginHistoryRepo) Save(ctx context.Context, event models.LoginEvent) error {
+	ctx, span := startSpan(ctx, "LoginHistoryRepository.Save")
+	defer span.End()
+	return spanError(span, r.repo.Save(ctx, event))
+}
+
+func (r *tracedLoginHistoryRepo) FindByUserID(ctx context.Context, userID string) ([]models.LoginEvent, error) {
+	ctx, span := startSpan(ctx, "LoginHistoryRepository.FindByUserID")
+	defer span.End()
+	result, err := r.repo.FindByUserID(ctx, userID)
+	return result, spanError(span, err)
+}
+
+func (r *tracedLoginHistoryRepo) DeleteByUserID(ctx context.Context, userID string) error {
+	ctx, span := startSpan(ctx, "LoginHistoryRepository.DeleteByUserID")
+	defer span.End()
+	return spanError(span, r.repo.DeleteByUserID(ctx, userID))
+}
+
+type tracedEmailChangeRepo struct {
+	repo repository.EmailChangeRepository
+}
+
+// traceEmailChangeRepo wraps a repository so that every call to it is traced.
+func traceEmailChangeRepo(repo repository.EmailChangeRepository) repository.EmailChangeRepository {
+	return &tracedEmailChangeRepo{repo: repo}
+}
+
+func (r *tracedEmailChangeRepo) Save(ctx context.Context, change models.EmailChange) error {
+	ctx, span := startSpan(ctx, "EmailChangeRepository.Save")
+	defer span.End()
+	return spanError(span, r.repo.Save(ctx, change))
+}
+
+func (r *tracedEmailChangeRepo) FindByConfirmToken(ctx context.Context, tokenHash string) (models.EmailChange, error) {
+	ctx, span := startSpan(ctx, "EmailChangeRepository.FindByConfirmToken")
+	defer span.End()
+	result, err := r.repo.FindByConfirmToken(ctx, tokenHash)
+	return result, spanError(span, err)
+}
+
+func (r *tracedEmailChangeRepo) FindByRevertToken(ctx context.Context, tokenHash string) (models.EmailChange, error) {
+	ctx, span := startSpan(ctx, "EmailChangeRepository.FindByRevertToken")
+	defer span.End()
+	result, err := r.repo.FindByRevertToken(ctx, tokenHash)
+	return result, spanError(span, err)
+}
+
+func (r *tracedEmailChangeRepo) Update(ctx context.Context, change models.EmailChange) error {
+	ctx, span := startSpan(ctx, "EmailChangeRepository.Update")
+	defer span.End()
+	return spanError(span, r.repo.Update(ctx, change))
+}
+
+func (r *tracedEmailChangeRepo) DeleteByUserID(ctx context.Context, userID string) error {
+	ctx, span := startSpan(ctx, "EmailChangeRepository.DeleteByUserID")
+	defer span.End()
+	return spanError(span, r.repo.DeleteByUserID(ctx, userID))
+}
+
+type tracedSessionRepo struct {
+	repo repository.SessionRepository
+}
+
+// traceSessionRepo wraps a repository so that every call to it is traced.
+func traceSessionRepo(repo repository.SessionRepository) repository.SessionRepository {
+	if repo == nil {
+		return nil
+	}
+
+	return &tracedSessionRepo{repo: repo}
+}
+
+func (r *tracedSessionRepo) Save(ctx context.Context, session models.Session) error {
+	ctx, span := startSpan(ctx, "SessionRepository.Save")
+	defer span.End()
+	return spanError(span, r.repo.Save(ctx, session))
+}
+
+func (r *tracedSessionRepo) Find(ctx context.Context, id string) (models.Session, error) {
+	ctx, span := startSpan(ctx, "SessionRepository.Find")
+	defer span.End()
+	result, err := r.repo.Find(ctx, id)
+	return result, spanError(span, err)
+}
+
+func (r *tracedSessionRepo) FindByUserID(ctx context.Context, userID string) ([]models.Session, error) {
+	ctx, span := startSpan(ctx, "SessionRepository.FindByUserID")
+	defer span.End()
+	result, err := r.repo.FindByUserID(ctx, userID)
+	return result, spanError(span, err)
+}
+
+func (r *tracedSessionRepo) UpdateLastSeen(ctx context.Context, id string, lastSeenAt time.Time) error {
+	ctx, span := startSpan(ctx, "SessionRepository.UpdateLastSeen")
+	defer span.End()
+	return spanError(span, r.repo.UpdateLastSeen(ctx, id, lastSeenAt))
+}
+
+func (r *tracedSessionRepo) Revoke(ctx context.Context, id string, revokedAt time.Time) error {
+	ctx, span := startSpan(ctx, "SessionRepository.Revoke")
+	defer span.End()
+	return spanError(span, r.repo.Revoke(ctx, id, revokedAt))
+}
+
+func (r *tracedSessionRepo) RevokeByUserID(ctx context.Context, userID string, revokedAt time.Time) error {
+	ctx, span := startSpan(ctx, "SessionRepository.RevokeByUserID")
+	defer span.End()
+	return spanError(span, r.repo.RevokeByUserID(ctx, userID, revokedAt))
+}
+
+func (r *tracedSessionRepo) DeleteByUserID(ctx context.Context, userID string) error {
+	ctx, span := startSpan(ctx, "SessionRepository.DeleteByUserID")
+	defer span.End()
+	return spanError(span, r.repo.DeleteByUserID(ctx, userID))
+}
+
+type tracedOAuthClientRepo struct {
+	repo repository.OAuthClientRepository
+}
+
+// traceOAuthClientRepo wraps a repository so that every call to it is traced.
+func traceOAuthClientRepo(repo repository.OAuthClientRepository) repository.OAuthClientRepository {
+	return &tracedOAuthClientRepo{repo: repo}
+}
+
+func (r *tracedOAuthClientRepo) Save(ctx context.Context, client models.OAuthClient) error {
+	ctx, span := startSpan(ctx, "OAuthClientRepository.Save")
+	defer span.End()
+	return spanError(span, r.repo.Save(ctx, client))
+}
+
+func (r *tracedOAuthClientRepo) Find(ctx context.Context, id string) (models.OAuthClient, error) {
+	ctx, span := startSpan(ctx, "OAuthClientRepository.Find")
+	defer span.End()
+	result, err := r.repo.Find(ctx, id)
+	return result, spanError(span, err)
+}
+
+type tracedAuthorizationCodeRepo struct {
+	repo repository.AuthorizationCodeRepository
+}
+
+// traceAuthorizationCodeRepo wraps a repository so that every call to it is traced.
+func traceAuthorizationCodeRepo(repo repository.AuthorizationCodeRepository) repository.AuthorizationCodeRepository {
+	return &tracedAuthorizationCodeRepo{repo: repo}
+}
+
+func (r *tracedAuthorizationCodeRepo) Save(ctx context.Context, code models.AuthorizationCode) error {
+	ctx, span := startSpan(ctx, "AuthorizationCodeRepository.Save")
+	defer span.End()
+	return spanError(span, r.repo.Save(ctx, code))
+}
+
+func (r *tracedAuthorizationCodeRepo) Consume(ctx context.Context, codeHash string) (models.AuthorizationCode, error) {
+	ctx, span := startSpan(ctx, "AuthorizationCodeRepository.Consume")
+	defer span.End()
+	result, err := r.repo.Consume(ctx, codeHash)
+	return result, spanError(span, err)
+}
+
+type tracedRefreshTokenRepo struct {
+	repo repository.RefreshTokenRepository
+}
+
+// traceRefreshTokenRepo wraps a repository so that every call to it is traced.
+func traceRefreshTokenRepo(repo repository.RefreshTokenRepository) repository.RefreshTokenRepository {
+	return &tracedRefreshTokenRepo{repo: repo}
+}
+
+func (r *tracedRefreshTokenRepo) Save(ctx context.Context, token models.RefreshToken) error {
+	ctx, span := startSpan(ctx, "RefreshTokenRepository.Save")
+	defer span.End()
+	return spanError(span, r.repo.Save(ctx, token))
+}
+
+func (r *tracedRefreshTokenRepo) Find(ctx context.Context, tokenHash string) (models.RefreshToken, error) {
+	ctx, span := startSpan(ctx, "RefreshTokenRepository.Find")
+	defer span.End()
+	result, err := r.repo.Find(ctx, tokenHash)
+	return result, spanError(span, err)
+}
+
+func (r *tracedRefreshTokenRepo) Consume(ctx context.Context, tokenHash string, revokedAt time.Time) error {
+	ctx, span := startSpan(ctx, "RefreshTokenRepository.Consume")
+	defer span.End()
+	return spanError(span, r.repo.Consume(ctx, tokenHash, revokedAt))
+}
+
+func (r *tracedRefreshTokenRepo) RevokeByUserID(ctx context.Context, userID string, revokedAt time.Time) error {
+	ctx, span := startSpan(ctx, "RefreshTokenRepository.RevokeByUserID")
+	defer span.End()
+	return spanError(span, r.repo.RevokeByUserID(ctx, userID, revokedAt))
+}
+
+func (r *tracedRefreshTokenRepo) DeleteByUserID(ctx context.Context, userID string) error {
+	ctx, span := startSpan(ctx, "RefreshTokenRepository.DeleteByUserID")
+	defer span.End()
+	return spanError(span, r.repo.DeleteByUserID(ctx, userID))
+}
+
+type tracedServiceAccountRepo struct {
+	repo repository.ServiceAccountRepository
+}
+
+// traceServiceAccountRepo wraps a repository so that every call to it is traced.
+func traceServiceAccountRepo(repo repository.ServiceAccountRepository) repository.ServiceAccountRepository {
+	return &tracedServiceAccountRepo{repo: repo}
+}
+
+func (r *tracedServiceAccountRepo) Save(ctx context.Context, account models.ServiceAccount) error {
+	ctx, span := startSpan(ctx, "ServiceAccountRepository.Save")
+	defer span.End()
+	return spanError(span, r.repo.Save(ctx, account))
+}
+
+func (r *tracedServiceAccountRepo) Find(ctx context.Context, id string) (models.ServiceAccount, error) {
+	ctx, span := startSpan(ctx, "ServiceAccountRepository.Find")
+	defer span.End()
+	result, err := r.repo.Find(ctx, id)
+	return result, spanError(span, err)
+}
+
+func (r *tracedServiceAccountRepo) FindAll(ctx context.Context) ([]models.ServiceAccount, error) {
+	ctx, span := startSpan(ctx, "ServiceAccountRepository.FindAll")
+	defer span.End()
+	result, err := r.repo.FindAll(ctx)
+	return result, spanError(span, err)
+}
+
+func (r *tracedServiceAccountRepo) Delete(ctx context.Context, id string) error {
+	ctx, span := startSpan(ctx, "ServiceAccountRepository.Delete")
+	defer span.End()
+	return spanError(span, r.repo.Delete(ctx, id))
+}
+
+type tracedAPIKeyRepo struct {
+	repo repository.APIKeyRepository
+}
+
+// traceAPIKeyRepo wraps a repository so that every call to it is traced.
+func traceAPIKeyRepo(repo repository.APIKeyRepository) repository.APIKeyRepository {
+	return &tracedAPIKeyRepo{repo: repo}
+}
+
+func (r *tracedAPIKeyRepo) Save(ctx context.Context, key models.APIKey) error {
+	ctx, span := startSpan(ctx, "APIKeyRepository.Save")
+	defer span.End()
+	return spanError(span, r.repo.Save(ctx, key))
+}
+
+func (r *tracedAPIKeyRepo) Find(ctx context.Context, id string) (models.APIKey, error) {
+	ctx, span := startSpan(ctx, "APIKeyRepository.Find")
+	defer span.End()
+	result, err := r.repo.Find(ctx, id)
+	return result, spanError(span, err)
+}
+
+func (r *tracedAPIKeyRepo) FindByPrefix(ctx context.Context, prefix string) (models.APIKey, error) {
+	ctx, span := startSpan(ctx, "APIKeyRepository.FindByPrefix")
+	defer span.End()
+	result, err := r.repo.FindByPrefix(ctx, prefix)
+	return result, spanError(span, err)
+}
+
+func (r *tracedAPIKeyRepo) FindByUserID(ctx context.Context, userID string) ([]models.APIKey, error) {
+	ctx, span := startSpan(ctx, "APIKeyRepository.FindByUserID")
+	defer span.End()
+	result, err := r.repo.FindByUserID(ctx, userID)
+	return result, spanError(span, err)
+}
+
+func (r *tracedAPIKeyRepo) UpdateLastUsed(ctx context.Context, id string, lastUsedAt time.Time) error {
+	ctx, span := startSpan(ctx, "APIKeyRepository.UpdateLastUsed")
+	defer span.End()
+	return spanError(span, r.repo.UpdateLastUsed(ctx, id, lastUsedAt))
+}
+
+func (r *tracedAPIKeyRepo) Revoke(ctx context.Context, id string, revokedAt time.Time) error {
+	ctx, span := startSpan(ctx, "APIKeyRepository.Revoke")
+	defer span.End()
+	return spanError(span, r.repo.Revoke(ctx, id, revokedAt))
+}
+
+func (r *tracedAPIKeyRepo) RevokeByUserID(ctx context.Context, userID string, revokedAt time.Time) error {
+	ctx, span := startSpan(ctx, "APIKeyRepository.RevokeByUserID")
+	defer span.End()
+	return spanError(span, r.repo.RevokeByUserID(ctx, userID, revokedAt))
+}
+
+func (r *tracedAPIKeyRepo) DeleteByUserID(ctx context.Context, userID string) error {
+	ctx, span := startSpan(ctx, "APIKeyRepository.DeleteByUserID")
+	defer span.End()
+	return spanError(span, r.repo.DeleteByUserID(ctx, userID))
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/CzarSimon/user-service/pkg/models"
+	"github.com/CzarSimon/user-service/pkg/repository"
+	"github.com/CzarSimon/user-service/pkg/repository/repotest"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracing(t *testing.T) {
+	assert := assert.New(t)
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+
+	user := testUser()
+	userRepo := &repotest.MockUserRepo{FindByEmailUser: user}
+	svc := NewUserService(hasher, issuer, userRepo, roleRepo)
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "parent")
+	_, err := svc.Login(ctx, models.LoginRequest{Email: user.Email, Password: "secret-drowssap"})
+	assert.NoError(err)
+	parent.End()
+
+	spans := exporter.GetSpans()
+	names := make([]string, 0, len(spans))
+	for _, span := range spans {
+		names = append(names, span.Name)
+		assert.Equal(parent.SpanContext().TraceID(), span.SpanContext.TraceID())
+		if span.Name != "parent" {
+			assert.Equal(parent.SpanContext().SpanID(), span.Parent.SpanID(), span.Name)
+		}
+	}
+	assert.Equal([]string{
+		"UserRepository.FindByEmail",
+		"Hasher.Verify",
+		"RoleRepository.FindByNames",
+		"Issuer.Issue",
+		"parent",
+	}, names)
+	assert.Contains(spans[1].Attributes, attribute.String("hash.algorithm", "scrypt"))
+	assert.Contains(spans[1].Attributes, attribute.Bool("hash.match", true))
+
+	exporter.Reset()
+	userRepo.FindByEmailErr = errors.New("db failure")
+	_, err = svc.Login(context.Background(), models.LoginRequest{Email: user.Email, Password: "secret-drowssap"})
+	assert.Error(err)
+	spans = exporter.GetSpans()
+	assert.Len(spans, 1)
+	assert.Equal(codes.Error, spans[0].Status.Code)
+
+	exporter.Reset()
+	userRepo.FindByEmailErr = repository.ErrNoSuchUser
+	_, err = svc.Login(context.Background(), models.LoginRequest{Email: user.Email, Password: "secret-drowssap"})
+	assert.Error(err)
+	spans = exporter.GetSpans()
+	assert.Len(spans, 1)
+	assert.Equal(codes.Unset, spans[0].Status.Code)
+	assert.Empty(spans[0].Events)
+}
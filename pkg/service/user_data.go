@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/CzarSimon/user-service/pkg/repository"
+)
+
+// userData repositories storing the credentials and personal data of users, apart from the users themselves.
+// Repositories which have not been configured are skipped.
+type userData struct {
+	loginRepo   repository.LoginHistoryRepository
+	sessionRepo repository.SessionRepository
+	keyRepo     repository.APIKeyRepository
+	changeRepo  repository.EmailChangeRepository
+	tokenRepo   repository.RefreshTokenRepository
+}
+
+// revokeCredentials revokes the sessions, api keys and refresh tokens of a user,
+// so that no credential issued to the user is accepted any longer.
+func (d userData) revokeCredentials(ctx context.Context, userID string) error {
+	err := d.revokeSessions(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	if d.keyRepo != nil {
+		err = d.keyRepo.RevokeByUserID(ctx, userID, now)
+		if err != nil {
+			return err
+		}
+	}
+
+	if d.tokenRepo != nil {
+		err = d.tokenRepo.RevokeByUserID(ctx, userID, now)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// revokeSessions revokes the sessions of a user, so that access tokens issued to the user are rejected.
+func (d userData) revokeSessions(ctx context.Context, userID string) error {
+	if d.sessionRepo == nil {
+		return nil
+	}
+	return d.sessionRepo.RevokeByUserID(ctx, userID, time.Now().UTC())
+}
+
+// purge deletes the credentials and personal data of a user. The user itself is left for the caller to delete.
+func (d userData) purge(ctx context.Context, userID string) error {
+	if d.loginRepo != nil {
+		err := d.loginRepo.DeleteByUserID(ctx, userID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if d.sessionRepo != nil {
+		err := d.sessionRepo.DeleteByUserID(ctx, userID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if d.keyRepo != nil {
+		err := d.keyRepo.DeleteByUserID(ctx, userID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if d.changeRepo != nil {
+		err := d.changeRepo.DeleteByUserID(ctx, userID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if d.tokenRepo != nil {
+		err := d.tokenRepo.DeleteByUserID(ctx, userID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -1,8 +1,9 @@
 package service
 
 import (
-	"log"
+	"context"
 	"net/http"
+	"time"
 
 	"github.com/CzarSimon/user-service/pkg/auth"
 	"github.com/CzarSimon/user-service/pkg/httputil"
@@ -12,55 +13,175 @@ import (
 	"go.uber.org/zap"
 )
 
-var logger *zap.SugaredLogger
+// Error codes returned by the service, which clients can switch on.
+const (
+	CodeVersionConflict    = "VERSION_CONFLICT"
+	CodeUserExists         = "USER_EXISTS"
+	CodeUserNotFound       = "USER_NOT_FOUND"
+	CodeInvalidCredentials = "INVALID_CREDENTIALS"
+	CodeAccountDisabled    = "ACCOUNT_DISABLED"
+	CodeAccountDeleted     = "ACCOUNT_DELETED"
+	CodeEmailRequired      = "EMAIL_REQUIRED"
+	CodeInvalidEmail       = "INVALID_EMAIL"
+	CodeEmailUnchanged     = "EMAIL_UNCHANGED"
+	CodeInvalidEmailToken  = "INVALID_EMAIL_TOKEN"
+	CodePasswordTooShort   = "PASSWORD_TOO_SHORT"
+	CodePasswordMismatch   = "PASSWORD_MISMATCH"
+	CodeInvalidName        = "INVALID_NAME"
+	CodeVersionRequired    = "VERSION_REQUIRED"
+	CodeRoleRequired       = "ROLE_REQUIRED"
+	CodeUnknownRole        = "UNKNOWN_ROLE"
+)
 
-func init() {
-	l, err := zap.NewProduction()
-	if err != nil {
-		log.Fatalln("Failed to get zap.Logger", err)
+// UserService service responsible for business logic related to users.
+type UserService interface {
+	SignUp(ctx context.Context, req models.SignupRequest) (models.LoginResponse, error)
+	Login(ctx context.Context, req models.LoginRequest) (models.LoginResponse, error)
+	// Logout revokes the session of the principal so that its token is no longer accepted.
+	Logout(ctx context.Context, principal auth.Token) error
+	Find(ctx context.Context, principal auth.Token, id string) (models.User, error)
+	ChangePassword(ctx context.Context, principal auth.Token, req models.ChangePasswordRequest) (models.LoginResponse, error)
+	UpdateProfile(ctx context.Context, principal auth.Token, req models.UpdateProfileRequest) (models.User, error)
+	ListUsers(ctx context.Context, principal auth.Token, query models.UserQuery) (models.UserPage, error)
+	ChangeRole(ctx context.Context, principal auth.Token, req models.ChangeRoleRequest) (models.User, error)
+	DisableUser(ctx context.Context, principal auth.Token, id string) error
+	EnableUser(ctx context.Context, principal auth.Token, id string) error
+	DeleteUser(ctx context.Context, principal auth.Token, id string) error
+}
+
+// UserServiceOption configures optional dependencies of a UserService.
+type UserServiceOption func(*userSvc)
+
+// WithLoginHistory records login attempts of existing users in a LoginHistoryRepository.
+func WithLoginHistory(loginRepo repository.LoginHistoryRepository) UserServiceOption {
+	return func(svc *userSvc) {
+		svc.loginRepo = traceLoginHistoryRepo(loginRepo)
 	}
+}
 
-	logger = l.Sugar().With("application", "user-service", "package", "pkg/service")
+// WithSessions records a session in a SessionRepository for every issued token.
+// Should be combined with a verifier created by NewSessionVerifier so that revoked sessions are rejected.
+func WithSessions(sessionRepo repository.SessionRepository) UserServiceOption {
+	return func(svc *userSvc) {
+		svc.sessionRepo = traceSessionRepo(sessionRepo)
+	}
 }
 
-// UserService service responsible for business logic related to users.
-type UserService interface {
-	SignUp(req models.SignupRequest) (models.LoginResponse, error)
-	Login(req models.LoginRequest) (models.LoginResponse, error)
-	Find(id string) (models.User, error)
-	ChangePassword(req models.ChangePasswordRequest) (models.LoginResponse, error)
+// WithAPIKeys revokes the api keys of users who are disabled or deleted by an admin.
+func WithAPIKeys(keyRepo repository.APIKeyRepository) UserServiceOption {
+	return func(svc *userSvc) {
+		svc.keyRepo = traceAPIKeyRepo(keyRepo)
+	}
+}
+
+// WithEmailChanges deletes the email changes of users who are deleted by an admin.
+func WithEmailChanges(changeRepo repository.EmailChangeRepository) UserServiceOption {
+	return func(svc *userSvc) {
+		svc.changeRepo = traceEmailChangeRepo(changeRepo)
+	}
+}
+
+// WithRefreshTokens revokes the refresh tokens issued on behalf of users who are disabled or deleted by an admin.
+func WithRefreshTokens(tokenRepo repository.RefreshTokenRepository) UserServiceOption {
+	return func(svc *userSvc) {
+		svc.tokenRepo = traceRefreshTokenRepo(tokenRepo)
+	}
+}
+
+// WithEmailNormalizer sets how email addresses are normalized before they are stored
+// or looked up. Defaults to models.DefaultEmailNormalizer.
+func WithEmailNormalizer(normalizer models.EmailNormalizer) UserServiceOption {
+	return func(svc *userSvc) {
+		svc.emailNormalizer = normalizer
+	}
+}
+
+// WithLogger sets the logger used when the user service is called outside of a request.
+func WithLogger(log *zap.Logger) UserServiceOption {
+	return func(svc *userSvc) {
+		svc.log = log
+	}
+}
+
+// NewUserService creates a new UserService.
+func NewUserService(hasher auth.Hasher, issuer auth.Issuer, userRepo repository.UserRepository, roleRepo repository.RoleRepository, opts ...UserServiceOption) UserService {
+	svc := &userSvc{
+		hasher:          hasher,
+		issuer:          issuer,
+		userRepo:        traceUserRepo(userRepo),
+		roleRepo:        traceRoleRepo(roleRepo),
+		emailNormalizer: models.DefaultEmailNormalizer,
+		passwordChecker: &defaultChecker{minLength: 8},
+		saltLength:      25,
+	}
+
+	for _, opt := range opts {
+		opt(svc)
+	}
+	return svc
 }
 
 type userSvc struct {
+	logging
 	hasher          auth.Hasher
 	issuer          auth.Issuer
 	userRepo        repository.UserRepository
+	roleRepo        repository.RoleRepository
+	loginRepo       repository.LoginHistoryRepository
+	emailNormalizer models.EmailNormalizer
+	metrics         *Metrics
+	auditSink       AuditSink
+	sessionRepo     repository.SessionRepository
+	keyRepo         repository.APIKeyRepository
+	changeRepo      repository.EmailChangeRepository
+	tokenRepo       repository.RefreshTokenRepository
 	passwordChecker passwordChecker
 	saltLength      int
 }
 
-func (svc *userSvc) SignUp(req models.SignupRequest) (models.LoginResponse, error) {
-	_, err := svc.userRepo.FindByEmail(req.Email)
+func (svc *userSvc) SignUp(ctx context.Context, req models.SignupRequest) (models.LoginResponse, error) {
+	ctx = svc.withLogger(ctx)
+	res, err := svc.signUp(ctx, req)
+	svc.metrics.recordSignup(err)
+	audit(ctx, svc.auditSink, models.AuditSignup, res.User.ID, res.User.ID, err)
+	return res, err
+}
+
+func (svc *userSvc) signUp(ctx context.Context, req models.SignupRequest) (models.LoginResponse, error) {
+	err := validate(req)
+	if err != nil {
+		return models.LoginResponse{}, err
+	}
+
+	email, err := normalizeEmail(svc.emailNormalizer, req.Email)
+	if err != nil {
+		return models.LoginResponse{}, err
+	}
+	req.Email = email
+
+	_, err = svc.userRepo.FindByEmail(ctx, req.Email)
 	if err != repository.ErrNoSuchUser {
 		return models.LoginResponse{}, errUserAlreadyExists()
 	}
 
-	credentials, err := svc.createCredentials(id.New(), req.Password, req.RepeatPassword)
+	credentials, err := svc.createCredentials(ctx, id.New(), req.Password, req.RepeatPassword)
 	if err != nil {
 		return models.LoginResponse{}, err
 	}
 
 	user := req.User(credentials)
-	err = svc.userRepo.Save(user)
-	if err != nil {
-		logger.Errorw("Failed to save user", "err", err)
+	err = svc.userRepo.Save(ctx, user)
+	if err == repository.ErrUserExists {
+		return models.LoginResponse{}, errUserAlreadyExists()
+	} else if err != nil {
+		logger(ctx).Errorw("Failed to save user", "err", err)
 		return models.LoginResponse{}, httputil.NewInternalServerError("Failed to save user")
 	}
 
-	return svc.createLoginResponse(user)
+	return svc.createLoginResponse(ctx, user)
 }
 
-func (svc *userSvc) createCredentials(userID, password, repeatPassword string) (models.Credentials, error) {
+func (svc *userSvc) createCredentials(ctx context.Context, userID, password, repeatPassword string) (models.Credentials, error) {
 	err := svc.passwordChecker.check(password, repeatPassword)
 	if err != nil {
 		return models.Credentials{}, err
@@ -68,13 +189,13 @@ func (svc *userSvc) createCredentials(userID, password, repeatPassword string) (
 
 	salt, err := auth.GenSalt(svc.saltLength)
 	if err != nil {
-		logger.Errorw("Failed generate salt", "err", err)
+		logger(ctx).Errorw("Failed generate salt", "err", err)
 		return models.Credentials{}, httputil.NewInternalServerError("Failed to generate salt")
 	}
 
-	hash, err := svc.hasher.Hash(password, salt)
+	hash, err := hashPassword(ctx, svc.hasher, password, salt)
 	if err != nil {
-		logger.Errorw("Failed generate hash", "err", err)
+		logger(ctx).Errorw("Failed generate hash", "err", err)
 		return models.Credentials{}, httputil.NewInternalServerError("Failed to hash password")
 	}
 
@@ -85,82 +206,333 @@ func (svc *userSvc) createCredentials(userID, password, repeatPassword string) (
 	}, nil
 }
 
-func (svc *userSvc) Login(req models.LoginRequest) (models.LoginResponse, error) {
-	user, err := svc.userRepo.FindByEmail(req.Email)
+func (svc *userSvc) Login(ctx context.Context, req models.LoginRequest) (models.LoginResponse, error) {
+	ctx = svc.withLogger(ctx)
+	res, userID, err := svc.login(ctx, req)
+	svc.metrics.recordLogin(err)
+	audit(ctx, svc.auditSink, models.AuditLogin, userID, userID, err)
+	return res, err
+}
+
+// login logs in a user, returning the id of the user attempting to log in if they exist.
+func (svc *userSvc) login(ctx context.Context, req models.LoginRequest) (models.LoginResponse, string, error) {
+	err := validate(req)
+	if err != nil {
+		return models.LoginResponse{}, "", err
+	}
+
+	email, err := normalizeEmail(svc.emailNormalizer, req.Email)
+	if err != nil {
+		return models.LoginResponse{}, "", err
+	}
+
+	user, err := svc.userRepo.FindByEmail(ctx, email)
 	if err == repository.ErrNoSuchUser {
-		return models.LoginResponse{}, errNoSuchUser()
+		return models.LoginResponse{}, "", errNoSuchUser()
 	} else if err != nil {
-		logger.Errorw("Failed find user by email", "err", err)
-		return models.LoginResponse{}, httputil.NewInternalServerError("Failed to get user")
+		logger(ctx).Errorw("Failed find user by email", "err", err)
+		return models.LoginResponse{}, "", httputil.NewInternalServerError("Failed to get user")
 	}
 
-	err = svc.hasher.Verify(req.Password, user.Credentials.Salt, user.Credentials.PasswordHash)
+	err = verifyPassword(ctx, svc.hasher, req.Password, user.Credentials.Salt, user.Credentials.PasswordHash)
+	svc.recordLogin(ctx, user.ID, err == nil, req.Client)
 	if err != nil {
-		return models.LoginResponse{}, errInvalidCredentials()
+		return models.LoginResponse{}, user.ID, errInvalidCredentials()
+	}
+
+	if user.Disabled {
+		return models.LoginResponse{}, user.ID, errAccountDisabled()
+	}
+
+	if user.IsDeleted() {
+		return models.LoginResponse{}, user.ID, errAccountDeleted()
+	}
+
+	res, err := svc.createLoginResponse(ctx, user)
+	return res, user.ID, err
+}
+
+func (svc *userSvc) Logout(ctx context.Context, principal auth.Token) error {
+	ctx = svc.withLogger(ctx)
+	if svc.sessionRepo == nil {
+		return nil
+	}
+
+	err := svc.sessionRepo.Revoke(ctx, principal.ID, time.Now().UTC())
+	if err == repository.ErrNoSuchSession {
+		return nil
+	} else if err != nil {
+		logger(ctx).Errorw("Failed to revoke session", "sessionId", principal.ID, "err", err)
+		err = httputil.NewInternalServerError("Failed to revoke session")
 	}
 
-	return svc.createLoginResponse(user)
+	audit(ctx, svc.auditSink, models.AuditTokenRevocation, principal.Subject, principal.Subject, err)
+	return err
 }
 
-func (svc *userSvc) Find(id string) (models.User, error) {
-	user, err := svc.userRepo.Find(id)
+// recordLogin records a login attempt if a LoginHistoryRepository has been configured.
+func (svc *userSvc) recordLogin(ctx context.Context, userID string, success bool, client models.ClientInfo) {
+	if svc.loginRepo == nil {
+		return
+	}
+
+	err := svc.loginRepo.Save(ctx, models.NewLoginEvent(userID, success, client))
+	if err != nil {
+		logger(ctx).Errorw("Failed to record login", "userId", userID, "err", err)
+	}
+}
+
+// createSession records a session for an issued token if a SessionRepository has been configured.
+func createSession(ctx context.Context, sessionRepo repository.SessionRepository, userID, token string) error {
+	if sessionRepo == nil {
+		return nil
+	}
+
+	tokenID, err := auth.TokenID(token)
+	if err != nil {
+		logger(ctx).Errorw("Failed to read token id", "userId", userID, "err", err)
+		return httputil.NewInternalServerError("Failed to create session")
+	}
+
+	md := httputil.GetRequestMetadata(ctx)
+	session := models.NewSession(tokenID, userID, models.ClientInfo{IP: md.IP, UserAgent: md.UserAgent})
+	err = sessionRepo.Save(ctx, session)
+	if err != nil {
+		logger(ctx).Errorw("Failed to save session", "userId", userID, "err", err)
+		return httputil.NewInternalServerError("Failed to create session")
+	}
+
+	return nil
+}
+
+func (svc *userSvc) Find(ctx context.Context, principal auth.Token, id string) (models.User, error) {
+	ctx = svc.withLogger(ctx)
+	err := assertUserAccess(principal, id, models.ReadUsersPermission)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	return svc.findUser(ctx, id)
+}
+
+func (svc *userSvc) findUser(ctx context.Context, id string) (models.User, error) {
+	return findUser(ctx, svc.userRepo, id)
+}
+
+func findUser(ctx context.Context, userRepo repository.UserRepository, id string) (models.User, error) {
+	user, err := userRepo.Find(ctx, id)
 	if err == repository.ErrNoSuchUser {
-		return models.User{}, httputil.NewError("No such user", http.StatusNotFound)
+		return models.User{}, errUserNotFound()
 	} else if err != nil {
-		logger.Errorw("Failed find user by id", "userId", id, "err", err)
+		logger(ctx).Errorw("Failed find user by id", "userId", id, "err", err)
 		return models.User{}, httputil.NewError("Failed to find user", http.StatusInternalServerError)
 	}
 
 	return user, nil
 }
 
-func (svc *userSvc) ChangePassword(req models.ChangePasswordRequest) (models.LoginResponse, error) {
-	user, err := svc.Find(req.UserID)
+func (svc *userSvc) ChangePassword(ctx context.Context, principal auth.Token, req models.ChangePasswordRequest) (models.LoginResponse, error) {
+	ctx = svc.withLogger(ctx)
+	res, err := svc.changePassword(ctx, principal, req)
+	svc.metrics.recordPasswordChange(err)
+	audit(ctx, svc.auditSink, models.AuditPasswordChange, principal.Subject, req.UserID, err)
+	return res, err
+}
+
+func (svc *userSvc) changePassword(ctx context.Context, principal auth.Token, req models.ChangePasswordRequest) (models.LoginResponse, error) {
+	err := assertUserAccess(principal, req.UserID, models.WriteUsersPermission)
+	if err != nil {
+		return models.LoginResponse{}, err
+	}
+
+	err = validate(req)
+	if err != nil {
+		return models.LoginResponse{}, err
+	}
+
+	user, err := svc.findUser(ctx, req.UserID)
 	if err != nil {
 		return models.LoginResponse{}, err
 	}
 
-	err = svc.hasher.Verify(req.OldPassword, user.Credentials.Salt, user.Credentials.PasswordHash)
+	err = verifyPassword(ctx, svc.hasher, req.OldPassword, user.Credentials.Salt, user.Credentials.PasswordHash)
 	if err != nil {
 		return models.LoginResponse{}, errInvalidCredentials()
 	}
 
-	credentials, err := svc.createCredentials(user.ID, req.NewPassword, req.RepeatPassword)
+	credentials, err := svc.createCredentials(ctx, user.ID, req.NewPassword, req.RepeatPassword)
 	if err != nil {
 		return models.LoginResponse{}, err
 	}
 
 	user.Credentials = credentials
-	err = svc.userRepo.UpdateCredentials(credentials)
+	err = svc.userRepo.UpdateCredentials(ctx, credentials)
 	if err != nil {
-		logger.Errorw("Failed to update credentials", "userID", user.ID, "err", err)
+		logger(ctx).Errorw("Failed to update credentials", "userID", user.ID, "err", err)
 		return models.LoginResponse{}, httputil.NewInternalServerError("Failed to update password")
 	}
 
-	return svc.createLoginResponse(user)
+	return svc.createLoginResponse(ctx, user)
 }
 
-func (svc *userSvc) createLoginResponse(user models.User) (models.LoginResponse, error) {
-	token, err := svc.issuer.Issue(user.ID, user.Role)
+func (svc *userSvc) UpdateProfile(ctx context.Context, principal auth.Token, req models.UpdateProfileRequest) (models.User, error) {
+	ctx = svc.withLogger(ctx)
+	err := assertUserAccess(principal, req.UserID, models.WriteUsersPermission)
 	if err != nil {
-		logger.Errorw("Failed issue token", "err", err)
+		return models.User{}, err
+	}
+
+	err = checkProfileUpdate(req)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	user, err := svc.findUser(ctx, req.UserID)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	if user.Version != req.Version {
+		return models.User{}, errVersionConflict()
+	}
+
+	updated := req.Apply(user)
+	err = svc.userRepo.UpdateProfile(ctx, updated, user.Version)
+	if err == repository.ErrVersionConflict {
+		return models.User{}, errVersionConflict()
+	} else if err != nil {
+		logger(ctx).Errorw("Failed to update profile", "userId", user.ID, "err", err)
+		return models.User{}, httputil.NewInternalServerError("Failed to update profile")
+	}
+
+	return updated, nil
+}
+
+func (svc *userSvc) createLoginResponse(ctx context.Context, user models.User) (models.LoginResponse, error) {
+	permissions, err := svc.findPermissions(ctx, user)
+	if err != nil {
+		return models.LoginResponse{}, err
+	}
+
+	token, err := issueToken(ctx, svc.issuer, user.ID, user.Role, permissions...)
+	if err != nil {
+		logger(ctx).Errorw("Failed issue token", "err", err)
 		return models.LoginResponse{}, httputil.NewInternalServerError("Failed to generate token")
 	}
 
+	err = createSession(ctx, svc.sessionRepo, user.ID, token)
+	if err != nil {
+		return models.LoginResponse{}, err
+	}
+
 	return models.LoginResponse{
 		Token: token,
 		User:  user,
 	}, nil
 }
 
+func (svc *userSvc) findPermissions(ctx context.Context, user models.User) ([]string, error) {
+	return findPermissions(ctx, svc.roleRepo, user)
+}
+
+// findPermissions returns every permission granted to a user by its roles.
+func findPermissions(ctx context.Context, roleRepo repository.RoleRepository, user models.User) ([]string, error) {
+	roles, err := roleRepo.FindByNames(ctx, userRoles(user))
+	if err != nil {
+		logger(ctx).Errorw("Failed to find roles", "userId", user.ID, "err", err)
+		return nil, httputil.NewInternalServerError("Failed to find permissions")
+	}
+
+	return models.Permissions(roles), nil
+}
+
+// userRoles returns every role held by a user, including its primary role.
+func userRoles(user models.User) []string {
+	for _, role := range user.Roles {
+		if role == user.Role {
+			return user.Roles
+		}
+	}
+	return append([]string{user.Role}, user.Roles...)
+}
+
+// normalizeEmail normalizes an email address and maps syntax errors to bad requests.
+func normalizeEmail(normalizer models.EmailNormalizer, email string) (string, error) {
+	normalized, err := normalizer.Normalize(email)
+	if err == models.ErrEmptyEmail {
+		return "", httputil.NewError("Email is required", http.StatusBadRequest).
+			WithCode(CodeEmailRequired).
+			WithMessageKey("email.required")
+	} else if err != nil {
+		return "", httputil.NewError("Invalid email address", http.StatusBadRequest).
+			WithCode(CodeInvalidEmail).
+			WithMessageKey("email.invalid")
+	}
+
+	return normalized, nil
+}
+
+// assertUserAccess checks that the principal is allowed to access a user,
+// which is only the case for the user themselves or if granted the required permission.
+// OAuth clients acting on behalf of the user must also have been granted the permission as a scope.
+func assertUserAccess(principal auth.Token, userID, permission string) error {
+	if principal.Subject != "" && principal.Subject == userID && (!principal.IsDelegated() || principal.HasScope(permission)) {
+		return nil
+	}
+
+	if principal.HasPermission(permission) {
+		return nil
+	}
+
+	return httputil.ErrForbidden()
+}
+
+// assertPermission checks that the principal has been granted a permission.
+func assertPermission(principal auth.Token, permission string) error {
+	if !principal.HasPermission(permission) {
+		return httputil.ErrForbidden()
+	}
+	return nil
+}
+
+func errVersionConflict() error {
+	return httputil.NewError("User has been modified", http.StatusConflict).
+		WithCode(CodeVersionConflict).
+		WithMessageKey("user.modified")
+}
+
 func errUserAlreadyExists() error {
-	return httputil.NewError("User already exists", http.StatusConflict)
+	return httputil.NewError("User already exists", http.StatusConflict).
+		WithCode(CodeUserExists).
+		WithMessageKey("user.exists")
+}
+
+func errUserNotFound() error {
+	return httputil.NewError("No such user", http.StatusNotFound).
+		WithCode(CodeUserNotFound).
+		WithMessageKey("user.notFound")
 }
 
 func errNoSuchUser() error {
-	return httputil.NewError("No such user", http.StatusUnauthorized)
+	return httputil.NewError("No such user", http.StatusUnauthorized).
+		WithCode(CodeUserNotFound).
+		WithMessageKey("user.notFound")
 }
 
 func errInvalidCredentials() error {
-	return httputil.NewError("Email and password does not match", http.StatusUnauthorized)
+	return httputil.NewError("Email and password does not match", http.StatusUnauthorized).
+		WithCode(CodeInvalidCredentials).
+		WithMessageKey("credentials.invalid")
+}
+
+func errAccountDisabled() error {
+	return httputil.NewError("Account is disabled", http.StatusForbidden).
+		WithCode(CodeAccountDisabled).
+		WithMessageKey("account.disabled")
+}
+
+func errAccountDeleted() error {
+	return httputil.NewError("Account is scheduled for deletion", http.StatusForbidden).
+		WithCode(CodeAccountDeleted).
+		WithMessageKey("account.deleted")
 }
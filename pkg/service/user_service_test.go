@@ -1,15 +1,22 @@
 package service
 
 import (
+	"context"
+	"errors"
+	"net/http"
 	"testing"
 	"time"
 
 	"github.com/CzarSimon/user-service/pkg/auth"
+	"github.com/CzarSimon/user-service/pkg/httputil"
 	"github.com/CzarSimon/user-service/pkg/id"
 	"github.com/CzarSimon/user-service/pkg/models"
 	"github.com/CzarSimon/user-service/pkg/repository"
 	"github.com/CzarSimon/user-service/pkg/repository/repotest"
 	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 var hasher = auth.NewHasher("secret-pepper")
@@ -19,6 +26,10 @@ var issuer = auth.NewJWTIssuer(auth.JWTCredentials{
 	Secret: "jwt-secret",
 })
 
+var roleRepo = &repotest.MockRoleRepo{
+	FindByNamesRoles: models.DefaultRoles(),
+}
+
 var verifier = auth.NewJWTVerifier(auth.JWTCredentials{
 	Issuer: "user-service-name",
 	Secret: "jwt-secret",
@@ -138,12 +149,13 @@ func Test_userSvc_SignUp(t *testing.T) {
 				hasher:          hasher,
 				issuer:          issuer,
 				userRepo:        tt.fields.userRepo,
+				roleRepo:        roleRepo,
 				passwordChecker: &defaultChecker{minLength: 8},
 				saltLength:      25,
 			}
-			got, err := svc.SignUp(tt.args.req)
+			got, err := svc.SignUp(context.Background(), tt.args.req)
 			if (err != nil) != tt.wantErr {
-				t.Errorf("userSvc.SignUp() error = %v, wantErr %v", err, tt.wantErr)
+				t.Errorf("userSvc.SignUp(context.Background()) error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
 
@@ -171,6 +183,78 @@ func Test_userSvc_SignUp(t *testing.T) {
 	}
 }
 
+func Test_userSvc_SignUp_EmailTakenOnSave(t *testing.T) {
+	userRepo := &repotest.MockUserRepo{
+		FindByEmailErr: repository.ErrNoSuchUser,
+		SaveErr:        repository.ErrUserExists,
+	}
+	svc := NewUserService(hasher, issuer, userRepo, roleRepo)
+	req := models.SignupRequest{
+		Email:             "mail@mail.com",
+		Password:          "secret-drowssap",
+		RepeatPassword:    "secret-drowssap",
+		Surname:           "Tester",
+		MiddleAndLastName: "McTest",
+	}
+
+	_, err := svc.SignUp(context.Background(), req)
+	assertStatus(t, http.StatusConflict, err)
+	assertCode(t, CodeUserExists, err)
+	assert.Equal(t, 1, userRepo.SaveInvocations)
+}
+
+func Test_userSvc_NormalizesEmail(t *testing.T) {
+	assert := assert.New(t)
+	userRepo := &repotest.MockUserRepo{FindByEmailErr: repository.ErrNoSuchUser}
+	svc := NewUserService(hasher, issuer, userRepo, roleRepo)
+
+	res, err := svc.SignUp(context.Background(), models.SignupRequest{
+		Email:             " Mail@Mail.COM ",
+		Password:          "secret-drowssap",
+		RepeatPassword:    "secret-drowssap",
+		Surname:           "Tester",
+		MiddleAndLastName: "McTest",
+	})
+	assert.NoError(err)
+	assert.Equal("mail@mail.com", userRepo.FindByEmailArg)
+	assert.Equal("mail@mail.com", userRepo.SaveArg.Email)
+	assert.Equal("mail@mail.com", res.User.Email)
+
+	userRepo = &repotest.MockUserRepo{FindByEmailUser: testUser()}
+	svc = NewUserService(hasher, issuer, userRepo, roleRepo)
+	_, err = svc.Login(context.Background(), models.LoginRequest{Email: "MAIL@mail.com", Password: "secret-drowssap"})
+	assert.NoError(err)
+	assert.Equal("mail@mail.com", userRepo.FindByEmailArg)
+
+	userRepo.UnsetArgs()
+	_, err = svc.Login(context.Background(), models.LoginRequest{Email: "not-an-email", Password: "secret-drowssap"})
+	assertStatus(t, http.StatusBadRequest, err)
+	assertCode(t, CodeInvalidEmail, err)
+	assert.Equal(0, userRepo.FindByEmailInvocations)
+
+	userRepo = &repotest.MockUserRepo{FindByEmailErr: repository.ErrNoSuchUser}
+	svc = NewUserService(hasher, issuer, userRepo, roleRepo, WithEmailNormalizer(models.EmailNormalizer{}))
+	_, err = svc.SignUp(context.Background(), models.SignupRequest{
+		Email:             "Mail@Mail.COM",
+		Password:          "secret-drowssap",
+		RepeatPassword:    "secret-drowssap",
+		Surname:           "Tester",
+		MiddleAndLastName: "McTest",
+	})
+	assert.NoError(err)
+	assert.Equal("Mail@mail.com", userRepo.SaveArg.Email)
+
+	_, err = svc.SignUp(context.Background(), models.SignupRequest{
+		Email:             "not-an-email",
+		Password:          "secret-drowssap",
+		RepeatPassword:    "secret-drowssap",
+		Surname:           "Tester",
+		MiddleAndLastName: "McTest",
+	})
+	assertStatus(t, http.StatusBadRequest, err)
+	assert.Equal(1, userRepo.SaveInvocations)
+}
+
 func Test_userSvc_Login(t *testing.T) {
 	user := models.User{
 		ID:                id.New(),
@@ -185,6 +269,12 @@ func Test_userSvc_Login(t *testing.T) {
 		},
 	}
 
+	disabledUser := user
+	disabledUser.Disabled = true
+	deletedUser := user
+	deletedAt := time.Now().UTC()
+	deletedUser.DeletedAt = &deletedAt
+
 	type fields struct {
 		userRepo *repotest.MockUserRepo
 	}
@@ -230,6 +320,38 @@ func Test_userSvc_Login(t *testing.T) {
 			want:    models.User{},
 			wantErr: true,
 		},
+		{
+			name: "sad-path-disabled-user",
+			fields: fields{
+				userRepo: &repotest.MockUserRepo{
+					FindByEmailUser: disabledUser,
+				},
+			},
+			args: args{
+				req: models.LoginRequest{
+					Email:    "mail@mail.com",
+					Password: "secret-drowssap",
+				},
+			},
+			want:    models.User{},
+			wantErr: true,
+		},
+		{
+			name: "sad-path-deleted-user",
+			fields: fields{
+				userRepo: &repotest.MockUserRepo{
+					FindByEmailUser: deletedUser,
+				},
+			},
+			args: args{
+				req: models.LoginRequest{
+					Email:    "mail@mail.com",
+					Password: "secret-drowssap",
+				},
+			},
+			want:    models.User{},
+			wantErr: true,
+		},
 		{
 			name: "sad-path-no-such-user",
 			fields: fields{
@@ -253,12 +375,13 @@ func Test_userSvc_Login(t *testing.T) {
 				hasher:          hasher,
 				issuer:          issuer,
 				userRepo:        tt.fields.userRepo,
+				roleRepo:        roleRepo,
 				passwordChecker: &defaultChecker{minLength: 8},
 				saltLength:      25,
 			}
-			got, err := svc.Login(tt.args.req)
+			got, err := svc.Login(context.Background(), tt.args.req)
 			if (err != nil) != tt.wantErr {
-				t.Errorf("userSvc.Login() error = %v, wantErr %v", err, tt.wantErr)
+				t.Errorf("userSvc.Login(context.Background()) error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
 
@@ -279,7 +402,10 @@ func Test_userSvc_Login(t *testing.T) {
 			assert.NoError(t, err)
 			assert.Equal(t, got.User.ID, token.Subject)
 			assert.Equal(t, tt.want.Role, token.Role)
+			assert.Equal(t, models.Permissions(roleRepo.FindByNamesRoles), token.Permissions)
+			assert.Equal(t, []string{models.AdminRole}, roleRepo.FindByNamesArg)
 			tt.fields.userRepo.UnsetArgs()
+			roleRepo.UnsetArgs()
 		})
 	}
 }
@@ -304,7 +430,8 @@ func Test_userSvc_ChangePassword(t *testing.T) {
 		userRepo *repotest.MockUserRepo
 	}
 	type args struct {
-		req models.ChangePasswordRequest
+		principal auth.Token
+		req       models.ChangePasswordRequest
 	}
 	tests := []struct {
 		name    string
@@ -321,6 +448,7 @@ func Test_userSvc_ChangePassword(t *testing.T) {
 				},
 			},
 			args: args{
+				principal: auth.Token{Subject: userID, Role: models.UserRole},
 				req: models.ChangePasswordRequest{
 					UserID:         userID,
 					OldPassword:    "secret-drowssap",
@@ -339,6 +467,7 @@ func Test_userSvc_ChangePassword(t *testing.T) {
 				},
 			},
 			args: args{
+				principal: auth.Token{Subject: userID, Role: models.UserRole},
 				req: models.ChangePasswordRequest{
 					UserID:         userID,
 					OldPassword:    "wrong-password",
@@ -357,6 +486,7 @@ func Test_userSvc_ChangePassword(t *testing.T) {
 				},
 			},
 			args: args{
+				principal: adminPrincipal(),
 				req: models.ChangePasswordRequest{
 					UserID:         id.New(),
 					OldPassword:    "secret-drowssap",
@@ -375,6 +505,7 @@ func Test_userSvc_ChangePassword(t *testing.T) {
 				},
 			},
 			args: args{
+				principal: auth.Token{Subject: userID, Role: models.UserRole},
 				req: models.ChangePasswordRequest{
 					UserID:         userID,
 					OldPassword:    "secret-drowssap",
@@ -385,6 +516,62 @@ func Test_userSvc_ChangePassword(t *testing.T) {
 			want:    models.User{},
 			wantErr: true,
 		},
+		{
+			name: "happy-path-admin-changing-other-user",
+			fields: fields{
+				userRepo: &repotest.MockUserRepo{
+					FindUser: user,
+				},
+			},
+			args: args{
+				principal: adminPrincipal(),
+				req: models.ChangePasswordRequest{
+					UserID:         userID,
+					OldPassword:    "secret-drowssap",
+					NewPassword:    "secret-drowssap-2",
+					RepeatPassword: "secret-drowssap-2",
+				},
+			},
+			want:    user,
+			wantErr: false,
+		},
+		{
+			name: "sad-path-other-user",
+			fields: fields{
+				userRepo: &repotest.MockUserRepo{
+					FindUser: user,
+				},
+			},
+			args: args{
+				principal: auth.Token{Subject: id.New(), Role: models.UserRole},
+				req: models.ChangePasswordRequest{
+					UserID:         userID,
+					OldPassword:    "secret-drowssap",
+					NewPassword:    "secret-drowssap-2",
+					RepeatPassword: "secret-drowssap-2",
+				},
+			},
+			want:    models.User{},
+			wantErr: true,
+		},
+		{
+			name: "sad-path-anonymous-principal",
+			fields: fields{
+				userRepo: &repotest.MockUserRepo{
+					FindUser: user,
+				},
+			},
+			args: args{
+				principal: auth.Token{},
+				req: models.ChangePasswordRequest{
+					OldPassword:    "secret-drowssap",
+					NewPassword:    "secret-drowssap-2",
+					RepeatPassword: "secret-drowssap-2",
+				},
+			},
+			want:    models.User{},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -392,16 +579,18 @@ func Test_userSvc_ChangePassword(t *testing.T) {
 				hasher:          hasher,
 				issuer:          issuer,
 				userRepo:        tt.fields.userRepo,
+				roleRepo:        roleRepo,
 				passwordChecker: &defaultChecker{minLength: 8},
 				saltLength:      25,
 			}
-			got, err := svc.ChangePassword(tt.args.req)
+			got, err := svc.ChangePassword(context.Background(), tt.args.principal, tt.args.req)
 			if (err != nil) != tt.wantErr {
-				t.Errorf("userSvc.ChangePassword() error = %v, wantErr %v", err, tt.wantErr)
+				t.Errorf("userSvc.ChangePassword(context.Background()) error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
 
 			if tt.wantErr {
+				assert.Equal(t, 0, tt.fields.userRepo.UpdateCredentialsInvocations)
 				return
 			}
 
@@ -445,10 +634,14 @@ func Test_userSvc_Find(t *testing.T) {
 	type fields struct {
 		userRepo *repotest.MockUserRepo
 	}
+	type args struct {
+		principal auth.Token
+		id        string
+	}
 	tests := []struct {
 		name    string
 		fields  fields
-		arg     string
+		args    args
 		want    models.User
 		wantErr bool
 	}{
@@ -459,10 +652,91 @@ func Test_userSvc_Find(t *testing.T) {
 					FindUser: user,
 				},
 			},
-			arg:     user.ID,
+			args: args{
+				principal: auth.Token{Subject: user.ID, Role: models.UserRole},
+				id:        user.ID,
+			},
+			want:    user,
+			wantErr: false,
+		},
+		{
+			name: "happy-path-admin-finding-other-user",
+			fields: fields{
+				userRepo: &repotest.MockUserRepo{
+					FindUser: user,
+				},
+			},
+			args: args{
+				principal: adminPrincipal(),
+				id:        user.ID,
+			},
 			want:    user,
 			wantErr: false,
 		},
+		{
+			name: "happy-path-client-granted-scope",
+			fields: fields{
+				userRepo: &repotest.MockUserRepo{
+					FindUser: user,
+				},
+			},
+			args: args{
+				principal: auth.Token{
+					Subject:     user.ID,
+					Role:        models.ClientRole,
+					Permissions: []string{models.ReadUsersPermission},
+					ClientID:    id.New(),
+					Scopes:      []string{models.OpenIDScope, models.ReadUsersPermission},
+				},
+				id: user.ID,
+			},
+			want:    user,
+			wantErr: false,
+		},
+		{
+			name: "sad-path-client-without-scope",
+			fields: fields{
+				userRepo: &repotest.MockUserRepo{
+					FindUser: user,
+				},
+			},
+			args: args{
+				principal: auth.Token{
+					Subject:  user.ID,
+					Role:     models.ClientRole,
+					ClientID: id.New(),
+					Scopes:   []string{models.OpenIDScope},
+				},
+				id: user.ID,
+			},
+			wantErr: true,
+		},
+		{
+			name: "sad-path-other-user",
+			fields: fields{
+				userRepo: &repotest.MockUserRepo{
+					FindUser: user,
+				},
+			},
+			args: args{
+				principal: auth.Token{Subject: id.New(), Role: models.UserRole},
+				id:        user.ID,
+			},
+			wantErr: true,
+		},
+		{
+			name: "sad-path-role-without-permission",
+			fields: fields{
+				userRepo: &repotest.MockUserRepo{
+					FindUser: user,
+				},
+			},
+			args: args{
+				principal: auth.Token{Subject: id.New(), Role: models.AdminRole},
+				id:        user.ID,
+			},
+			wantErr: true,
+		},
 		{
 			name: "sad-path-no-such-user",
 			fields: fields{
@@ -470,7 +744,10 @@ func Test_userSvc_Find(t *testing.T) {
 					FindErr: repository.ErrNoSuchUser,
 				},
 			},
-			arg:     id.New(),
+			args: args{
+				principal: adminPrincipal(),
+				id:        id.New(),
+			},
 			wantErr: true,
 		},
 	}
@@ -480,12 +757,13 @@ func Test_userSvc_Find(t *testing.T) {
 				hasher:          hasher,
 				issuer:          issuer,
 				userRepo:        tt.fields.userRepo,
+				roleRepo:        roleRepo,
 				passwordChecker: &defaultChecker{minLength: 8},
 				saltLength:      25,
 			}
-			got, err := svc.Find(tt.arg)
+			got, err := svc.Find(context.Background(), tt.args.principal, tt.args.id)
 			if (err != nil) != tt.wantErr {
-				t.Errorf("userSvc.Find() error = %v, wantErr %v", err, tt.wantErr)
+				t.Errorf("userSvc.Find(context.Background()) error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
 
@@ -504,3 +782,218 @@ func Test_userSvc_Find(t *testing.T) {
 		})
 	}
 }
+
+func adminPrincipal() auth.Token {
+	return auth.Token{
+		ID:          id.New(),
+		Subject:     id.New(),
+		Role:        models.AdminRole,
+		Permissions: []string{models.ReadUsersPermission, models.WriteUsersPermission},
+	}
+}
+
+func Test_userSvc_UpdateProfile(t *testing.T) {
+	user := models.NewUser("mail@mail.com", "Tester", "McTest", models.UserRole, models.Credentials{})
+	surname := "New Tester"
+	blank := "  "
+
+	tests := []struct {
+		name      string
+		userRepo  *repotest.MockUserRepo
+		principal auth.Token
+		req       models.UpdateProfileRequest
+		wantErr   int
+	}{
+		{
+			name: "happy-path",
+			userRepo: &repotest.MockUserRepo{
+				FindUser: user,
+			},
+			principal: auth.Token{Subject: user.ID, Role: models.UserRole},
+			req: models.UpdateProfileRequest{
+				UserID:  user.ID,
+				Surname: &surname,
+				Version: 1,
+			},
+		},
+		{
+			name: "happy-path-admin",
+			userRepo: &repotest.MockUserRepo{
+				FindUser: user,
+			},
+			principal: adminPrincipal(),
+			req: models.UpdateProfileRequest{
+				UserID:  user.ID,
+				Surname: &surname,
+				Version: 1,
+			},
+		},
+		{
+			name: "sad-path-other-user",
+			userRepo: &repotest.MockUserRepo{
+				FindUser: user,
+			},
+			principal: auth.Token{Subject: id.New(), Role: models.UserRole},
+			req: models.UpdateProfileRequest{
+				UserID:  user.ID,
+				Surname: &surname,
+				Version: 1,
+			},
+			wantErr: http.StatusForbidden,
+		},
+		{
+			name: "sad-path-blank-surname",
+			userRepo: &repotest.MockUserRepo{
+				FindUser: user,
+			},
+			principal: auth.Token{Subject: user.ID, Role: models.UserRole},
+			req: models.UpdateProfileRequest{
+				UserID:  user.ID,
+				Surname: &blank,
+				Version: 1,
+			},
+			wantErr: http.StatusBadRequest,
+		},
+		{
+			name: "sad-path-missing-version",
+			userRepo: &repotest.MockUserRepo{
+				FindUser: user,
+			},
+			principal: auth.Token{Subject: user.ID, Role: models.UserRole},
+			req: models.UpdateProfileRequest{
+				UserID:  user.ID,
+				Surname: &surname,
+			},
+			wantErr: http.StatusBadRequest,
+		},
+		{
+			name: "sad-path-stale-version",
+			userRepo: &repotest.MockUserRepo{
+				FindUser: user,
+			},
+			principal: auth.Token{Subject: user.ID, Role: models.UserRole},
+			req: models.UpdateProfileRequest{
+				UserID:  user.ID,
+				Surname: &surname,
+				Version: 2,
+			},
+			wantErr: http.StatusConflict,
+		},
+		{
+			name: "sad-path-concurrent-update",
+			userRepo: &repotest.MockUserRepo{
+				FindUser:         user,
+				UpdateProfileErr: repository.ErrVersionConflict,
+			},
+			principal: auth.Token{Subject: user.ID, Role: models.UserRole},
+			req: models.UpdateProfileRequest{
+				UserID:  user.ID,
+				Surname: &surname,
+				Version: 1,
+			},
+			wantErr: http.StatusConflict,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewUserService(hasher, issuer, tt.userRepo, roleRepo)
+			got, err := svc.UpdateProfile(context.Background(), tt.principal, tt.req)
+			if tt.wantErr != 0 {
+				assertStatus(t, tt.wantErr, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, surname, got.Surname)
+			assert.Equal(t, user.MiddleAndLastName, got.MiddleAndLastName)
+			assert.Equal(t, user.Email, got.Email)
+			assert.Equal(t, 2, got.Version)
+			assert.Equal(t, got, tt.userRepo.UpdateProfileArg)
+			assert.Equal(t, 1, tt.userRepo.UpdateProfileVersionArg)
+		})
+	}
+}
+
+func Test_userSvc_Login_RecordsLoginHistory(t *testing.T) {
+	assert := assert.New(t)
+	user := testUser()
+	loginRepo := &repotest.MockLoginHistoryRepo{}
+	userRepo := &repotest.MockUserRepo{FindByEmailUser: user}
+	svc := NewUserService(hasher, issuer, userRepo, roleRepo, WithLoginHistory(loginRepo))
+	client := models.ClientInfo{IP: "10.0.0.1", UserAgent: "test-agent"}
+
+	_, err := svc.Login(context.Background(), models.LoginRequest{Email: user.Email, Password: "secret-drowssap", Client: client})
+	assert.NoError(err)
+	assert.Equal(1, loginRepo.SaveInvocations)
+	assert.Equal(user.ID, loginRepo.SaveArg.UserID)
+	assert.True(loginRepo.SaveArg.Success)
+	assert.Equal("10.0.0.1", loginRepo.SaveArg.IP)
+	assert.Equal("test-agent", loginRepo.SaveArg.UserAgent)
+
+	_, err = svc.Login(context.Background(), models.LoginRequest{Email: user.Email, Password: "wrong-password", Client: client})
+	assertCode(t, CodeInvalidCredentials, err)
+	assert.Equal(2, loginRepo.SaveInvocations)
+	assert.False(loginRepo.SaveArg.Success)
+}
+
+func Test_userSvc_ValidatesRequests(t *testing.T) {
+	assert := assert.New(t)
+	user := testUser()
+	userRepo := &repotest.MockUserRepo{FindUser: user}
+	svc := NewUserService(hasher, issuer, userRepo, roleRepo)
+
+	_, err := svc.SignUp(context.Background(), models.SignupRequest{Email: "mail@mail.com"})
+	assertStatus(t, http.StatusBadRequest, err)
+	assertCode(t, httputil.CodeValidationFailed, err)
+	httpErr, ok := err.(*httputil.Error)
+	assert.True(ok)
+	assert.Equal([]models.FieldError{
+		{Field: "password", Message: "is required", MessageKey: "validation.required"},
+		{Field: "surname", Message: "is required", MessageKey: "validation.required"},
+		{Field: "middleAndLastName", Message: "is required", MessageKey: "validation.required"},
+	}, httpErr.Errors)
+
+	_, err = svc.Login(context.Background(), models.LoginRequest{Email: "mail@mail.com"})
+	assertStatus(t, http.StatusBadRequest, err)
+
+	_, err = svc.ChangePassword(context.Background(), auth.Token{Subject: user.ID}, models.ChangePasswordRequest{UserID: user.ID})
+	assertStatus(t, http.StatusBadRequest, err)
+
+	assert.Equal(0, userRepo.FindByEmailInvocations)
+	assert.Equal(0, userRepo.FindInvocations)
+	assert.Equal(0, userRepo.SaveInvocations)
+}
+
+func Test_userSvc_LogsWithContextLogger(t *testing.T) {
+	assert := assert.New(t)
+	core, logs := observer.New(zapcore.ErrorLevel)
+	ctx := httputil.ContextWithLogger(context.Background(), zap.New(core).With(zap.String("requestId", "request-1")))
+
+	userRepo := &repotest.MockUserRepo{FindByEmailErr: errors.New("connection refused")}
+	svc := NewUserService(hasher, issuer, userRepo, roleRepo)
+	_, err := svc.Login(ctx, models.LoginRequest{Email: "mail@mail.com", Password: "secret-drowssap"})
+	assertStatus(t, http.StatusInternalServerError, err)
+
+	entries := logs.All()
+	assert.Len(entries, 1)
+	assert.Equal("request-1", entries[0].ContextMap()["requestId"])
+	assert.Equal("pkg/service", entries[0].ContextMap()["package"])
+}
+
+func Test_userSvc_LogsWithInjectedLogger(t *testing.T) {
+	assert := assert.New(t)
+	core, logs := observer.New(zapcore.ErrorLevel)
+	userRepo := &repotest.MockUserRepo{FindByEmailErr: errors.New("connection refused")}
+	svc := NewUserService(hasher, issuer, userRepo, roleRepo, WithLogger(zap.New(core)))
+
+	_, err := svc.Login(context.Background(), models.LoginRequest{Email: "mail@mail.com", Password: "secret-drowssap"})
+	assertStatus(t, http.StatusInternalServerError, err)
+	assert.Len(logs.All(), 1)
+
+	requestCore, requestLogs := observer.New(zapcore.ErrorLevel)
+	ctx := httputil.ContextWithLogger(context.Background(), zap.New(requestCore))
+	_, err = svc.Login(ctx, models.LoginRequest{Email: "mail@mail.com", Password: "secret-drowssap"})
+	assertStatus(t, http.StatusInternalServerError, err)
+	assert.Len(requestLogs.All(), 1)
+	assert.Len(logs.All(), 1)
+}
@@ -0,0 +1,25 @@
+package service
+
+import (
+	"github.com/CzarSimon/user-service/pkg/httputil"
+	"github.com/CzarSimon/user-service/pkg/models"
+)
+
+type validatable interface {
+	Validate() error
+}
+
+// validate validates a request and maps validation errors to bad requests listing the invalid fields.
+func validate(req validatable) error {
+	err := req.Validate()
+	if err == nil {
+		return nil
+	}
+
+	validationErr, ok := err.(*models.ValidationError)
+	if !ok {
+		return httputil.ErrBadRequest()
+	}
+
+	return httputil.NewValidationError(validationErr.Errors...)
+}